@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"heisei/internal/server/archive"
+)
+
+// stringList is a flag that may be given several times
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+func runExport(ctx context.Context, a *admin, fs *flag.FlagSet, args []string) error {
+	out := fs.String("out", "", "archive file to write, stdout if empty")
+	ipMode := fs.String("ip", string(archive.IPKeep), "how author IPs are written: keep, mask, hash or drop")
+	salt := fs.String("ip-salt", "", "salt of the hashed IPs")
+	var categories stringList
+	fs.Var(&categories, "category", "export only the category with this slug, may be repeated")
+	if _, err := parse(fs, args, 0, 0); err != nil {
+		return err
+	}
+	mode, err := archive.ParseIPMode(*ipMode)
+	if err != nil {
+		return usageError(fs, "%v", err)
+	}
+
+	var w io.Writer = a.stdout
+	var file *os.File
+	if *out != "" {
+		if file, err = os.Create(*out); err != nil {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+	buf := bufio.NewWriter(w)
+
+	stats, err := archive.Export(ctx, a.db.DB, buf, archive.ExportOptions{
+		IPMode:     mode,
+		Salt:       *salt,
+		Categories: categories,
+	})
+	if err != nil {
+		return err
+	}
+	if err := buf.Flush(); err != nil {
+		return err
+	}
+	if file != nil {
+		if err := file.Close(); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(os.Stderr, "exported %d categories, %d threads and %d posts\n", stats.Categories, stats.Threads, stats.Posts)
+	return nil
+}
+
+func runImport(ctx context.Context, a *admin, fs *flag.FlagSet, args []string) error {
+	in := fs.String("in", "", "archive file to read, stdin if empty")
+	if _, err := parse(fs, args, 0, 0); err != nil {
+		return err
+	}
+
+	var r io.Reader = os.Stdin
+	if *in != "" {
+		file, err := os.Open(*in)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		r = file
+	}
+
+	stats, err := archive.Import(ctx, a.db.DB, r)
+	if stats != nil {
+		fmt.Fprintf(os.Stderr, "imported %d categories, %d threads and %d posts, skipped %d existing records\n",
+			stats.Categories, stats.Threads, stats.Posts, stats.Skipped)
+	}
+	return err
+}
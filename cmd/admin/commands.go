@@ -0,0 +1,358 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"heisei/internal/common/models"
+	"heisei/pkg/database"
+)
+
+func runMigrate(ctx context.Context, a *admin, fs *flag.FlagSet, args []string) error {
+	values, err := parse(fs, args, 1, 2)
+	if err != nil {
+		return err
+	}
+	sqlDB, err := a.db.DB.DB()
+	if err != nil {
+		return err
+	}
+
+	action, arg := values[0], ""
+	if len(values) > 1 {
+		arg = values[1]
+	}
+	switch action {
+	case "up":
+		if arg == "" {
+			err = database.RunMigrations(sqlDB)
+			break
+		}
+		n, convErr := strconv.Atoi(arg)
+		if convErr != nil || n <= 0 {
+			return usageError(fs, "invalid number of migrations %q", arg)
+		}
+		err = database.MigrateSteps(sqlDB, n)
+	case "down":
+		// Rolling back drops data, so only the last migration is rolled back
+		// unless a number is given
+		n := 1
+		if arg != "" {
+			var convErr error
+			if n, convErr = strconv.Atoi(arg); convErr != nil || n <= 0 {
+				return usageError(fs, "invalid number of migrations %q", arg)
+			}
+		}
+		err = database.MigrateSteps(sqlDB, -n)
+	case "force":
+		version, convErr := strconv.Atoi(arg)
+		if convErr != nil {
+			return usageError(fs, "invalid version %q", arg)
+		}
+		err = database.ForceMigration(sqlDB, version)
+	case "version":
+		if arg != "" {
+			return usageError(fs, "version takes no arguments")
+		}
+	default:
+		return usageError(fs, "unknown action %q", action)
+	}
+	if err != nil {
+		return err
+	}
+
+	version, dirty, err := database.MigrationVersion(ctx, sqlDB)
+	if err != nil {
+		return err
+	}
+	latest, err := database.LatestMigrationVersion()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(a.stdout, "version %d of %d", version, latest)
+	if dirty {
+		fmt.Fprint(a.stdout, " (dirty: fix the schema and force a version)")
+	}
+	fmt.Fprintln(a.stdout)
+	return nil
+}
+
+func runCategory(ctx context.Context, a *admin, fs *flag.FlagSet, args []string) error {
+	name := fs.String("name", "", "name of the new category")
+	slug := fs.String("slug", "", "slug of the new category")
+	parent := fs.String("parent", "", "slug of the section to place the category in")
+	description := fs.String("description", "", "description of the category")
+	position := fs.Int("position", 0, "position of the category among its siblings")
+	sort := fs.String("sort", models.ThreadSortBump, "default thread `order`: "+strings.Join(models.ThreadSorts, ", "))
+	maxPosts := fs.Int("max-posts", models.DefaultMaxPosts, "posts per thread, 0 for no limit")
+	readOnly := fs.Bool("read-only", false, "accept no new threads and posts")
+	values, err := parse(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+
+	switch values[0] {
+	case "list":
+		return listCategories(ctx, a)
+	case "create":
+	default:
+		return usageError(fs, "unknown action %q", values[0])
+	}
+	if *name == "" || *slug == "" {
+		return usageError(fs, "--name and --slug are required")
+	}
+	if !slices.Contains(models.ThreadSorts, *sort) {
+		return usageError(fs, "invalid sort order %q, expected one of %s", *sort, strings.Join(models.ThreadSorts, ", "))
+	}
+
+	dto := models.CategoryDTO{
+		Position:    *position,
+		Name:        *name,
+		Slug:        *slug,
+		Description: *description,
+		DefaultSort: *sort,
+		MaxPosts:    *maxPosts,
+		ReadOnly:    *readOnly,
+	}
+	if *parent != "" {
+		section, err := a.svc.Category.GetCategoryBySlug(ctx, *parent)
+		if err != nil {
+			return fmt.Errorf("section %q: %w", *parent, err)
+		}
+		dto.ParentID = &section.ID
+	}
+	category, err := a.svc.Category.CreateCategory(ctx, dto)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(a.stdout, "created category %d (%s)\n", category.ID, category.Slug)
+	return nil
+}
+
+func listCategories(ctx context.Context, a *admin) error {
+	categories, err := a.svc.Category.GetAllCategories(ctx)
+	if err != nil {
+		return err
+	}
+	return table(a.stdout, func(w io.Writer) {
+		fmt.Fprintln(w, "ID\tPARENT\tPOS\tSLUG\tNAME\tTHREADS\tSORT\tMAX POSTS\tREAD ONLY")
+		for _, category := range categories {
+			parent := "-"
+			if category.ParentID != nil {
+				parent = strconv.FormatUint(uint64(*category.ParentID), 10)
+			}
+			fmt.Fprintf(w, "%d\t%s\t%d\t%s\t%s\t%d\t%s\t%d\t%t\n", category.ID, parent, category.Position,
+				category.Slug, category.Name, category.ThreadCount, category.DefaultSort, category.MaxPosts, category.ReadOnly)
+		}
+	})
+}
+
+func runThread(ctx context.Context, a *admin, fs *flag.FlagSet, args []string) error {
+	values, err := parse(fs, args, 2, 3)
+	if err != nil {
+		return err
+	}
+	action := values[0]
+	if (action == "move") != (len(values) == 3) {
+		return usageError(fs, "unexpected number of arguments for %s", action)
+	}
+	id, err := parseID(fs, values[1])
+	if err != nil {
+		return err
+	}
+
+	switch action {
+	case "lock", "unlock":
+		if err := a.svc.Thread.SetLocked(ctx, id, action == "lock"); err != nil {
+			return err
+		}
+		fmt.Fprintf(a.stdout, "%sed thread %d\n", action, id)
+	case "delete":
+		if err := a.svc.Thread.DeleteThread(ctx, id); err != nil {
+			return err
+		}
+		fmt.Fprintf(a.stdout, "deleted thread %d\n", id)
+	case "move":
+		category, err := a.svc.Category.GetCategoryBySlug(ctx, values[2])
+		if err != nil {
+			return fmt.Errorf("category %q: %w", values[2], err)
+		}
+		if _, err := a.svc.Thread.MoveThread(ctx, id, category.ID); err != nil {
+			return err
+		}
+		fmt.Fprintf(a.stdout, "moved thread %d to %s\n", id, category.Slug)
+	default:
+		return usageError(fs, "unknown action %q", action)
+	}
+	return nil
+}
+
+func runPost(ctx context.Context, a *admin, fs *flag.FlagSet, args []string) error {
+	values, err := parse(fs, args, 2, -1)
+	if err != nil {
+		return err
+	}
+	if values[0] != "restore" {
+		return usageError(fs, "unknown action %q", values[0])
+	}
+	ids := make([]uint, len(values)-1)
+	for i, value := range values[1:] {
+		if ids[i], err = parseID(fs, value); err != nil {
+			return err
+		}
+	}
+
+	for _, id := range ids {
+		if err := a.svc.Post.RestorePost(ctx, id); err != nil {
+			return fmt.Errorf("post %d: %w", id, err)
+		}
+		fmt.Fprintf(a.stdout, "restored post %d\n", id)
+	}
+	return nil
+}
+
+func runBan(ctx context.Context, a *admin, fs *flag.FlagSet, args []string) error {
+	all := fs.Bool("all", false, "list expired bans too")
+	reason := fs.String("reason", "", "reason of the ban")
+	duration := fs.Duration("for", 0, "duration of the ban, e.g. 72h; permanent if 0")
+	values, err := parse(fs, args, 1, 2)
+	if err != nil {
+		return err
+	}
+	action := values[0]
+	if (action == "add" || action == "remove") != (len(values) == 2) {
+		return usageError(fs, "unexpected number of arguments for %s", action)
+	}
+
+	switch action {
+	case "list":
+		bans, err := a.svc.Ban.GetBans(ctx, *all)
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		return table(a.stdout, func(w io.Writer) {
+			fmt.Fprintln(w, "ID\tNETWORK\tCREATED\tEXPIRES\tREASON")
+			for _, ban := range bans {
+				expires := "never"
+				if ban.ExpiresAt != nil {
+					expires = ban.ExpiresAt.Local().Format(timeLayout)
+					if !ban.IsActive(now) {
+						expires += " (expired)"
+					}
+				}
+				fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", ban.ID, ban.Network, ban.CreatedAt.Local().Format(timeLayout), expires, ban.Reason)
+			}
+		})
+	case "add":
+		if *duration < 0 {
+			return usageError(fs, "invalid duration %s", *duration)
+		}
+		ban, err := a.svc.Ban.CreateBan(ctx, values[1], *reason, *duration)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(a.stdout, "banned %s (ban %d)\n", ban.Network, ban.ID)
+	case "remove":
+		id, err := parseID(fs, values[1])
+		if err != nil {
+			return err
+		}
+		if err := a.svc.Ban.DeleteBan(ctx, id); err != nil {
+			return err
+		}
+		fmt.Fprintf(a.stdout, "removed ban %d\n", id)
+	case "purge":
+		count, err := a.svc.Ban.PurgeExpiredBans(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(a.stdout, "removed %d expired bans\n", count)
+	default:
+		return usageError(fs, "unknown action %q", action)
+	}
+	return nil
+}
+
+func runRebuildCounters(ctx context.Context, a *admin, fs *flag.FlagSet, args []string) error {
+	dryRun := fs.Bool("dry-run", false, "only report the threads whose counters are wrong")
+	if _, err := parse(fs, args, 0, 0); err != nil {
+		return err
+	}
+	discrepancies, err := a.svc.Thread.ReconcileCounters(ctx, !*dryRun)
+	if err != nil {
+		return err
+	}
+	if len(discrepancies) == 0 {
+		fmt.Fprintln(a.stdout, "all thread counters are correct")
+		return nil
+	}
+
+	err = table(a.stdout, func(w io.Writer) {
+		fmt.Fprintln(w, "THREAD\tPOSTS\tACTUAL\tLAST POST\tACTUAL")
+		for _, d := range discrepancies {
+			fmt.Fprintf(w, "%d\t%d\t%d\t%s\t%s\n", d.ThreadID, d.PostCount, d.ActualPostCount,
+				d.LastPostAt.Local().Format(timeLayout), d.ActualLastPostAt.Local().Format(timeLayout))
+		}
+	})
+	if err != nil {
+		return err
+	}
+	if *dryRun {
+		fmt.Fprintf(a.stdout, "\n%d threads have wrong counters\n", len(discrepancies))
+	} else {
+		fmt.Fprintf(a.stdout, "\ncorrected the counters of %d threads\n", len(discrepancies))
+	}
+	return nil
+}
+
+func runStats(ctx context.Context, a *admin, fs *flag.FlagSet, args []string) error {
+	if _, err := parse(fs, args, 0, 0); err != nil {
+		return err
+	}
+	stats, err := a.svc.Category.GetBoardStats(ctx)
+	if err != nil {
+		return err
+	}
+	bans, err := a.svc.Ban.GetBans(ctx, false)
+	if err != nil {
+		return err
+	}
+
+	var threads, posts, deleted, recent int64
+	err = table(a.stdout, func(w io.Writer) {
+		fmt.Fprintln(w, "SLUG\tTHREADS\tPOSTS\tDELETED\t24H\tLAST POST")
+		for _, board := range stats {
+			lastPost := "-"
+			if board.LastPostAt != nil {
+				lastPost = board.LastPostAt.Local().Format(timeLayout)
+			}
+			fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\t%s\n", board.Slug, board.Threads, board.Posts, board.DeletedPosts, board.RecentPosts, lastPost)
+			threads += board.Threads
+			posts += board.Posts
+			deleted += board.DeletedPosts
+			recent += board.RecentPosts
+		}
+		fmt.Fprintf(w, "total\t%d\t%d\t%d\t%d\t\n", threads, posts, deleted, recent)
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(a.stdout, "\n%d categories, %d active bans\n", len(stats), len(bans))
+	return nil
+}
+
+// parseID reads the ID of a thread, post or ban
+func parseID(fs *flag.FlagSet, s string) (uint, error) {
+	id, err := strconv.ParseUint(s, 10, 0)
+	if err != nil || id == 0 {
+		return 0, usageError(fs, "invalid ID %q", s)
+	}
+	return uint(id), nil
+}
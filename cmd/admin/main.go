@@ -0,0 +1,218 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"sort"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"heisei/internal/server/config"
+	"heisei/internal/server/repositories"
+	"heisei/internal/server/services"
+	"heisei/pkg/database"
+	"heisei/pkg/utils"
+
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// errUsage is returned for invalid command lines; the usage has already
+// been printed
+var errUsage = errors.New("invalid usage")
+
+// timeLayout is used for times in the output
+const timeLayout = "2006-01-02 15:04:05"
+
+// admin holds what the commands work with
+type admin struct {
+	db     *database.Database
+	svc    *services.Services
+	stdout io.Writer
+}
+
+type command struct {
+	usage   string
+	summary string
+	run     func(ctx context.Context, a *admin, fs *flag.FlagSet, args []string) error
+}
+
+var commands = map[string]command{
+	"export": {
+		usage:   "export [--out file] [--ip keep|mask|hash|drop] [--ip-salt salt] [--category slug]...",
+		summary: "Write categories, threads and posts to an archive",
+		run:     runExport,
+	},
+	"import": {
+		usage:   "import [--in file]",
+		summary: "Restore an archive, skipping the records already present",
+		run:     runImport,
+	},
+	"migrate": {
+		usage:   "migrate up [N] | down [N] | force <version> | version",
+		summary: "Apply or roll back migrations, or clear a failed migration",
+		run:     runMigrate,
+	},
+	"category": {
+		usage:   "category list | create --name name --slug slug [flags]",
+		summary: "List or create categories",
+		run:     runCategory,
+	},
+	"thread": {
+		usage:   "thread lock|unlock|delete <id> | move <id> <category>",
+		summary: "Lock, unlock, delete or move a thread to the category with the given slug",
+		run:     runThread,
+	},
+	"post": {
+		usage:   "post restore <id>...",
+		summary: "Restore deleted posts",
+		run:     runPost,
+	},
+	"ban": {
+		usage:   "ban list [--all] | add <network> [--reason text] [--for duration] | remove <id> | purge",
+		summary: "Manage the networks banned from posting",
+		run:     runBan,
+	},
+	"rebuild-counters": {
+		usage:   "rebuild-counters [--dry-run]",
+		summary: "Check the post counts and last post times of all threads and correct them",
+		run:     runRebuildCounters,
+	},
+	"seed": {
+		usage:   "seed [--seed n] [--boards n] [--threads n] [--posts n] [flags]",
+		summary: "Fill an empty database with generated boards for demos and load tests",
+		run:     runSeed,
+	},
+	"stats": {
+		usage:   "stats",
+		summary: "Print thread and post counts per category",
+		run:     runStats,
+	},
+}
+
+func main() {
+	os.Exit(run())
+}
+
+// run executes the command line and returns the exit code, so that deferred
+// calls run before exiting
+func run() int {
+	configPath := flag.String("config", "configs/config.yaml", "path to the configuration file")
+	flag.Usage = usage
+	flag.Parse()
+
+	args := flag.Args()
+	if len(args) == 0 || args[0] == "help" {
+		usage()
+		if len(args) == 0 {
+			return 2
+		}
+		return 0
+	}
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", args[0])
+		usage()
+		return 2
+	}
+
+	cfg, err := config.LoadConfig(*configPath)
+	if err != nil {
+		log.Printf("Failed to load config: %v", err)
+		return 1
+	}
+	// Tables and archives are written to stdout, so logs go to stderr
+	logger := utils.NewLogger(cfg.Log.Level, os.Stderr)
+
+	db, err := database.NewDatabase(cfg)
+	if err != nil {
+		log.Printf("Failed to connect to database: %v", err)
+		return 1
+	}
+	defer db.Close()
+	db.Logger = gormlogger.New(log.New(os.Stderr, "", log.LstdFlags), gormlogger.Config{
+		SlowThreshold: time.Second,
+		LogLevel:      gormlogger.Warn,
+	})
+
+	a := &admin{
+		db:     db,
+		svc:    services.NewServices(repositories.NewRepositories(db.DB), logger),
+		stdout: os.Stdout,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	fs := flag.NewFlagSet(args[0], flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: heisei_admin %s\n\n%s\n\nFlags:\n", cmd.usage, cmd.summary)
+		fs.PrintDefaults()
+	}
+	err = cmd.run(ctx, a, fs, args[1:])
+	if errors.Is(err, errUsage) {
+		return 2
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", args[0], err)
+		return 1
+	}
+	return 0
+}
+
+func usage() {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintln(os.Stderr, "Usage: heisei_admin [-config file] command [flags] [args]\n\nCommands:")
+	w := tabwriter.NewWriter(os.Stderr, 0, 0, 2, ' ', 0)
+	for _, name := range names {
+		fmt.Fprintf(w, "  %s\t%s\n", commands[name].usage, commands[name].summary)
+	}
+	w.Flush()
+}
+
+// parse parses flags and between min and max positional arguments, which
+// may appear in any order; a negative max allows any number
+func parse(fs *flag.FlagSet, args []string, min, max int) ([]string, error) {
+	var values []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			// The flag package has printed the error and the usage
+			return nil, errUsage
+		}
+		if fs.NArg() == 0 {
+			break
+		}
+		values = append(values, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+
+	if len(values) < min || (max >= 0 && len(values) > max) {
+		return nil, usageError(fs, "unexpected number of arguments: %d", len(values))
+	}
+	return values, nil
+}
+
+// usageError prints a problem with the command line and the usage
+func usageError(fs *flag.FlagSet, format string, args ...interface{}) error {
+	fmt.Fprintf(os.Stderr, format+"\n", args...)
+	fs.Usage()
+	return errUsage
+}
+
+// table prints tab separated rows as aligned columns
+func table(w io.Writer, rows func(w io.Writer)) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	rows(tw)
+	return tw.Flush()
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"time"
+
+	"heisei/internal/server/seed"
+)
+
+// dateLayout is accepted by --end besides RFC 3339
+const dateLayout = "2006-01-02"
+
+func runSeed(ctx context.Context, a *admin, fs *flag.FlagSet, args []string) error {
+	defaults := seed.DefaultConfig()
+	seedValue := fs.Int64("seed", defaults.Seed, "selects the data; the same flags give the same data")
+	sections := fs.Int("sections", defaults.Sections, "number of sections; 0 for top-level boards")
+	boards := fs.Int("boards", defaults.Boards, "number of boards per section")
+	threads := fs.Int("threads", defaults.Threads, "number of threads per board")
+	posts := fs.Int("posts", defaults.Posts, "average number of posts per thread")
+	language := fs.String("lang", defaults.Language, "language of the text: ja, en or mixed")
+	end := fs.String("end", "", "time of the latest post, as 2006-01-02 or RFC 3339; the start of today (UTC) if empty")
+	span := fs.Duration("span", defaults.Span, "period before --end over which the posts are spread")
+	if _, err := parse(fs, args, 0, 0); err != nil {
+		return err
+	}
+
+	cfg := seed.Config{
+		Seed:     *seedValue,
+		Sections: *sections,
+		Boards:   *boards,
+		Threads:  *threads,
+		Posts:    *posts,
+		Language: *language,
+		End:      time.Now().UTC().Truncate(24 * time.Hour),
+		Span:     *span,
+	}
+	if *end != "" {
+		t, err := time.Parse(dateLayout, *end)
+		if err != nil {
+			if t, err = time.Parse(time.RFC3339, *end); err != nil {
+				return usageError(fs, "invalid end time %q", *end)
+			}
+		}
+		cfg.End = t.UTC()
+	}
+	if err := cfg.Validate(); err != nil {
+		return usageError(fs, "%v", err)
+	}
+
+	stats, err := seed.Seed(ctx, a.db.DB, cfg)
+	if stats != nil {
+		fmt.Fprintf(a.stdout, "created %d categories, %d threads and %d posts\n", stats.Categories, stats.Threads, stats.Posts)
+	}
+	return err
+}
@@ -1,34 +1,114 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
 	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
 
 	"heisei/internal/client/api"
+	"heisei/internal/client/cli"
 	"heisei/internal/client/config"
+	"heisei/internal/client/state"
 	"heisei/internal/client/tui"
+	"heisei/pkg/tracing"
 	"heisei/pkg/utils"
+
+	"go.uber.org/zap"
 )
 
 func main() {
+	configPath := flag.String("config", "configs/config.yaml", "path to the configuration file")
+	flag.Usage = func() {
+		cli.New(nil, nil, nil, flag.CommandLine.Output()).Usage()
+		fmt.Fprintln(flag.CommandLine.Output(), "\nFlags:")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
 	// Load configuration
-	cfg, err := config.LoadConfig()
+	cfg, err := config.LoadConfig(*configPath)
 	if err != nil {
 		log.Fatalf("Failed to load config: %v", err)
 	}
 
-	// Initialize logger
-	logger := utils.GetLogger()
+	// Initialize tracing so that requests to the server carry a trace context
+	shutdownTracing, err := tracing.Init(cfg.Client.Tracing, "heisei-client")
+	if err != nil {
+		log.Fatalf("Failed to initialize tracing: %v", err)
+	}
+	defer shutdownTracing(context.Background())
 
 	// Initialize API client
-	apiClient := api.NewClient(cfg.Server.URL)
+	apiClient := api.NewClient(cfg.Client.ServerURL,
+		api.WithTimeout(cfg.Client.Connection.Timeout),
+		api.WithRetries(cfg.Client.Connection.RetryAttempts, api.DefaultRetryBackoff),
+		api.WithLanguage(cfg.Client.UI.Language),
+	)
+
+	// Run a subcommand instead of the TUI if one is given
+	if flag.NArg() > 0 {
+		code := runCommand(apiClient, flag.Args())
+		// os.Exit skips the deferred calls, so flush the traces first
+		shutdownTracing(context.Background())
+		os.Exit(code)
+	}
+
+	// The TUI draws on the terminal, so the log goes to a file
+	logger, logFile, err := openLog(cfg.Client.ServerURL)
+	if err != nil {
+		log.Fatalf("Failed to open log: %v", err)
+	}
+	defer logFile.Close()
 
 	// Initialize and run TUI application
 	app, err := tui.NewApp(cfg, apiClient, logger)
 	if err != nil {
-		logger.Fatal("Failed to initialize TUI", err)
+		logger.Error("Failed to initialize TUI", zap.Error(err))
+		log.Fatalf("Failed to initialize TUI: %v", err)
 	}
 
 	if err := app.Run(); err != nil {
-		logger.Fatal("Application error", err)
+		logger.Error("Application error", zap.Error(err))
+		log.Fatalf("Application error: %v", err)
+	}
+}
+
+// openLog returns a logger appending to client.log in the state directory of
+// the server
+func openLog(serverURL string) (*zap.Logger, *os.File, error) {
+	dir, err := state.DefaultDir(serverURL)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, nil, err
+	}
+	f, err := os.OpenFile(filepath.Join(dir, "client.log"), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, nil, err
+	}
+	return utils.NewLogger("info", f), f, nil
+}
+
+// runCommand runs a non-interactive subcommand and returns the exit code
+func runCommand(apiClient *api.Client, args []string) int {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	err := cli.New(apiClient, os.Stdin, os.Stdout, os.Stderr).Run(ctx, args)
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, cli.ErrUsage):
+		return 2
+	default:
+		fmt.Fprintf(os.Stderr, "heisei_client: %v\n", err)
+		return 1
 	}
 }
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"heisei/internal/client/api"
+	"heisei/internal/client/loadgen"
+)
+
+func main() {
+	os.Exit(run())
+}
+
+// run executes the load test and returns the exit code
+func run() int {
+	serverURL := flag.String("url", "http://localhost:8080", "base URL of the server")
+	users := flag.Int("users", 10, "number of users browsing, reading and posting")
+	mixFlag := flag.String("mix", "browse=5,read=4,post=1", "relative weights of the actions of the users")
+	think := flag.Duration("think", time.Second, "average pause of a user between two actions")
+	subscribers := flag.Int("subscribers", 10, "number of subscribers polling watched threads")
+	watch := flag.Int("watch", 5, "number of threads watched by each subscriber")
+	poll := flag.Duration("poll", 5*time.Second, "poll interval of the subscribers, as client.ui.refresh_rate")
+	duration := flag.Duration("duration", 30*time.Second, "measured duration of the test, after the ramp-up")
+	ramp := flag.Duration("ramp", 5*time.Second, "period over which the users and subscribers start")
+	timeout := flag.Duration("timeout", api.DefaultTimeout, "timeout of each request")
+	retries := flag.Int("retries", 0, "retries of failed idempotent requests, as client.connection.retry_attempts")
+	seed := flag.Int64("seed", 1, "selects the actions and threads of the users")
+	format := flag.String("format", "text", "report format: text or json")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: heisei_loadgen [flags]\n\n"+
+			"Simulates users and subscribers against a server and reports latency percentiles,\n"+
+			"error rates and throughput per operation. The server needs threads, e.g. from\n"+
+			"heisei_admin seed.\n\nFlags:\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	mix, err := loadgen.ParseMix(*mixFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid -mix: %v\n", err)
+		return 2
+	}
+	if *format != "text" && *format != "json" {
+		fmt.Fprintf(os.Stderr, "invalid -format %q\n", *format)
+		return 2
+	}
+	cfg := loadgen.Config{
+		Users:        *users,
+		Mix:          mix,
+		ThinkTime:    *think,
+		Subscribers:  *subscribers,
+		Watch:        *watch,
+		PollInterval: *poll,
+		Ramp:         *ramp,
+		Duration:     *duration,
+		Seed:         *seed,
+	}
+	if err := cfg.Validate(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	// Every simulated person keeps a connection open, as separate TUIs would
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConnsPerHost = cfg.Users + cfg.Subscribers
+	client := api.NewClient(*serverURL,
+		api.WithHTTPClient(&http.Client{Timeout: *timeout, Transport: transport}),
+		api.WithRetries(*retries, api.DefaultRetryBackoff),
+	)
+
+	// Interrupting ends the test early and still prints the report
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	fmt.Fprintf(os.Stderr, "%d users (%s) and %d subscribers against %s for %s after a %s ramp-up\n",
+		cfg.Users, cfg.Mix, cfg.Subscribers, *serverURL, cfg.Duration, cfg.Ramp)
+	report, err := loadgen.New(client, cfg).Run(ctx)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	if *format == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(report)
+	} else {
+		err = report.WriteText(os.Stdout)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
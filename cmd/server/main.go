@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"log"
 	"net/http"
@@ -11,19 +12,22 @@ import (
 	"time"
 
 	"heisei/internal/server/api/handlers"
-	"heisei/internal/server/api/middleware"
 	"heisei/internal/server/config"
 	"heisei/internal/server/repositories"
 	"heisei/internal/server/services"
 	"heisei/pkg/database"
+	"heisei/pkg/tracing"
 	"heisei/pkg/utils"
 
 	"go.uber.org/zap"
 )
 
 func main() {
+	configPath := flag.String("config", "configs/config.yaml", "path to the configuration file")
+	flag.Parse()
+
 	// Load configuration
-	cfg, err := config.LoadConfig()
+	cfg, err := config.LoadConfig(*configPath)
 	if err != nil {
 		log.Fatalf("Failed to load config: %v", err)
 	}
@@ -32,6 +36,13 @@ func main() {
 	utils.InitLogger(cfg.Log.Level)
 	logger := utils.GetLogger()
 
+	// Initialize tracing
+	shutdownTracing, err := tracing.Init(cfg.Tracing, "heisei-server")
+	if err != nil {
+		logger.Error("Failed to initialize tracing", zap.Error(err))
+		os.Exit(1)
+	}
+
 	// Initialize database
 	db, err := database.NewDatabase(cfg)
 	if err != nil {
@@ -53,18 +64,19 @@ func main() {
 
 	// Initialize repositories and services
 	repos := repositories.NewRepositories(db.DB)
-	services := services.NewServices(repos, logger)
+	svc := services.NewServices(repos, logger)
 
-	// Initialize handlers and middleware
-	h := handlers.NewHandlers(services, logger)
-	m := middleware.NewMiddleware(logger)
+	// Correct thread counters that drifted from their posts
+	jobCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	if cfg.Database.ReconcileInterval > 0 {
+		go svc.Thread.RunCounterReconciliation(jobCtx, cfg.Database.ReconcileInterval)
+	}
 
 	// Set up HTTP server
-	mux := h.SetupRoutes()
-
 	srv := &http.Server{
 		Addr:    fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port),
-		Handler: m.LoggingMiddleware(mux),
+		Handler: handlers.NewHandler(svc, cfg, logger),
 	}
 
 	// Start server
@@ -80,6 +92,14 @@ func main() {
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 	logger.Info("Server is shutting down...")
+	stopJobs()
+
+	// Fail readiness checks first so that no new traffic is routed here while draining
+	svc.Health.StartDraining()
+	if cfg.Server.ShutdownDelay > 0 {
+		logger.Info("Draining connections", zap.Duration("delay", cfg.Server.ShutdownDelay))
+		time.Sleep(cfg.Server.ShutdownDelay)
+	}
 
 	// Gracefully shutdown the server
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
@@ -87,6 +107,9 @@ func main() {
 	if err := srv.Shutdown(ctx); err != nil {
 		logger.Error("Server forced to shutdown", zap.Error(err))
 	}
+	if err := shutdownTracing(ctx); err != nil {
+		logger.Error("Failed to flush traces", zap.Error(err))
+	}
 
 	logger.Info("Server exiting")
 }
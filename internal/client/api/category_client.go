@@ -1,60 +1,53 @@
 package api
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
-	"heisei/internal/common/models"
 	"net/http"
+
+	"heisei/internal/common/models"
 )
 
+// CategoryClient calls the category endpoints
 type CategoryClient struct {
-	baseURL string
-	client  *http.Client
-}
-
-func NewCategoryClient(baseURL string, client *http.Client) *CategoryClient {
-	return &CategoryClient{
-		baseURL: baseURL,
-		client:  client,
-	}
+	r *requester
 }
 
-func (c *CategoryClient) GetCategories() ([]models.CategoryDTO, error) {
-	resp, err := c.client.Get(fmt.Sprintf("%s/api/categories", c.baseURL))
-	if err != nil {
-		return nil, fmt.Errorf("failed to get categories: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
-	}
-
+func (c *CategoryClient) GetCategories(ctx context.Context) ([]models.CategoryDTO, error) {
 	var categories []models.CategoryDTO
-	if err := json.NewDecoder(resp.Body).Decode(&categories); err != nil {
-		return nil, fmt.Errorf("failed to decode categories: %w", err)
+	if err := c.r.do(ctx, http.MethodGet, "/categories", nil, nil, &categories, http.StatusOK); err != nil {
+		return nil, fmt.Errorf("failed to get categories: %w", err)
 	}
-
 	return categories, nil
 }
 
-func (c *CategoryClient) GetCategoryByID(id uint) (*models.CategoryDTO, error) {
-	resp, err := c.client.Get(fmt.Sprintf("%s/api/categories/%d", c.baseURL, id))
-	if err != nil {
+func (c *CategoryClient) GetCategoryByID(ctx context.Context, id uint) (*models.CategoryDTO, error) {
+	var category models.CategoryDTO
+	if err := c.r.do(ctx, http.MethodGet, fmt.Sprintf("/categories/%d", id), nil, nil, &category, http.StatusOK); err != nil {
 		return nil, fmt.Errorf("failed to get category: %w", err)
 	}
-	defer resp.Body.Close()
+	return &category, nil
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+func (c *CategoryClient) CreateCategory(ctx context.Context, req models.CategoryRequest) (*models.CategoryDTO, error) {
+	var category models.CategoryDTO
+	if err := c.r.do(ctx, http.MethodPost, "/categories", nil, req, &category, http.StatusCreated); err != nil {
+		return nil, fmt.Errorf("failed to create category: %w", err)
 	}
+	return &category, nil
+}
 
+func (c *CategoryClient) UpdateCategory(ctx context.Context, id uint, req models.CategoryRequest) (*models.CategoryDTO, error) {
 	var category models.CategoryDTO
-	if err := json.NewDecoder(resp.Body).Decode(&category); err != nil {
-		return nil, fmt.Errorf("failed to decode category: %w", err)
+	if err := c.r.do(ctx, http.MethodPut, fmt.Sprintf("/categories/%d", id), nil, req, &category, http.StatusOK); err != nil {
+		return nil, fmt.Errorf("failed to update category: %w", err)
 	}
-
 	return &category, nil
 }
 
-// Additional methods like CreateCategory, UpdateCategory, DeleteCategory can be added here
+func (c *CategoryClient) DeleteCategory(ctx context.Context, id uint) error {
+	if err := c.r.do(ctx, http.MethodDelete, fmt.Sprintf("/categories/%d", id), nil, nil, nil, http.StatusNoContent); err != nil {
+		return fmt.Errorf("failed to delete category: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Defaults used by NewClient unless overridden by an Option
+const (
+	DefaultTimeout       = 10 * time.Second
+	DefaultRetryAttempts = 3
+	DefaultRetryBackoff  = 200 * time.Millisecond
+)
+
+// Client is the typed SDK of the Heisei server API.
+// Every call takes a context; idempotent calls are retried on transient
+// failures and server errors are returned as *APIError.
+type Client struct {
+	*CategoryClient
+	*ThreadClient
+	*PostClient
+	r *requester
+}
+
+type options struct {
+	timeout       time.Duration
+	retryAttempts int
+	retryBackoff  time.Duration
+	language      string
+	httpClient    *http.Client
+}
+
+// Option configures a Client
+type Option func(*options)
+
+// WithTimeout bounds each HTTP attempt, including reading the response
+func WithTimeout(timeout time.Duration) Option {
+	return func(o *options) { o.timeout = timeout }
+}
+
+// WithRetries sets how many times idempotent requests are retried and the
+// initial backoff, which doubles after each attempt
+func WithRetries(attempts int, backoff time.Duration) Option {
+	return func(o *options) {
+		o.retryAttempts = attempts
+		o.retryBackoff = backoff
+	}
+}
+
+// WithLanguage asks the server for error messages in the given language
+func WithLanguage(language string) Option {
+	return func(o *options) { o.language = language }
+}
+
+// WithHTTPClient replaces the HTTP client; WithTimeout is then ignored
+func WithHTTPClient(client *http.Client) Option {
+	return func(o *options) { o.httpClient = client }
+}
+
+// NewClient creates a client for the server at baseURL, e.g. http://localhost:8080
+func NewClient(baseURL string, opts ...Option) *Client {
+	o := options{
+		timeout:       DefaultTimeout,
+		retryAttempts: DefaultRetryAttempts,
+		retryBackoff:  DefaultRetryBackoff,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.httpClient == nil {
+		o.httpClient = NewHTTPClient(o.timeout)
+	}
+
+	r := &requester{
+		baseURL:       strings.TrimRight(baseURL, "/"),
+		client:        o.httpClient,
+		retryAttempts: max(o.retryAttempts, 0),
+		retryBackoff:  o.retryBackoff,
+	}
+	r.language.Store(o.language)
+	return &Client{
+		CategoryClient: &CategoryClient{r: r},
+		ThreadClient:   &ThreadClient{r: r},
+		PostClient:     &PostClient{r: r},
+		r:              r,
+	}
+}
+
+// SetLanguage changes the language of server error messages
+func (c *Client) SetLanguage(language string) {
+	c.r.language.Store(language)
+}
@@ -0,0 +1,101 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"heisei/internal/common/models"
+)
+
+func newTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	return NewClient(srv.URL, WithHTTPClient(srv.Client()), WithRetries(2, time.Millisecond))
+}
+
+func TestRetriesIdempotentRequests(t *testing.T) {
+	var calls int32
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`[{"id":1,"name":"News","slug":"news"}]`))
+	})
+
+	categories, err := client.GetCategories(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(categories) != 1 || categories[0].Slug != "news" {
+		t.Errorf("categories = %+v", categories)
+	}
+	if calls != 3 {
+		t.Errorf("server saw %d requests, want 3", calls)
+	}
+}
+
+func TestDoesNotRetryPost(t *testing.T) {
+	var calls int32
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	_, err := client.CreatePost(context.Background(), models.CreatePostRequest{ThreadID: 1, Content: "hi"})
+	if !errors.Is(err, ErrServer) {
+		t.Errorf("err = %v, want ErrServer", err)
+	}
+	if calls != 1 {
+		t.Errorf("server saw %d requests, want 1", calls)
+	}
+}
+
+func TestDoesNotRetryClientErrors(t *testing.T) {
+	var calls int32
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("X-Request-ID", "req-1")
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"code":"not_found","message":"thread not found"}`))
+	})
+
+	_, err := client.GetThreadByID(context.Background(), 42)
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatalf("err = %v, want ErrNotFound", err)
+	}
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Message != "thread not found" || apiErr.RequestID != "req-1" {
+		t.Errorf("APIError = %+v", apiErr)
+	}
+	if calls != 1 {
+		t.Errorf("server saw %d requests, want 1", calls)
+	}
+}
+
+func TestContextCancelsRetries(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "5")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+	client := NewClient(srv.URL, WithHTTPClient(srv.Client()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := client.GetThreads(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("call returned after %v", elapsed)
+	}
+}
@@ -0,0 +1,131 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"heisei/internal/common/models"
+	"heisei/internal/server/api/openapi"
+)
+
+type recordedRequest struct {
+	Method string
+	Path   string
+}
+
+// TestClientCallsMatchSpec calls every client method against a recording
+// server and checks that each request targets a documented operation
+func TestClientCallsMatchSpec(t *testing.T) {
+	var mu sync.Mutex
+	var requests []recordedRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests = append(requests, recordedRequest{Method: r.Method, Path: r.URL.Path})
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		switch r.Method {
+		case http.MethodPost:
+			w.WriteHeader(http.StatusCreated)
+		case http.MethodDelete:
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		// null decodes into both slices and structs
+		w.Write([]byte("null"))
+	}))
+	defer srv.Close()
+
+	client := NewClient(srv.URL, WithHTTPClient(srv.Client()), WithRetries(0, 0))
+	ctx := context.Background()
+
+	calls := map[string]func() error{
+		"GetCategories":   func() error { _, err := client.GetCategories(ctx); return err },
+		"GetCategoryByID": func() error { _, err := client.GetCategoryByID(ctx, 1); return err },
+		"CreateCategory": func() error {
+			_, err := client.CreateCategory(ctx, models.CategoryRequest{Name: "News", Slug: "news"})
+			return err
+		},
+		"UpdateCategory": func() error {
+			_, err := client.UpdateCategory(ctx, 1, models.CategoryRequest{Name: "News", Slug: "news"})
+			return err
+		},
+		"DeleteCategory":       func() error { return client.DeleteCategory(ctx, 1) },
+		"GetThreads":           func() error { _, err := client.GetThreads(ctx); return err },
+		"GetThreadsByCategory": func() error { _, err := client.GetThreadsByCategory(ctx, 1, models.ThreadSortBump); return err },
+		"SearchThreads":        func() error { _, err := client.SearchThreads(ctx, 1, "title"); return err },
+		"GetThreadByID":        func() error { _, err := client.GetThreadByID(ctx, 1); return err },
+		"CreateThread": func() error {
+			_, err := client.CreateThread(ctx, models.CreateThreadRequest{CategoryID: 1, Title: "title"})
+			return err
+		},
+		"UpdateThread": func() error {
+			_, err := client.UpdateThread(ctx, 1, models.UpdateThreadRequest{CategoryID: 1, Title: "title"})
+			return err
+		},
+		"DeleteThread":     func() error { return client.DeleteThread(ctx, 1) },
+		"GetPostsByThread": func() error { _, err := client.GetPostsByThread(ctx, 1); return err },
+		"GetPostByID":      func() error { _, err := client.GetPostByID(ctx, 1); return err },
+		"CreatePost": func() error {
+			_, err := client.CreatePost(ctx, models.CreatePostRequest{ThreadID: 1, Content: "content"})
+			return err
+		},
+		"UpdatePost": func() error {
+			_, err := client.UpdatePost(ctx, 1, models.UpdatePostRequest{Content: "content"})
+			return err
+		},
+		"DeletePost": func() error { return client.DeletePost(ctx, 1) },
+	}
+	for name, call := range calls {
+		if err := call(); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+
+	ops, err := openapi.Operations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, req := range requests {
+		if !matchesAny(ops, req) {
+			t.Errorf("client request %s %s does not match any documented operation", req.Method, req.Path)
+		}
+	}
+	if len(requests) != len(calls) {
+		t.Errorf("recorded %d requests for %d calls", len(requests), len(calls))
+	}
+}
+
+func matchesAny(ops []openapi.Operation, req recordedRequest) bool {
+	for _, op := range ops {
+		if op.Method == req.Method && matchesTemplate(op.Path, req.Path) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesTemplate reports whether path fits a template such as /threads/{id}
+func matchesTemplate(template, path string) bool {
+	want := strings.Split(template, "/")
+	got := strings.Split(path, "/")
+	if len(want) != len(got) {
+		return false
+	}
+	for i := range want {
+		if strings.HasPrefix(want[i], "{") && strings.HasSuffix(want[i], "}") {
+			if got[i] == "" {
+				return false
+			}
+			continue
+		}
+		if want[i] != got[i] {
+			return false
+		}
+	}
+	return true
+}
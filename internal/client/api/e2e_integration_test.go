@@ -0,0 +1,113 @@
+//go:build integration
+
+package api_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"heisei/internal/client/api"
+	"heisei/internal/common/models"
+	"heisei/internal/server/testserver"
+	"heisei/pkg/database/dbtest"
+)
+
+func TestMain(m *testing.M) { dbtest.Main(m) }
+
+// TestClientEndToEnd drives a board through the client against the real
+// server and database
+func TestClientEndToEnd(t *testing.T) {
+	srv := testserver.New(t)
+	client := srv.Client
+	ctx := context.Background()
+
+	category, err := client.CreateCategory(ctx, models.CategoryRequest{Name: "News", Slug: "news"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if category.DefaultSort != models.ThreadSortBump || category.MaxPosts != models.DefaultMaxPosts {
+		t.Errorf("category defaults = %s, %d posts", category.DefaultSort, category.MaxPosts)
+	}
+	if _, err := client.CreateCategory(ctx, models.CategoryRequest{Name: "Again", Slug: "news"}); !errors.Is(err, api.ErrConflict) {
+		t.Errorf("duplicate slug: err = %v, want a conflict", err)
+	}
+	sport, err := client.CreateCategory(ctx, models.CategoryRequest{Name: "Sport", Slug: "sport"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.UpdateCategory(ctx, sport.ID, models.CategoryRequest{Name: "Sport", Slug: "news"}); !errors.Is(err, api.ErrConflict) {
+		t.Errorf("duplicate slug on update: err = %v, want a conflict", err)
+	}
+	if _, err := client.UpdateCategory(ctx, sport.ID, models.CategoryRequest{Name: "Sports", Slug: "sport"}); err != nil {
+		t.Errorf("keeping the slug: %v", err)
+	}
+
+	thread, err := client.CreateThread(ctx, models.CreateThreadRequest{CategoryID: category.ID, Title: "Hello"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, content := range []string{"first", "second"} {
+		if _, err := client.CreatePost(ctx, models.CreatePostRequest{ThreadID: thread.ID, Content: content}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	sage, err := client.CreatePost(ctx, models.CreatePostRequest{ThreadID: thread.ID, Content: "quiet", Sage: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	posts, err := client.GetPostsByThread(ctx, thread.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(posts) != 3 || posts[2].ID != sage.ID || !posts[2].Sage {
+		t.Fatalf("posts = %+v, want 3 ending with the sage post", posts)
+	}
+	got, err := client.GetThreadByID(ctx, thread.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.PostCount != 3 || !got.BumpedAt.Equal(posts[1].CreatedAt) {
+		t.Errorf("thread = %+v, want 3 posts, bumped by the second", got)
+	}
+
+	if _, err := client.UpdatePost(ctx, sage.ID, models.UpdatePostRequest{Content: "edited"}); err != nil {
+		t.Fatal(err)
+	}
+	if post, err := client.GetPostByID(ctx, sage.ID); err != nil || post.Content != "edited" {
+		t.Errorf("updated post = %+v, %v", post, err)
+	}
+
+	found, err := client.SearchThreads(ctx, category.ID, "hell")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found) != 1 || found[0].ID != thread.ID {
+		t.Errorf("search found %+v, want the thread", found)
+	}
+
+	if err := client.DeleteCategory(ctx, category.ID); err != nil {
+		t.Fatal(err)
+	}
+	_, err = client.GetThreadByID(ctx, thread.ID)
+	var apiErr *api.APIError
+	if !errors.As(err, &apiErr) || !errors.Is(err, api.ErrNotFound) || apiErr.RequestID == "" {
+		t.Errorf("thread of a deleted category: err = %v, want not found with a request ID", err)
+	}
+}
+
+func TestClientValidationErrors(t *testing.T) {
+	srv := testserver.New(t)
+	fixtures := srv.Seed(t)
+	ctx := context.Background()
+
+	_, err := srv.Client.CreatePost(ctx, models.CreatePostRequest{ThreadID: fixtures.Welcome.ID})
+	var apiErr *api.APIError
+	if !errors.As(err, &apiErr) || !errors.Is(err, api.ErrValidationFailed) || len(apiErr.Details) == 0 {
+		t.Fatalf("empty post: err = %v, want a validation error with details", err)
+	}
+	if apiErr.Details[0].Field != "content" {
+		t.Errorf("details = %+v, want the content field", apiErr.Details)
+	}
+}
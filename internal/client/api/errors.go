@@ -0,0 +1,107 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"heisei/internal/common/models"
+)
+
+// Sentinel errors matched by APIError through errors.Is
+var (
+	ErrBadRequest       = errors.New("bad request")
+	ErrNotFound         = errors.New("not found")
+	ErrConflict         = errors.New("conflict")
+	ErrValidationFailed = errors.New("validation failed")
+	ErrRateLimited      = errors.New("rate limited")
+	ErrServer           = errors.New("server error")
+	// ErrUnavailable matches network failures and 502, 503 and 504 responses
+	ErrUnavailable = errors.New("server unavailable")
+)
+
+// maxErrorBodySize bounds how much of an error response is read
+const maxErrorBodySize = 64 << 10
+
+// APIError is returned when the server answers with an error status
+type APIError struct {
+	StatusCode int
+	Code       string
+	Message    string
+	Details    []models.ErrorDetail
+	RequestID  string
+	// RetryAfter is the wait requested by the server, if any
+	RetryAfter time.Duration
+}
+
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("%s (status %d", e.Message, e.StatusCode)
+	if e.RequestID != "" {
+		msg += ", request " + e.RequestID
+	}
+	return msg + ")"
+}
+
+// Is lets callers match APIError against the sentinel errors of this package
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrBadRequest:
+		return e.StatusCode == http.StatusBadRequest
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrConflict:
+		return e.StatusCode == http.StatusConflict
+	case ErrValidationFailed:
+		return e.Code == models.ErrorCodeValidationFailed
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	case ErrServer:
+		return e.StatusCode >= http.StatusInternalServerError
+	case ErrUnavailable:
+		switch e.StatusCode {
+		case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+	}
+	return false
+}
+
+// decodeError builds an APIError from an error response.
+// Bodies that are not an ErrorResponse are used verbatim as the message.
+func decodeError(resp *http.Response) error {
+	apiErr := &APIError{
+		StatusCode: resp.StatusCode,
+		Code:       models.ErrorCodeForStatus(resp.StatusCode),
+		RequestID:  resp.Header.Get("X-Request-ID"),
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+	if err != nil {
+		apiErr.Message = http.StatusText(resp.StatusCode)
+		return apiErr
+	}
+
+	var errResp models.ErrorResponse
+	if json.Unmarshal(body, &errResp) == nil && errResp.Message != "" {
+		apiErr.Message = errResp.Message
+		apiErr.Details = errResp.Details
+		if errResp.Code != "" {
+			apiErr.Code = errResp.Code
+		}
+		if errResp.RequestID != "" {
+			apiErr.RequestID = errResp.RequestID
+		}
+		return apiErr
+	}
+
+	apiErr.Message = strings.TrimSpace(string(body))
+	if apiErr.Message == "" {
+		apiErr.Message = http.StatusText(resp.StatusCode)
+	}
+	return apiErr
+}
@@ -0,0 +1,179 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+// apiPrefix is the path prefix of the versioned server API
+const apiPrefix = "/api/v1"
+
+// maxRetryDelay caps the backoff between two attempts
+const maxRetryDelay = 10 * time.Second
+
+// NewHTTPClient creates the HTTP client shared by the API clients.
+// Requests carry the W3C trace context so that server spans join the client
+// trace.
+func NewHTTPClient(timeout time.Duration) *http.Client {
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: otelhttp.NewTransport(http.DefaultTransport),
+	}
+}
+
+// requester sends JSON requests to the server API on behalf of the resource clients
+type requester struct {
+	baseURL       string
+	client        *http.Client
+	retryAttempts int
+	retryBackoff  time.Duration
+	// language is sent as Accept-Language to get localized error messages
+	language atomic.Value
+}
+
+// do sends a request and decodes the response into out unless it is nil.
+// Idempotent requests are retried on network errors and on 429, 502, 503 and
+// 504 responses with exponential backoff.
+func (r *requester) do(ctx context.Context, method, path string, query url.Values, in, out interface{}, wantStatus int) error {
+	target := r.baseURL + apiPrefix + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+
+	var body []byte
+	if in != nil {
+		var err error
+		body, err = json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+	}
+
+	attempts := 1
+	if isIdempotent(method) {
+		attempts += r.retryAttempts
+	}
+
+	var lastErr error
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			if err := sleep(ctx, r.retryDelay(attempt, lastErr)); err != nil {
+				return err
+			}
+		}
+
+		resp, err := r.send(ctx, method, target, body)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			lastErr = fmt.Errorf("%w: %w", ErrUnavailable, err)
+			continue
+		}
+
+		if resp.StatusCode != wantStatus {
+			lastErr = decodeError(resp)
+			resp.Body.Close()
+			if isRetryableStatus(resp.StatusCode) {
+				continue
+			}
+			return lastErr
+		}
+
+		err = decodeBody(resp, out)
+		resp.Body.Close()
+		return err
+	}
+	return lastErr
+}
+
+func (r *requester) send(ctx context.Context, method, target string, body []byte) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if language, _ := r.language.Load().(string); language != "" {
+		req.Header.Set("Accept-Language", language)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return r.client.Do(req)
+}
+
+// retryDelay returns the wait before the given attempt, honouring Retry-After
+func (r *requester) retryDelay(attempt int, lastErr error) time.Duration {
+	var apiErr *APIError
+	if errors.As(lastErr, &apiErr) && apiErr.RetryAfter > 0 {
+		return min(apiErr.RetryAfter, maxRetryDelay)
+	}
+	delay := r.retryBackoff << (attempt - 1)
+	if delay <= 0 || delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+	// Full jitter spreads retries of concurrent clients
+	return time.Duration(rand.Int63n(int64(delay)) + 1)
+}
+
+func decodeBody(resp *http.Response, out interface{}) error {
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+func isRetryableStatus(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// parseRetryAfter reads a Retry-After header given in seconds
+func parseRetryAfter(value string) time.Duration {
+	seconds, err := strconv.Atoi(value)
+	if err != nil || seconds < 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -1,85 +1,53 @@
 package api
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
 	"fmt"
-	"heisei/internal/common/models"
 	"net/http"
+
+	"heisei/internal/common/models"
 )
 
+// PostClient calls the post endpoints
 type PostClient struct {
-	baseURL string
-	client  *http.Client
-}
-
-func NewPostClient(baseURL string, client *http.Client) *PostClient {
-	return &PostClient{
-		baseURL: baseURL,
-		client:  client,
-	}
+	r *requester
 }
 
-func (c *PostClient) GetPostsByThread(threadID uint) ([]models.PostDTO, error) {
-	resp, err := c.client.Get(fmt.Sprintf("%s/api/threads/%d/posts", c.baseURL, threadID))
-	if err != nil {
-		return nil, fmt.Errorf("failed to get posts: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
-	}
-
+func (c *PostClient) GetPostsByThread(ctx context.Context, threadID uint) ([]models.PostDTO, error) {
 	var posts []models.PostDTO
-	if err := json.NewDecoder(resp.Body).Decode(&posts); err != nil {
-		return nil, fmt.Errorf("failed to decode posts: %w", err)
+	if err := c.r.do(ctx, http.MethodGet, fmt.Sprintf("/threads/%d/posts", threadID), nil, nil, &posts, http.StatusOK); err != nil {
+		return nil, fmt.Errorf("failed to get posts: %w", err)
 	}
-
 	return posts, nil
 }
 
-func (c *PostClient) GetPostByID(id uint) (*models.PostDTO, error) {
-	resp, err := c.client.Get(fmt.Sprintf("%s/api/posts/%d", c.baseURL, id))
-	if err != nil {
-		return nil, fmt.Errorf("failed to get post: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
-	}
-
+func (c *PostClient) GetPostByID(ctx context.Context, id uint) (*models.PostDTO, error) {
 	var post models.PostDTO
-	if err := json.NewDecoder(resp.Body).Decode(&post); err != nil {
-		return nil, fmt.Errorf("failed to decode post: %w", err)
+	if err := c.r.do(ctx, http.MethodGet, fmt.Sprintf("/posts/%d", id), nil, nil, &post, http.StatusOK); err != nil {
+		return nil, fmt.Errorf("failed to get post: %w", err)
 	}
-
 	return &post, nil
 }
 
-func (c *PostClient) CreatePost(post models.PostDTO) (*models.PostDTO, error) {
-	body, err := json.Marshal(post)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal post: %w", err)
-	}
-
-	resp, err := c.client.Post(fmt.Sprintf("%s/api/posts", c.baseURL), "application/json", bytes.NewBuffer(body))
-	if err != nil {
+func (c *PostClient) CreatePost(ctx context.Context, req models.CreatePostRequest) (*models.PostDTO, error) {
+	var post models.PostDTO
+	if err := c.r.do(ctx, http.MethodPost, "/posts", nil, req, &post, http.StatusCreated); err != nil {
 		return nil, fmt.Errorf("failed to create post: %w", err)
 	}
-	defer resp.Body.Close()
+	return &post, nil
+}
 
-	if resp.StatusCode != http.StatusCreated {
-		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+func (c *PostClient) UpdatePost(ctx context.Context, id uint, req models.UpdatePostRequest) (*models.PostDTO, error) {
+	var post models.PostDTO
+	if err := c.r.do(ctx, http.MethodPut, fmt.Sprintf("/posts/%d", id), nil, req, &post, http.StatusOK); err != nil {
+		return nil, fmt.Errorf("failed to update post: %w", err)
 	}
+	return &post, nil
+}
 
-	var createdPost models.PostDTO
-	if err := json.NewDecoder(resp.Body).Decode(&createdPost); err != nil {
-		return nil, fmt.Errorf("failed to decode created post: %w", err)
+func (c *PostClient) DeletePost(ctx context.Context, id uint) error {
+	if err := c.r.do(ctx, http.MethodDelete, fmt.Sprintf("/posts/%d", id), nil, nil, nil, http.StatusNoContent); err != nil {
+		return fmt.Errorf("failed to delete post: %w", err)
 	}
-
-	return &createdPost, nil
+	return nil
 }
-
-// Additional methods like UpdatePost, DeletePost can be added here
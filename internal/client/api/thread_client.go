@@ -1,85 +1,79 @@
 package api
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
 	"fmt"
-	"heisei/internal/common/models"
 	"net/http"
+	"net/url"
+	"strconv"
+
+	"heisei/internal/common/models"
 )
 
+// ThreadClient calls the thread endpoints
 type ThreadClient struct {
-	baseURL string
-	client  *http.Client
+	r *requester
 }
 
-func NewThreadClient(baseURL string, client *http.Client) *ThreadClient {
-	return &ThreadClient{
-		baseURL: baseURL,
-		client:  client,
-	}
+func (c *ThreadClient) GetThreads(ctx context.Context) ([]models.ThreadDTO, error) {
+	return c.listThreads(ctx, nil)
 }
 
-func (c *ThreadClient) GetThreadsByCategory(categoryID uint) ([]models.ThreadDTO, error) {
-	resp, err := c.client.Get(fmt.Sprintf("%s/api/categories/%d/threads", c.baseURL, categoryID))
-	if err != nil {
-		return nil, fmt.Errorf("failed to get threads: %w", err)
+// GetThreadsByCategory returns the threads of a category in the given sort
+// order. An empty sort uses the default order of the category.
+func (c *ThreadClient) GetThreadsByCategory(ctx context.Context, categoryID uint, sort string) ([]models.ThreadDTO, error) {
+	params := url.Values{"category_id": {strconv.FormatUint(uint64(categoryID), 10)}}
+	if sort != "" {
+		params.Set("sort", sort)
 	}
-	defer resp.Body.Close()
+	return c.listThreads(ctx, params)
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+// SearchThreads returns the threads whose title contains query.
+// A categoryID of 0 searches all categories.
+func (c *ThreadClient) SearchThreads(ctx context.Context, categoryID uint, query string) ([]models.ThreadDTO, error) {
+	params := url.Values{"q": {query}}
+	if categoryID != 0 {
+		params.Set("category_id", strconv.FormatUint(uint64(categoryID), 10))
 	}
+	return c.listThreads(ctx, params)
+}
 
+func (c *ThreadClient) listThreads(ctx context.Context, params url.Values) ([]models.ThreadDTO, error) {
 	var threads []models.ThreadDTO
-	if err := json.NewDecoder(resp.Body).Decode(&threads); err != nil {
-		return nil, fmt.Errorf("failed to decode threads: %w", err)
+	if err := c.r.do(ctx, http.MethodGet, "/threads", params, nil, &threads, http.StatusOK); err != nil {
+		return nil, fmt.Errorf("failed to get threads: %w", err)
 	}
-
 	return threads, nil
 }
 
-func (c *ThreadClient) GetThreadByID(id uint) (*models.ThreadDTO, error) {
-	resp, err := c.client.Get(fmt.Sprintf("%s/api/threads/%d", c.baseURL, id))
-	if err != nil {
-		return nil, fmt.Errorf("failed to get thread: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
-	}
-
+func (c *ThreadClient) GetThreadByID(ctx context.Context, id uint) (*models.ThreadDTO, error) {
 	var thread models.ThreadDTO
-	if err := json.NewDecoder(resp.Body).Decode(&thread); err != nil {
-		return nil, fmt.Errorf("failed to decode thread: %w", err)
+	if err := c.r.do(ctx, http.MethodGet, fmt.Sprintf("/threads/%d", id), nil, nil, &thread, http.StatusOK); err != nil {
+		return nil, fmt.Errorf("failed to get thread: %w", err)
 	}
-
 	return &thread, nil
 }
 
-func (c *ThreadClient) CreateThread(thread models.ThreadDTO) (*models.ThreadDTO, error) {
-	body, err := json.Marshal(thread)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal thread: %w", err)
-	}
-
-	resp, err := c.client.Post(fmt.Sprintf("%s/api/threads", c.baseURL), "application/json", bytes.NewBuffer(body))
-	if err != nil {
+func (c *ThreadClient) CreateThread(ctx context.Context, req models.CreateThreadRequest) (*models.ThreadDTO, error) {
+	var thread models.ThreadDTO
+	if err := c.r.do(ctx, http.MethodPost, "/threads", nil, req, &thread, http.StatusCreated); err != nil {
 		return nil, fmt.Errorf("failed to create thread: %w", err)
 	}
-	defer resp.Body.Close()
+	return &thread, nil
+}
 
-	if resp.StatusCode != http.StatusCreated {
-		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+func (c *ThreadClient) UpdateThread(ctx context.Context, id uint, req models.UpdateThreadRequest) (*models.ThreadDTO, error) {
+	var thread models.ThreadDTO
+	if err := c.r.do(ctx, http.MethodPut, fmt.Sprintf("/threads/%d", id), nil, req, &thread, http.StatusOK); err != nil {
+		return nil, fmt.Errorf("failed to update thread: %w", err)
 	}
+	return &thread, nil
+}
 
-	var createdThread models.ThreadDTO
-	if err := json.NewDecoder(resp.Body).Decode(&createdThread); err != nil {
-		return nil, fmt.Errorf("failed to decode created thread: %w", err)
+func (c *ThreadClient) DeleteThread(ctx context.Context, id uint) error {
+	if err := c.r.do(ctx, http.MethodDelete, fmt.Sprintf("/threads/%d", id), nil, nil, nil, http.StatusNoContent); err != nil {
+		return fmt.Errorf("failed to delete thread: %w", err)
 	}
-
-	return &createdThread, nil
+	return nil
 }
-
-// Additional methods like UpdateThread, DeleteThread can be added here
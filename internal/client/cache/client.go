@@ -0,0 +1,213 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+
+	"heisei/internal/client/api"
+	"heisei/internal/common/models"
+
+	"go.uber.org/zap"
+)
+
+// ErrDraftQueued is returned by CreatePost when the server is unreachable and
+// the post was queued as a draft instead
+var ErrDraftQueued = errors.New("server unreachable, post saved as draft")
+
+// DraftConflict reports a draft that the server rejected on submission
+type DraftConflict struct {
+	Draft Draft
+	Err   error
+}
+
+// Client wraps api.Client so that reads fall back to the cache and posts are
+// queued as drafts while the server is unreachable
+type Client struct {
+	*api.Client
+	store *Store
+	// drafts keeps the draft queue. Unsent posts must survive clearing the
+	// cache, so it is a store in the state directory.
+	drafts  *Store
+	logger  *zap.Logger
+	offline atomic.Bool
+}
+
+func NewClient(client *api.Client, store, drafts *Store, logger *zap.Logger) *Client {
+	return &Client{
+		Client: client,
+		store:  store,
+		drafts: drafts,
+		logger: logger,
+	}
+}
+
+// Store returns the underlying cache store
+func (c *Client) Store() *Store {
+	return c.store
+}
+
+// Offline reports whether the last request failed to reach the server
+func (c *Client) Offline() bool {
+	return c.offline.Load()
+}
+
+func (c *Client) GetCategories(ctx context.Context) ([]models.CategoryDTO, error) {
+	return load(c, ctx, "categories", c.Client.GetCategories)
+}
+
+func (c *Client) GetThreadsByCategory(ctx context.Context, categoryID uint, sort string) ([]models.ThreadDTO, error) {
+	key := fmt.Sprintf("threads/category-%d", categoryID)
+	if sort != "" {
+		key += "-" + sort
+	}
+	return load(c, ctx, key, func(ctx context.Context) ([]models.ThreadDTO, error) {
+		return c.Client.GetThreadsByCategory(ctx, categoryID, sort)
+	})
+}
+
+func (c *Client) GetThreadByID(ctx context.Context, id uint) (*models.ThreadDTO, error) {
+	return load(c, ctx, fmt.Sprintf("threads/%d", id), func(ctx context.Context) (*models.ThreadDTO, error) {
+		return c.Client.GetThreadByID(ctx, id)
+	})
+}
+
+func (c *Client) GetPostsByThread(ctx context.Context, threadID uint) ([]models.PostDTO, error) {
+	return load(c, ctx, fmt.Sprintf("posts/thread-%d", threadID), func(ctx context.Context) ([]models.PostDTO, error) {
+		return c.Client.GetPostsByThread(ctx, threadID)
+	})
+}
+
+// CreatePost sends the post or, if the server is unreachable, queues it as a
+// draft and returns ErrDraftQueued
+func (c *Client) CreatePost(ctx context.Context, req models.CreatePostRequest) (*models.PostDTO, error) {
+	post, err := c.Client.CreatePost(ctx, req)
+	if err == nil {
+		c.offline.Store(false)
+		return post, nil
+	}
+	if !errors.Is(err, api.ErrUnavailable) {
+		return nil, err
+	}
+
+	c.offline.Store(true)
+	if _, err := c.drafts.AddDraft(req.ThreadID, req.Content, req.Sage); err != nil {
+		return nil, fmt.Errorf("failed to save draft: %w", err)
+	}
+	return nil, ErrDraftQueued
+}
+
+// DraftsForThread returns the queued drafts of a thread
+func (c *Client) DraftsForThread(threadID uint) ([]Draft, error) {
+	drafts, err := c.drafts.Drafts()
+	if err != nil {
+		return nil, err
+	}
+	var result []Draft
+	for _, d := range drafts {
+		if d.ThreadID == threadID {
+			result = append(result, d)
+		}
+	}
+	return result, nil
+}
+
+// PendingDrafts returns the number of drafts waiting to be submitted
+func (c *Client) PendingDrafts() int {
+	drafts, err := c.drafts.Drafts()
+	if err != nil {
+		c.logger.Warn("Failed to read drafts", zap.Error(err))
+		return 0
+	}
+	pending := 0
+	for _, d := range drafts {
+		if d.Conflict == "" {
+			pending++
+		}
+	}
+	return pending
+}
+
+// DiscardDraft removes a draft from the queue
+func (c *Client) DiscardDraft(id string) error {
+	return c.drafts.RemoveDraft(id)
+}
+
+// SubmitDrafts sends the queued drafts in order. It stops at the first draft
+// that cannot be delivered because of the server or the network and returns
+// that error; drafts the server rejects, e.g. because the thread was locked
+// meanwhile, are kept with their conflict and reported.
+func (c *Client) SubmitDrafts(ctx context.Context) ([]models.PostDTO, []DraftConflict, error) {
+	drafts, err := c.drafts.Drafts()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var sent []models.PostDTO
+	var conflicts []DraftConflict
+	for _, d := range drafts {
+		if d.Conflict != "" {
+			continue
+		}
+
+		post, err := c.Client.CreatePost(ctx, models.CreatePostRequest{ThreadID: d.ThreadID, Content: d.Content, Sage: d.Sage})
+		if err != nil {
+			if !isRejection(err) {
+				if errors.Is(err, api.ErrUnavailable) {
+					c.offline.Store(true)
+				}
+				return sent, conflicts, err
+			}
+			if err := c.drafts.MarkDraftConflict(d.ID, err.Error()); err != nil {
+				return sent, conflicts, err
+			}
+			d.Conflict = err.Error()
+			conflicts = append(conflicts, DraftConflict{Draft: d, Err: err})
+			continue
+		}
+
+		c.offline.Store(false)
+		if err := c.drafts.RemoveDraft(d.ID); err != nil {
+			return sent, conflicts, err
+		}
+		sent = append(sent, *post)
+	}
+	return sent, conflicts, nil
+}
+
+// isRejection reports whether the server refused a post for good, as opposed
+// to failing for reasons that may go away on retry
+func isRejection(err error) bool {
+	var apiErr *api.APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	return apiErr.StatusCode < 500 && !errors.Is(err, api.ErrRateLimited)
+}
+
+// load fetches a value from the server and caches it, or returns the cached
+// value if the server is unreachable
+func load[T any](c *Client, ctx context.Context, key string, fetch func(context.Context) (T, error)) (T, error) {
+	v, err := fetch(ctx)
+	if err == nil {
+		c.offline.Store(false)
+		if err := c.store.Put(key, v); err != nil {
+			c.logger.Warn("Failed to cache response", zap.String("key", key), zap.Error(err))
+		}
+		return v, nil
+	}
+	if !errors.Is(err, api.ErrUnavailable) {
+		return v, err
+	}
+
+	c.offline.Store(true)
+	var cached T
+	if _, cacheErr := c.store.Get(key, &cached); cacheErr != nil {
+		if !errors.Is(cacheErr, ErrMiss) {
+			c.logger.Warn("Failed to read cache", zap.String("key", key), zap.Error(cacheErr))
+		}
+		return v, err
+	}
+	return cached, nil
+}
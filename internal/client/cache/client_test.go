@@ -0,0 +1,149 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"heisei/internal/client/api"
+	"heisei/internal/common/models"
+
+	"go.uber.org/zap"
+)
+
+// fakeServer answers like the API server while up is set and fails with 503 otherwise
+type fakeServer struct {
+	up     atomic.Bool
+	locked atomic.Bool
+	posts  atomic.Int32
+}
+
+func (f *fakeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !f.up.Load() {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/api/v1/categories":
+		w.Write([]byte(`[{"id":1,"name":"News","slug":"news"}]`))
+	case r.Method == http.MethodPost && r.URL.Path == "/api/v1/posts":
+		if f.locked.Load() {
+			w.WriteHeader(http.StatusConflict)
+			w.Write([]byte(`{"code":"conflict","message":"Thread 1 is locked"}`))
+			return
+		}
+		f.posts.Add(1)
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id":10,"thread_id":1,"content":"hello"}`))
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func newTestClient(t *testing.T) (*Client, *fakeServer) {
+	t.Helper()
+	fake := &fakeServer{}
+	fake.up.Store(true)
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+
+	store, err := NewStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	drafts, err := NewStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	apiClient := api.NewClient(srv.URL, api.WithHTTPClient(srv.Client()), api.WithRetries(0, time.Millisecond))
+	return NewClient(apiClient, store, drafts, zap.NewNop()), fake
+}
+
+func TestReadsFallBackToCache(t *testing.T) {
+	client, fake := newTestClient(t)
+	ctx := context.Background()
+
+	if _, err := client.GetCategories(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	fake.up.Store(false)
+	categories, err := client.GetCategories(ctx)
+	if err != nil {
+		t.Fatalf("offline read failed: %v", err)
+	}
+	if len(categories) != 1 || categories[0].Slug != "news" {
+		t.Errorf("categories = %+v", categories)
+	}
+	if !client.Offline() {
+		t.Error("client should report being offline")
+	}
+
+	if _, err := client.GetPostsByThread(ctx, 1); !errors.Is(err, api.ErrUnavailable) {
+		t.Errorf("uncached read err = %v, want ErrUnavailable", err)
+	}
+}
+
+func TestDraftsAreSubmittedOnReconnect(t *testing.T) {
+	client, fake := newTestClient(t)
+	ctx := context.Background()
+
+	fake.up.Store(false)
+	_, err := client.CreatePost(ctx, models.CreatePostRequest{ThreadID: 1, Content: "hello"})
+	if !errors.Is(err, ErrDraftQueued) {
+		t.Fatalf("err = %v, want ErrDraftQueued", err)
+	}
+	if n := client.PendingDrafts(); n != 1 {
+		t.Fatalf("pending drafts = %d, want 1", n)
+	}
+
+	if _, _, err := client.SubmitDrafts(ctx); !errors.Is(err, api.ErrUnavailable) {
+		t.Errorf("submit while offline err = %v, want ErrUnavailable", err)
+	}
+
+	fake.up.Store(true)
+	sent, conflicts, err := client.SubmitDrafts(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sent) != 1 || len(conflicts) != 0 || fake.posts.Load() != 1 {
+		t.Errorf("sent %d, conflicts %d, server saw %d posts", len(sent), len(conflicts), fake.posts.Load())
+	}
+	if n := client.PendingDrafts(); n != 0 {
+		t.Errorf("pending drafts = %d, want 0", n)
+	}
+}
+
+func TestLockedThreadReportsConflict(t *testing.T) {
+	client, fake := newTestClient(t)
+	ctx := context.Background()
+
+	fake.up.Store(false)
+	client.CreatePost(ctx, models.CreatePostRequest{ThreadID: 1, Content: "hello"})
+
+	fake.up.Store(true)
+	fake.locked.Store(true)
+	_, conflicts, err := client.SubmitDrafts(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(conflicts) != 1 || !errors.Is(conflicts[0].Err, api.ErrConflict) {
+		t.Fatalf("conflicts = %+v", conflicts)
+	}
+
+	drafts, err := client.DraftsForThread(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(drafts) != 1 || drafts[0].Conflict == "" {
+		t.Errorf("draft should be kept with its conflict: %+v", drafts)
+	}
+	if n := client.PendingDrafts(); n != 0 {
+		t.Errorf("pending drafts = %d, want 0", n)
+	}
+}
@@ -0,0 +1,85 @@
+package cache
+
+import (
+	"errors"
+	"strconv"
+	"time"
+)
+
+const draftsKey = "drafts"
+
+// Draft is a post written while the server was unreachable
+type Draft struct {
+	ID        string    `json:"id"`
+	ThreadID  uint      `json:"thread_id"`
+	Content   string    `json:"content"`
+	Sage      bool      `json:"sage,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	// Conflict is set when the server rejected the draft, e.g. because the
+	// thread was locked meanwhile. Such drafts are not submitted again.
+	Conflict string `json:"conflict,omitempty"`
+}
+
+// Drafts returns all queued drafts, oldest first
+func (s *Store) Drafts() ([]Draft, error) {
+	var drafts []Draft
+	if _, err := s.Get(draftsKey, &drafts); err != nil && !errors.Is(err, ErrMiss) {
+		return nil, err
+	}
+	return drafts, nil
+}
+
+// AddDraft queues a new draft for the thread
+func (s *Store) AddDraft(threadID uint, content string, sage bool) (*Draft, error) {
+	now := time.Now()
+	draft := Draft{
+		ID:        strconv.FormatInt(now.UnixNano(), 36),
+		ThreadID:  threadID,
+		Content:   content,
+		Sage:      sage,
+		CreatedAt: now,
+	}
+	err := s.updateDrafts(func(drafts []Draft) []Draft {
+		return append(drafts, draft)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &draft, nil
+}
+
+// RemoveDraft drops a draft from the queue
+func (s *Store) RemoveDraft(id string) error {
+	return s.updateDrafts(func(drafts []Draft) []Draft {
+		kept := drafts[:0]
+		for _, d := range drafts {
+			if d.ID != id {
+				kept = append(kept, d)
+			}
+		}
+		return kept
+	})
+}
+
+// MarkDraftConflict records why the server rejected a draft
+func (s *Store) MarkDraftConflict(id, reason string) error {
+	return s.updateDrafts(func(drafts []Draft) []Draft {
+		for i := range drafts {
+			if drafts[i].ID == id {
+				drafts[i].Conflict = reason
+			}
+		}
+		return drafts
+	})
+}
+
+func (s *Store) updateDrafts(fn func([]Draft) []Draft) error {
+	s.draftsMu.Lock()
+	defer s.draftsMu.Unlock()
+
+	drafts, err := s.Drafts()
+	if err != nil {
+		return err
+	}
+	return s.Put(draftsKey, fn(drafts))
+}
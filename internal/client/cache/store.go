@@ -0,0 +1,136 @@
+package cache
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrMiss is returned by Store.Get for keys that were never stored
+var ErrMiss = errors.New("not in cache")
+
+var unsafeChars = regexp.MustCompile(`[^A-Za-z0-9.-]+`)
+
+// Store keeps API responses, drafts or client state as JSON files in a
+// directory
+type Store struct {
+	dir string
+	mu  sync.Mutex
+	// draftsMu serializes read-modify-write cycles of the draft queue
+	draftsMu sync.Mutex
+}
+
+type entry struct {
+	FetchedAt time.Time       `json:"fetched_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// NewStore creates a store in dir, creating the directory if needed
+func NewStore(dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	return &Store{dir: dir}, nil
+}
+
+// DefaultDir returns the cache directory of a server below the user's cache
+// directory, $XDG_CACHE_HOME/heisei/<server> on Linux
+func DefaultDir(serverURL string) (string, error) {
+	base, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate user cache directory: %w", err)
+	}
+	return filepath.Join(base, "heisei", ServerKey(serverURL)), nil
+}
+
+// ServerKey turns a server URL into a file name, e.g. localhost_8080
+func ServerKey(serverURL string) string {
+	key := serverURL
+	if u, err := url.Parse(serverURL); err == nil && u.Host != "" {
+		key = u.Host + u.Path
+	}
+	key = strings.Trim(unsafeChars.ReplaceAllString(key, "_"), "_")
+	if key == "" {
+		return "default"
+	}
+	return key
+}
+
+// Dir returns the directory of the store
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// Put stores v as JSON under key
+func (s *Store) Put(key string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", key, err)
+	}
+	e, err := json.Marshal(entry{FetchedAt: time.Now(), Data: data})
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", key, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.write(key, e)
+}
+
+// Get decodes the value stored under key into v and returns when it was stored
+func (s *Store) Get(key string, v interface{}) (time.Time, error) {
+	s.mu.Lock()
+	data, err := os.ReadFile(s.path(key))
+	s.mu.Unlock()
+	if errors.Is(err, os.ErrNotExist) {
+		return time.Time{}, ErrMiss
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to read %s: %w", key, err)
+	}
+
+	var e entry
+	if err := json.Unmarshal(data, &e); err != nil {
+		return time.Time{}, fmt.Errorf("failed to decode %s: %w", key, err)
+	}
+	if err := json.Unmarshal(e.Data, v); err != nil {
+		return time.Time{}, fmt.Errorf("failed to decode %s: %w", key, err)
+	}
+	return e.FetchedAt, nil
+}
+
+func (s *Store) path(key string) string {
+	return filepath.Join(s.dir, filepath.FromSlash(key)+".json")
+}
+
+// write replaces the file of key atomically so that readers never see partial data
+func (s *Store) write(key string, data []byte) error {
+	path := s.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", key, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write %s: %w", key, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", key, err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write %s: %w", key, err)
+	}
+	return nil
+}
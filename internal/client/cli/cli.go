@@ -0,0 +1,162 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"heisei/internal/client/api"
+)
+
+// ErrUsage is returned for invalid command lines; the usage has already
+// been printed
+var ErrUsage = errors.New("invalid usage")
+
+// timeLayout is used for times in the text output
+const timeLayout = "2006-01-02 15:04:05"
+
+// Output formats
+const (
+	formatText = "text"
+	formatJSON = "json"
+)
+
+type command struct {
+	usage   string
+	summary string
+	run     func(ctx context.Context, c *CLI, fs *flag.FlagSet, args []string) error
+}
+
+var commands = map[string]command{
+	"categories": {usage: "categories", summary: "List the categories", run: runCategories},
+	"threads":    {usage: "threads <category> [--sort order]", summary: "List the threads of a category, given by slug or ID", run: runThreads},
+	"read":       {usage: "read <thread> [--last N]", summary: "Print the posts of a thread", run: runRead},
+	"post":       {usage: "post <thread> [--sage] < file", summary: "Post the text read from stdin to a thread", run: runPost},
+}
+
+// CLI runs the non-interactive subcommands of the client
+type CLI struct {
+	client *api.Client
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+	// format is the output format of the running command
+	format string
+}
+
+func New(client *api.Client, stdin io.Reader, stdout, stderr io.Writer) *CLI {
+	return &CLI{
+		client: client,
+		stdin:  stdin,
+		stdout: stdout,
+		stderr: stderr,
+	}
+}
+
+// Run executes the subcommand named by args[0] with the remaining arguments
+func (c *CLI) Run(ctx context.Context, args []string) error {
+	if len(args) == 0 || args[0] == "help" {
+		c.Usage()
+		if len(args) == 0 {
+			return ErrUsage
+		}
+		return nil
+	}
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(c.stderr, "unknown command %q\n\n", args[0])
+		c.Usage()
+		return ErrUsage
+	}
+
+	fs := flag.NewFlagSet(args[0], flag.ContinueOnError)
+	fs.SetOutput(c.stderr)
+	fs.StringVar(&c.format, "format", formatText, "output format, text or json")
+	fs.Usage = func() {
+		fmt.Fprintf(c.stderr, "Usage: %s\n\n%s\n\nFlags:\n", cmd.usage, cmd.summary)
+		fs.PrintDefaults()
+	}
+	return cmd.run(ctx, c, fs, args[1:])
+}
+
+// Usage prints the list of subcommands
+func (c *CLI) Usage() {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintln(c.stderr, "Usage: heisei_client [-config file] [command [flags] [args]]")
+	fmt.Fprintln(c.stderr, "\nWithout a command the interactive client is started.\n\nCommands:")
+	w := tabwriter.NewWriter(c.stderr, 0, 0, 2, ' ', 0)
+	for _, name := range names {
+		fmt.Fprintf(w, "  %s\t%s\n", commands[name].usage, commands[name].summary)
+	}
+	w.Flush()
+	fmt.Fprintln(c.stderr, "\nAll commands accept --format text|json.")
+}
+
+// parse parses flags and the given number of positional arguments, which
+// may appear in any order
+func (c *CLI) parse(fs *flag.FlagSet, args []string, positional int) ([]string, error) {
+	var values []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			// The flag package has printed the error and the usage
+			return nil, ErrUsage
+		}
+		if fs.NArg() == 0 {
+			break
+		}
+		values = append(values, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+
+	if len(values) != positional {
+		fmt.Fprintf(c.stderr, "expected %d argument(s), got %d\n", positional, len(values))
+		fs.Usage()
+		return nil, ErrUsage
+	}
+	if c.format != formatText && c.format != formatJSON {
+		fmt.Fprintf(c.stderr, "invalid format %q, expected text or json\n", c.format)
+		return nil, ErrUsage
+	}
+	return values, nil
+}
+
+// write prints v as JSON or calls text to print it for humans
+func (c *CLI) write(v interface{}, text func(w io.Writer) error) error {
+	if c.format == formatJSON {
+		encoder := json.NewEncoder(c.stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(v)
+	}
+	return text(c.stdout)
+}
+
+// table prints tab-separated rows as aligned columns
+func table(w io.Writer, rows func(w io.Writer)) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	rows(tw)
+	return tw.Flush()
+}
+
+// oneLine makes text fit into a table cell
+func oneLine(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Local().Format(timeLayout)
+}
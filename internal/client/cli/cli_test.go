@@ -0,0 +1,166 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"heisei/internal/client/api"
+)
+
+// fakeServer answers like the API server for a board with one category,
+// one thread and three posts, the third of four having been deleted
+type fakeServer struct {
+	posted string
+	sage   bool
+	sort   string
+}
+
+func (f *fakeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/api/v1/categories":
+		w.Write([]byte(`[{"id":1,"name":"News","slug":"news"},{"id":2,"name":"Misc","slug":"misc"}]`))
+	case r.Method == http.MethodGet && r.URL.Path == "/api/v1/threads" && r.URL.Query().Get("category_id") == "1":
+		f.sort = r.URL.Query().Get("sort")
+		w.Write([]byte(`[{"id":7,"category_id":1,"title":"Release","post_count":3,"locked":true}]`))
+	case r.Method == http.MethodGet && r.URL.Path == "/api/v1/threads/7":
+		w.Write([]byte(`{"id":7,"category_id":1,"title":"Release","post_count":3}`))
+	case r.Method == http.MethodGet && r.URL.Path == "/api/v1/threads/7/posts":
+		w.Write([]byte(`[{"id":1,"thread_id":7,"number":1,"content":"first"},{"id":2,"thread_id":7,"number":2,"content":"second"},{"id":4,"thread_id":7,"number":4,"content":"fourth"}]`))
+	case r.Method == http.MethodPost && r.URL.Path == "/api/v1/posts":
+		var body struct {
+			Content string `json:"content"`
+			Sage    bool   `json:"sage"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		f.posted = body.Content
+		f.sage = body.Sage
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id":4,"thread_id":7,"content":"posted"}`))
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"code":"not_found","message":"not found"}`))
+	}
+}
+
+func run(t *testing.T, stdin string, args ...string) (stdout, stderr string, fake *fakeServer, err error) {
+	t.Helper()
+	fake = &fakeServer{}
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+
+	client := api.NewClient(srv.URL, api.WithHTTPClient(srv.Client()), api.WithRetries(0, time.Millisecond))
+	var out, errOut bytes.Buffer
+	err = New(client, strings.NewReader(stdin), &out, &errOut).Run(context.Background(), args)
+	return out.String(), errOut.String(), fake, err
+}
+
+func TestCategories(t *testing.T) {
+	out, _, _, err := run(t, "", "categories")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "1   news  News") || !strings.HasPrefix(out, "ID  SLUG  NAME") {
+		t.Errorf("unexpected table:\n%s", out)
+	}
+}
+
+func TestThreadsBySlug(t *testing.T) {
+	out, _, _, err := run(t, "", "threads", "--format", "json", "news")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var threads []struct {
+		ID     uint `json:"id"`
+		Locked bool `json:"locked"`
+	}
+	if err := json.Unmarshal([]byte(out), &threads); err != nil {
+		t.Fatalf("invalid JSON %q: %v", out, err)
+	}
+	if len(threads) != 1 || threads[0].ID != 7 || !threads[0].Locked {
+		t.Errorf("threads = %+v", threads)
+	}
+
+	if _, _, _, err := run(t, "", "threads", "nope"); err == nil || !strings.Contains(err.Error(), `category "nope" not found`) {
+		t.Errorf("unknown category: %v", err)
+	}
+}
+
+func TestThreadsSort(t *testing.T) {
+	_, _, fake, err := run(t, "", "threads", "news", "--sort", "post_count")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fake.sort != "post_count" {
+		t.Errorf("sort = %q", fake.sort)
+	}
+	_, _, fake, _ = run(t, "", "threads", "news")
+	if fake.sort != "" {
+		t.Errorf("default sort = %q", fake.sort)
+	}
+}
+
+func TestReadLast(t *testing.T) {
+	out, _, _, err := run(t, "", "read", "7", "--last", "2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(out, "first") || !strings.Contains(out, "2  -\nsecond") || !strings.Contains(out, "4  -\nfourth") {
+		t.Errorf("unexpected posts:\n%s", out)
+	}
+
+	_, _, _, err = run(t, "", "read", "8")
+	if !errors.Is(err, api.ErrNotFound) {
+		t.Errorf("missing thread: %v", err)
+	}
+}
+
+func TestPostFromStdin(t *testing.T) {
+	out, _, fake, err := run(t, "line one\nline two\n", "post", "7")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fake.posted != "line one\nline two" {
+		t.Errorf("posted %q", fake.posted)
+	}
+	if out != "Posted 4 to thread 7\n" {
+		t.Errorf("output = %q", out)
+	}
+
+	if _, _, _, err := run(t, " \n", "post", "7"); err == nil {
+		t.Error("empty post accepted")
+	}
+
+	if _, _, fake, _ := run(t, "text", "post", "--sage", "7"); !fake.sage || fake.posted != "text" {
+		t.Errorf("sage post: posted %q, sage %v", fake.posted, fake.sage)
+	}
+}
+
+func TestUsageErrors(t *testing.T) {
+	for _, args := range [][]string{
+		{},
+		{"frobnicate"},
+		{"threads"},
+		{"categories", "--format", "xml"},
+		{"read", "7", "--last", "-1"},
+		{"threads", "news", "--sort", "random"},
+	} {
+		_, stderr, _, err := run(t, "", args...)
+		if !errors.Is(err, ErrUsage) {
+			t.Errorf("%v: err = %v", args, err)
+		}
+		if stderr == "" {
+			t.Errorf("%v: nothing printed", args)
+		}
+	}
+	if _, _, _, err := run(t, "", "read", "abc"); err == nil || errors.Is(err, ErrUsage) {
+		t.Errorf("invalid ID: %v", err)
+	}
+}
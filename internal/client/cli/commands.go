@@ -0,0 +1,165 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"heisei/internal/common/models"
+)
+
+func runCategories(ctx context.Context, c *CLI, fs *flag.FlagSet, args []string) error {
+	if _, err := c.parse(fs, args, 0); err != nil {
+		return err
+	}
+
+	categories, err := c.client.GetCategories(ctx)
+	if err != nil {
+		return err
+	}
+	return c.write(categories, func(w io.Writer) error {
+		return table(w, func(w io.Writer) {
+			fmt.Fprintln(w, "ID\tSLUG\tNAME")
+			for _, category := range categories {
+				fmt.Fprintf(w, "%d\t%s\t%s\n", category.ID, category.Slug, oneLine(category.Name))
+			}
+		})
+	})
+}
+
+func runThreads(ctx context.Context, c *CLI, fs *flag.FlagSet, args []string) error {
+	sort := fs.String("sort", "", "thread `order`: "+strings.Join(models.ThreadSorts, ", ")+" (default: the order of the category)")
+	values, err := c.parse(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	if *sort != "" && !slices.Contains(models.ThreadSorts, *sort) {
+		fmt.Fprintf(c.stderr, "invalid sort order %q, expected one of %s\n", *sort, strings.Join(models.ThreadSorts, ", "))
+		return ErrUsage
+	}
+
+	category, err := c.findCategory(ctx, values[0])
+	if err != nil {
+		return err
+	}
+	threads, err := c.client.GetThreadsByCategory(ctx, category.ID, *sort)
+	if err != nil {
+		return err
+	}
+	return c.write(threads, func(w io.Writer) error {
+		return table(w, func(w io.Writer) {
+			fmt.Fprintln(w, "ID\tPOSTS\tLAST POST\tTITLE")
+			for _, thread := range threads {
+				title := oneLine(thread.Title)
+				if thread.Locked {
+					title += " (locked)"
+				}
+				fmt.Fprintf(w, "%d\t%d\t%s\t%s\n", thread.ID, thread.PostCount, formatTime(thread.LastPostAt), title)
+			}
+		})
+	})
+}
+
+// threadPosts is the JSON output of read
+type threadPosts struct {
+	Thread *models.ThreadDTO `json:"thread"`
+	Posts  []models.PostDTO  `json:"posts"`
+}
+
+func runRead(ctx context.Context, c *CLI, fs *flag.FlagSet, args []string) error {
+	last := fs.Int("last", 0, "print only the last `N` posts")
+	values, err := c.parse(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	if *last < 0 {
+		fmt.Fprintln(c.stderr, "--last must not be negative")
+		return ErrUsage
+	}
+	threadID, err := parseID(values[0], "thread")
+	if err != nil {
+		return err
+	}
+
+	thread, err := c.client.GetThreadByID(ctx, threadID)
+	if err != nil {
+		return err
+	}
+	posts, err := c.client.GetPostsByThread(ctx, threadID)
+	if err != nil {
+		return err
+	}
+	first := 0
+	if *last > 0 && len(posts) > *last {
+		first = len(posts) - *last
+	}
+
+	return c.write(threadPosts{Thread: thread, Posts: posts[first:]}, func(w io.Writer) error {
+		if _, err := fmt.Fprintf(w, "%s\n\n", thread.Title); err != nil {
+			return err
+		}
+		for _, post := range posts[first:] {
+			if _, err := fmt.Fprintf(w, "%d  %s\n%s\n\n", post.Number, formatTime(post.CreatedAt), post.Content); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func runPost(ctx context.Context, c *CLI, fs *flag.FlagSet, args []string) error {
+	sage := fs.Bool("sage", false, "post without bumping the thread")
+	values, err := c.parse(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	threadID, err := parseID(values[0], "thread")
+	if err != nil {
+		return err
+	}
+
+	data, err := io.ReadAll(c.stdin)
+	if err != nil {
+		return fmt.Errorf("failed to read the post from stdin: %w", err)
+	}
+	// Files and here-documents end with a newline that is not part of the post
+	content := strings.TrimRight(string(data), "\r\n")
+	if strings.TrimSpace(content) == "" {
+		return fmt.Errorf("the post read from stdin is empty")
+	}
+
+	post, err := c.client.CreatePost(ctx, models.CreatePostRequest{ThreadID: threadID, Content: content, Sage: *sage})
+	if err != nil {
+		return err
+	}
+	return c.write(post, func(w io.Writer) error {
+		_, err := fmt.Fprintf(w, "Posted %d to thread %d\n", post.ID, post.ThreadID)
+		return err
+	})
+}
+
+// findCategory looks a category up by slug or ID
+func (c *CLI) findCategory(ctx context.Context, ref string) (*models.CategoryDTO, error) {
+	categories, err := c.client.GetCategories(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i, category := range categories {
+		if category.Slug == ref || strconv.FormatUint(uint64(category.ID), 10) == ref {
+			return &categories[i], nil
+		}
+	}
+	return nil, fmt.Errorf("category %q not found", ref)
+}
+
+func parseID(s, what string) (uint, error) {
+	id, err := strconv.ParseUint(s, 10, 32)
+	if err != nil || id == 0 {
+		return 0, fmt.Errorf("invalid %s ID %q", what, s)
+	}
+	return uint(id), nil
+}
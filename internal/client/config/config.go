@@ -6,6 +6,8 @@ import (
 	"strconv"
 	"time"
 
+	"heisei/pkg/tracing"
+
 	"gopkg.in/yaml.v2"
 )
 
@@ -17,12 +19,45 @@ type ClientConfig struct {
 	ServerURL  string           `yaml:"server_url"`
 	UI         UIConfig         `yaml:"ui"`
 	Connection ConnectionConfig `yaml:"connection"`
+	Tracing    tracing.Config   `yaml:"tracing"`
 }
 
 type UIConfig struct {
 	Language       string        `yaml:"language"`
 	RefreshRate    time.Duration `yaml:"refresh_rate"`
 	MaxThreadsShow int           `yaml:"max_threads"`
+	// Bell rings the terminal bell when watched threads get new replies
+	Bell bool `yaml:"bell"`
+	// AmbiguousWide draws East Asian ambiguous width characters such as
+	// "○" or "★" two cells wide, matching CJK terminal settings
+	AmbiguousWide bool `yaml:"ambiguous_wide"`
+	// Theme is "default", "monochrome" or "green-phosphor"
+	Theme  string       `yaml:"theme"`
+	Keymap KeymapConfig `yaml:"keymap"`
+}
+
+type KeymapConfig struct {
+	// Preset is "vi" or "emacs"
+	Preset string `yaml:"preset"`
+	// Bindings replace the keys of single actions, e.g. quit: ["q", "Ctrl+Q"]
+	Bindings map[string]KeyList `yaml:"bindings"`
+}
+
+// KeyList is a list of keys that may also be written as a single key
+type KeyList []string
+
+func (k *KeyList) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var key string
+	if err := unmarshal(&key); err == nil {
+		*k = KeyList{key}
+		return nil
+	}
+	var keys []string
+	if err := unmarshal(&keys); err != nil {
+		return err
+	}
+	*k = keys
+	return nil
 }
 
 type ConnectionConfig struct {
@@ -74,6 +109,22 @@ func (c *Config) overrideWithEnv() {
 			c.Client.UI.MaxThreadsShow = m
 		}
 	}
+	if bell := os.Getenv("UI_BELL"); bell != "" {
+		if b, err := strconv.ParseBool(bell); err == nil {
+			c.Client.UI.Bell = b
+		}
+	}
+	if wide := os.Getenv("UI_AMBIGUOUS_WIDE"); wide != "" {
+		if w, err := strconv.ParseBool(wide); err == nil {
+			c.Client.UI.AmbiguousWide = w
+		}
+	}
+	if theme := os.Getenv("UI_THEME"); theme != "" {
+		c.Client.UI.Theme = theme
+	}
+	if preset := os.Getenv("UI_KEYMAP"); preset != "" {
+		c.Client.UI.Keymap.Preset = preset
+	}
 	if timeout := os.Getenv("CONNECTION_TIMEOUT"); timeout != "" {
 		if t, err := strconv.Atoi(timeout); err == nil {
 			c.Client.Connection.Timeout = time.Duration(t) * time.Second
@@ -84,6 +135,7 @@ func (c *Config) overrideWithEnv() {
 			c.Client.Connection.RetryAttempts = r
 		}
 	}
+	c.Client.Tracing.OverrideWithEnv()
 }
 
 func (c *Config) validate() error {
@@ -105,5 +157,12 @@ func (c *Config) validate() error {
 	if c.Client.Connection.RetryAttempts < 0 {
 		return fmt.Errorf("invalid connection retry attempts: %d", c.Client.Connection.RetryAttempts)
 	}
+	if err := c.Client.Tracing.Validate(); err != nil {
+		return err
+	}
+	// The TUI draws on the terminal, so spans cannot be written to stderr
+	if c.Client.Tracing.Exporter == tracing.ExporterStdout {
+		return fmt.Errorf("the stdout tracing exporter cannot be used by the client, use file instead")
+	}
 	return nil
 }
@@ -0,0 +1,147 @@
+// Package i18n translates the messages of the TUI client.
+//
+// Catalogs are YAML files in locales, one per language. The current language
+// is process wide and can be switched at runtime with SetLanguage.
+package i18n
+
+import (
+	"embed"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// DefaultLanguage is used for messages missing in the current language
+const DefaultLanguage = "en"
+
+//go:embed locales/*.yaml
+var localeFiles embed.FS
+
+// message is a catalog entry with plural forms. Languages without plural
+// forms only set Other.
+type message struct {
+	One   string `yaml:"one"`
+	Other string `yaml:"other"`
+}
+
+// UnmarshalYAML accepts a plain string for messages without plural forms
+func (m *message) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var s string
+	if err := unmarshal(&s); err == nil {
+		m.Other = s
+		return nil
+	}
+	type plain message
+	return unmarshal((*plain)(m))
+}
+
+// pluralRules select the form of a message for a count
+var pluralRules = map[string]func(n int) string{
+	"en": func(n int) string {
+		if n == 1 {
+			return "one"
+		}
+		return "other"
+	},
+	"ja": func(int) string { return "other" },
+}
+
+var (
+	catalogs map[string]map[string]message
+	mu       sync.RWMutex
+	current  = DefaultLanguage
+)
+
+func init() {
+	catalogs = make(map[string]map[string]message)
+	for lang := range pluralRules {
+		data, err := localeFiles.ReadFile("locales/" + lang + ".yaml")
+		if err != nil {
+			panic(err)
+		}
+		catalog := make(map[string]message)
+		if err := yaml.Unmarshal(data, &catalog); err != nil {
+			panic(fmt.Sprintf("invalid catalog %s: %v", lang, err))
+		}
+		catalogs[lang] = catalog
+	}
+}
+
+// Languages returns the supported languages in a stable order
+func Languages() []string {
+	return []string{"en", "ja"}
+}
+
+// SetLanguage switches the language of all messages
+func SetLanguage(lang string) error {
+	lang = strings.ToLower(lang)
+	if _, ok := catalogs[lang]; !ok {
+		return fmt.Errorf("unsupported language %q, expected one of %s", lang, strings.Join(Languages(), ", "))
+	}
+	mu.Lock()
+	current = lang
+	mu.Unlock()
+	return nil
+}
+
+// Language returns the current language
+func Language() string {
+	mu.RLock()
+	defer mu.RUnlock()
+	return current
+}
+
+// T returns the message for key formatted with args
+func T(key string, args ...interface{}) string {
+	return format(lookup(key, -1), args)
+}
+
+// N returns the plural form of the message for key that matches n, formatted
+// with n followed by args
+func N(key string, n int, args ...interface{}) string {
+	return format(lookup(key, n), append([]interface{}{n}, args...))
+}
+
+// FormatDateTime formats t in local time the way the current language writes dates
+func FormatDateTime(t time.Time) string {
+	t = t.Local()
+	s := t.Format(lookup("date.format", -1))
+	if Language() == "ja" {
+		// Go only knows English weekday names
+		s = strings.Replace(s, t.Weekday().String()[:3], jaWeekdays[t.Weekday()], 1)
+	}
+	return s
+}
+
+var jaWeekdays = [...]string{"日", "月", "火", "水", "木", "金", "土"}
+
+// lookup returns the format of key in the current language, falling back to
+// the default language and then to the key itself. n < 0 selects the
+// singular form of messages without a count.
+func lookup(key string, n int) string {
+	lang := Language()
+	m, ok := catalogs[lang][key]
+	if !ok {
+		lang = DefaultLanguage
+		if m, ok = catalogs[lang][key]; !ok {
+			return key
+		}
+	}
+	if n >= 0 && pluralRules[lang](n) == "one" && m.One != "" {
+		return m.One
+	}
+	if m.Other == "" {
+		return m.One
+	}
+	return m.Other
+}
+
+func format(msg string, args []interface{}) string {
+	if len(args) == 0 {
+		return msg
+	}
+	return fmt.Sprintf(msg, args...)
+}
@@ -0,0 +1,96 @@
+package i18n
+
+import (
+	"regexp"
+	"testing"
+	"time"
+)
+
+var verb = regexp.MustCompile(`%[-+# 0-9.\[\]]*[a-zA-Z]`)
+
+// TestCatalogsMatch checks that every language has the messages of the
+// default language with the same format verbs
+func TestCatalogsMatch(t *testing.T) {
+	base := catalogs[DefaultLanguage]
+	for _, lang := range Languages() {
+		catalog := catalogs[lang]
+		for key, want := range base {
+			got, ok := catalog[key]
+			if !ok {
+				t.Errorf("%s: missing %s", lang, key)
+				continue
+			}
+			if w, g := len(verb.FindAllString(want.Other, -1)), len(verb.FindAllString(got.Other, -1)); w != g {
+				t.Errorf("%s: %s has %d format verbs, %s has %d", lang, key, g, DefaultLanguage, w)
+			}
+		}
+		for key := range catalog {
+			if _, ok := base[key]; !ok {
+				t.Errorf("%s: %s is not in the %s catalog", lang, key, DefaultLanguage)
+			}
+		}
+	}
+}
+
+func TestPlurals(t *testing.T) {
+	defer SetLanguage(DefaultLanguage)
+
+	SetLanguage("en")
+	if got := N("status.drafts", 1); got != "1 draft" {
+		t.Errorf("en one = %q", got)
+	}
+	if got := N("status.drafts", 3); got != "3 drafts" {
+		t.Errorf("en other = %q", got)
+	}
+
+	SetLanguage("ja")
+	if got := N("status.drafts", 1); got != "下書き 1 件" {
+		t.Errorf("ja = %q", got)
+	}
+	if got := T("no.such.key"); got != "no.such.key" {
+		t.Errorf("missing key = %q", got)
+	}
+	if err := SetLanguage("fr"); err == nil {
+		t.Error("unsupported language accepted")
+	}
+	if Language() != "ja" {
+		t.Errorf("language changed to %q", Language())
+	}
+}
+
+func TestFormatDateTime(t *testing.T) {
+	defer SetLanguage(DefaultLanguage)
+	date := time.Date(2024, 3, 4, 5, 6, 7, 0, time.Local)
+
+	SetLanguage("en")
+	if got := FormatDateTime(date); got != "Mon, Mar 4 2024 05:06:07" {
+		t.Errorf("en = %q", got)
+	}
+	SetLanguage("ja")
+	if got := FormatDateTime(date); got != "2024/03/04(月) 05:06:07" {
+		t.Errorf("ja = %q", got)
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		in    string
+		width int
+		want  string
+	}{
+		{"short", 10, "short"},
+		{"hello world", 8, "hello w…"},
+		{"平成掲示板へようこそ", 10, "平成掲示…"},
+		{"平成掲示板へようこそ", 9, "平成掲示…"},
+		{"abc", 0, ""},
+	}
+	for _, tt := range tests {
+		got := Truncate(tt.in, tt.width)
+		if got != tt.want {
+			t.Errorf("Truncate(%q, %d) = %q, want %q", tt.in, tt.width, got, tt.want)
+		}
+		if Width(got) > tt.width {
+			t.Errorf("Truncate(%q, %d) is %d cells wide", tt.in, tt.width, Width(got))
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package i18n
+
+import (
+	"github.com/mattn/go-runewidth"
+	"github.com/rivo/uniseg"
+)
+
+// SetAmbiguousWide sets whether characters of ambiguous East Asian width,
+// such as ★, ○ or Greek and Cyrillic letters, take two cells. CJK terminals
+// usually draw them wide. Both tview (layout) and tcell (drawing) must agree,
+// so call this before the screen is created.
+func SetAmbiguousWide(wide bool) {
+	uniseg.EastAsianAmbiguousWidth = 1
+	if wide {
+		uniseg.EastAsianAmbiguousWidth = 2
+	}
+	runewidth.DefaultCondition.EastAsianWidth = wide
+	runewidth.DefaultCondition.CreateLUT()
+}
+
+// Width returns the number of terminal cells s takes
+func Width(s string) int {
+	return uniseg.StringWidth(s)
+}
+
+// Truncate shortens s to at most width cells, ending it with an ellipsis if
+// anything was cut. Wide characters are never split.
+func Truncate(s string, width int) string {
+	if Width(s) <= width {
+		return s
+	}
+	const ellipsis = "…"
+	limit := width - Width(ellipsis)
+	if limit < 0 {
+		return ""
+	}
+
+	used := 0
+	state := -1
+	rest := s
+	var cluster string
+	var boundaries, clusterWidth int
+	for rest != "" {
+		cluster, rest, boundaries, state = uniseg.StepString(rest, state)
+		clusterWidth = boundaries >> uniseg.ShiftWidth
+		if used+clusterWidth > limit {
+			break
+		}
+		used += clusterWidth
+	}
+	return s[:len(s)-len(rest)-len(cluster)] + ellipsis
+}
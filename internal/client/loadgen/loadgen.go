@@ -0,0 +1,458 @@
+// Package loadgen simulates people using the board against a server and
+// measures the latency of their requests. It talks to the server through the
+// API client of the TUI, so it exercises the same requests.
+package loadgen
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"heisei/internal/client/api"
+	"heisei/internal/common/models"
+)
+
+// Operations measured by the load generator
+const (
+	OpCategories = "categories"
+	OpThreads    = "threads"
+	OpThread     = "thread"
+	OpPosts      = "posts"
+	OpPost       = "post"
+	// OpPoll is the request of a subscriber checking a watched thread
+	OpPoll = "poll"
+)
+
+// Mix is the relative weight of the actions of the users
+type Mix struct {
+	// Browse lists the categories and the threads of a board
+	Browse int
+	// Read opens a thread with its posts
+	Read int
+	// Post writes a post in an open thread
+	Post int
+}
+
+// ParseMix parses a mix written as browse=5,read=4,post=1; actions left
+// out get no weight
+func ParseMix(s string) (Mix, error) {
+	var mix Mix
+	for _, part := range strings.Split(s, ",") {
+		name, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		weight, err := strconv.Atoi(value)
+		if !ok || err != nil || weight < 0 {
+			return Mix{}, fmt.Errorf("invalid weight %q, want action=number", part)
+		}
+		switch name {
+		case "browse":
+			mix.Browse = weight
+		case "read":
+			mix.Read = weight
+		case "post":
+			mix.Post = weight
+		default:
+			return Mix{}, fmt.Errorf("unknown action %q", name)
+		}
+	}
+	return mix, nil
+}
+
+func (m Mix) String() string {
+	return fmt.Sprintf("browse=%d,read=%d,post=%d", m.Browse, m.Read, m.Post)
+}
+
+// Config describes the simulated load
+type Config struct {
+	// Users browse, read and post according to Mix, pausing for ThinkTime
+	// on average between two actions
+	Users     int
+	Mix       Mix
+	ThinkTime time.Duration
+	// Subscribers watch Watch threads each and poll them every PollInterval,
+	// as the TUI does for its watched threads
+	Subscribers  int
+	Watch        int
+	PollInterval time.Duration
+	// Ramp spreads the start of the users and subscribers. Requests are
+	// measured for Duration after it.
+	Ramp     time.Duration
+	Duration time.Duration
+	// Seed selects the actions and threads of the users
+	Seed int64
+}
+
+// Validate reports an invalid configuration
+func (c Config) Validate() error {
+	switch {
+	case c.Users < 0 || c.Subscribers < 0 || c.Watch < 0:
+		return errors.New("users, subscribers and watched threads cannot be negative")
+	case c.Users+c.Subscribers == 0:
+		return errors.New("no users or subscribers to simulate")
+	case c.Users > 0 && c.Mix.Browse+c.Mix.Read+c.Mix.Post == 0:
+		return errors.New("the mix has no actions")
+	case c.Subscribers > 0 && (c.Watch == 0 || c.PollInterval <= 0):
+		return errors.New("subscribers need watched threads and a poll interval")
+	case c.Duration <= 0:
+		return errors.New("duration must be positive")
+	case c.ThinkTime < 0 || c.Ramp < 0:
+		return errors.New("think time and ramp-up cannot be negative")
+	}
+	return nil
+}
+
+// Runner runs the simulated load against a server
+type Runner struct {
+	client   *api.Client
+	cfg      Config
+	catalog  *catalog
+	recorder *recorder
+}
+
+func New(client *api.Client, cfg Config) *Runner {
+	return &Runner{client: client, cfg: cfg}
+}
+
+// Run loads the categories and threads of the server, then runs the users
+// and subscribers until the duration is over or ctx is done. The report
+// covers the requests after the ramp-up.
+func (r *Runner) Run(ctx context.Context) (*Report, error) {
+	if err := r.cfg.Validate(); err != nil {
+		return nil, err
+	}
+	var err error
+	if r.catalog, err = loadCatalog(ctx, r.client); err != nil {
+		return nil, err
+	}
+
+	started := time.Now()
+	r.recorder = newRecorder(started.Add(r.cfg.Ramp))
+	ctx, cancel := context.WithDeadline(ctx, started.Add(r.cfg.Ramp+r.cfg.Duration))
+	defer cancel()
+
+	var wg sync.WaitGroup
+	total := r.cfg.Users + r.cfg.Subscribers
+	for i := 0; i < total; i++ {
+		delay := r.cfg.Ramp * time.Duration(i) / time.Duration(total)
+		rng := rand.New(rand.NewSource(r.cfg.Seed + int64(i)))
+		run := r.user
+		if i >= r.cfg.Users {
+			run = r.subscriber
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if sleep(ctx, delay) {
+				run(ctx, i, rng)
+			}
+		}(i)
+	}
+	wg.Wait()
+	return r.recorder.report(time.Now()), nil
+}
+
+// user performs actions chosen by the mix until ctx is done
+func (r *Runner) user(ctx context.Context, id int, rng *rand.Rand) {
+	mix := r.cfg.Mix
+	for n := 0; ctx.Err() == nil; n++ {
+		switch pick := rng.Intn(mix.Browse + mix.Read + mix.Post); {
+		case pick < mix.Browse:
+			r.browse(ctx, rng)
+		case pick < mix.Browse+mix.Read:
+			r.read(ctx, rng)
+		default:
+			r.post(ctx, rng, fmt.Sprintf("loadgen user %d post %d", id, n))
+		}
+		sleep(ctx, time.Duration(rng.ExpFloat64()*float64(r.cfg.ThinkTime)))
+	}
+}
+
+func (r *Runner) browse(ctx context.Context, rng *rand.Rand) {
+	err := r.measure(ctx, OpCategories, func() error {
+		_, err := r.client.GetCategories(ctx)
+		return err
+	})
+	board := r.catalog.randomBoard(rng)
+	if err != nil || board == nil {
+		return
+	}
+	var threads []models.ThreadDTO
+	err = r.measure(ctx, OpThreads, func() error {
+		threads, err = r.client.GetThreadsByCategory(ctx, board.ID, "")
+		return err
+	})
+	if err == nil {
+		r.catalog.setThreads(board.ID, threads)
+	}
+}
+
+func (r *Runner) read(ctx context.Context, rng *rand.Rand) {
+	thread := r.catalog.randomThread(rng, false)
+	if thread == nil {
+		return
+	}
+	err := r.measure(ctx, OpThread, func() error {
+		_, err := r.client.GetThreadByID(ctx, thread.ID)
+		return err
+	})
+	if err != nil {
+		return
+	}
+	r.measure(ctx, OpPosts, func() error {
+		_, err := r.client.GetPostsByThread(ctx, thread.ID)
+		return err
+	})
+}
+
+func (r *Runner) post(ctx context.Context, rng *rand.Rand, content string) {
+	thread := r.catalog.randomThread(rng, true)
+	if thread == nil {
+		return
+	}
+	err := r.measure(ctx, OpPost, func() error {
+		_, err := r.client.CreatePost(ctx, models.CreatePostRequest{
+			ThreadID: thread.ID,
+			Content:  content,
+			Sage:     rng.Intn(10) == 0,
+		})
+		return err
+	})
+	switch {
+	case err == nil:
+		r.catalog.addPost(thread.ID)
+	case errors.Is(err, api.ErrConflict):
+		// Locked or full since the threads were listed
+		r.catalog.close(thread.ID)
+	}
+}
+
+// subscriber polls its watched threads every poll interval until ctx is done
+func (r *Runner) subscriber(ctx context.Context, _ int, rng *rand.Rand) {
+	watched := make([]uint, 0, r.cfg.Watch)
+	for i := 0; i < r.cfg.Watch; i++ {
+		if thread := r.catalog.randomThread(rng, false); thread != nil {
+			watched = append(watched, thread.ID)
+		}
+	}
+	if len(watched) == 0 {
+		return
+	}
+
+	// Subscribers started together do not poll together
+	if !sleep(ctx, time.Duration(rng.Int63n(int64(r.cfg.PollInterval)))) {
+		return
+	}
+	ticker := time.NewTicker(r.cfg.PollInterval)
+	defer ticker.Stop()
+	for {
+		for _, id := range watched {
+			r.measure(ctx, OpPoll, func() error {
+				_, err := r.client.GetThreadByID(ctx, id)
+				return err
+			})
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// measure times a request and records it, unless the run ended while it
+// was in flight
+func (r *Runner) measure(ctx context.Context, op string, fn func() error) error {
+	start := time.Now()
+	err := fn()
+	if ctx.Err() == nil {
+		r.recorder.record(op, start, time.Since(start), err)
+	}
+	return err
+}
+
+// sleep waits for d and reports whether ctx is still running
+func sleep(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// catalog holds the boards and threads known to the users. The threads are
+// kept in flat indexes, all of them and those that accept posts, so that
+// picking one at random does not depend on their number.
+type catalog struct {
+	mu      sync.RWMutex
+	boards  []models.CategoryDTO
+	byID    map[uint]*catalogThread
+	byBoard map[uint][]*catalogThread
+	all     []*catalogThread
+	open    []*catalogThread
+}
+
+// catalogThread is a thread with its positions in the indexes of the catalog;
+// open is -1 if the thread does not accept posts
+type catalogThread struct {
+	thread    models.ThreadDTO
+	board     models.CategoryDTO
+	all, open int
+}
+
+// loadCatalog lists the boards, categories that are not sections, and
+// their threads
+func loadCatalog(ctx context.Context, client *api.Client) (*catalog, error) {
+	categories, err := client.GetCategories(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list categories: %w", err)
+	}
+	sections := make(map[uint]bool)
+	for _, category := range categories {
+		if category.ParentID != nil {
+			sections[*category.ParentID] = true
+		}
+	}
+
+	c := newCatalog()
+	for _, category := range categories {
+		if sections[category.ID] {
+			continue
+		}
+		threads, err := client.GetThreadsByCategory(ctx, category.ID, "")
+		if err != nil {
+			return nil, fmt.Errorf("failed to list threads of category %d: %w", category.ID, err)
+		}
+		c.boards = append(c.boards, category)
+		c.setThreads(category.ID, threads)
+	}
+	if len(c.all) == 0 {
+		return nil, errors.New("the server has no threads; seed the database first")
+	}
+	return c, nil
+}
+
+func newCatalog() *catalog {
+	return &catalog{
+		byID:    make(map[uint]*catalogThread),
+		byBoard: make(map[uint][]*catalogThread),
+	}
+}
+
+func (c *catalog) randomBoard(rng *rand.Rand) *models.CategoryDTO {
+	if len(c.boards) == 0 {
+		return nil
+	}
+	return &c.boards[rng.Intn(len(c.boards))]
+}
+
+// randomThread returns a random thread, one that accepts posts if open is
+// set, or nil if there is none
+func (c *catalog) randomThread(rng *rand.Rand, open bool) *models.ThreadDTO {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	threads := c.all
+	if open {
+		threads = c.open
+	}
+	if len(threads) == 0 {
+		return nil
+	}
+	thread := threads[rng.Intn(len(threads))].thread
+	return &thread
+}
+
+// setThreads replaces the threads of a board with the listed ones
+func (c *catalog) setThreads(boardID uint, threads []models.ThreadDTO) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var board models.CategoryDTO
+	for _, b := range c.boards {
+		if b.ID == boardID {
+			board = b
+			break
+		}
+	}
+
+	for _, t := range c.byBoard[boardID] {
+		// Skip threads listed on another board since
+		if c.byID[t.thread.ID] == t {
+			c.remove(t)
+		}
+	}
+	listed := make([]*catalogThread, 0, len(threads))
+	for _, thread := range threads {
+		if old := c.byID[thread.ID]; old != nil {
+			c.remove(old)
+		}
+		t := &catalogThread{thread: thread, board: board, all: len(c.all), open: -1}
+		c.all = append(c.all, t)
+		c.byID[thread.ID] = t
+		c.reindex(t)
+		listed = append(listed, t)
+	}
+	c.byBoard[boardID] = listed
+}
+
+// remove drops a thread from the indexes by moving the last thread of each
+// into its place
+func (c *catalog) remove(t *catalogThread) {
+	last := c.all[len(c.all)-1]
+	c.all[t.all], last.all = last, t.all
+	c.all = c.all[:len(c.all)-1]
+	c.closeThread(t)
+	delete(c.byID, t.thread.ID)
+}
+
+// reindex adds a thread to the open index or drops it from there, depending on
+// whether it accepts posts
+func (c *catalog) reindex(t *catalogThread) {
+	full := t.board.MaxPosts > 0 && t.thread.PostCount >= t.board.MaxPosts
+	accepts := !t.board.ReadOnly && !t.thread.Locked && !full
+	switch {
+	case accepts && t.open < 0:
+		t.open = len(c.open)
+		c.open = append(c.open, t)
+	case !accepts:
+		c.closeThread(t)
+	}
+}
+
+// closeThread drops a thread from the open index, if it is there
+func (c *catalog) closeThread(t *catalogThread) {
+	if t.open < 0 {
+		return
+	}
+	last := c.open[len(c.open)-1]
+	c.open[t.open], last.open = last, t.open
+	c.open = c.open[:len(c.open)-1]
+	t.open = -1
+}
+
+// update applies fn to the thread with the given ID
+func (c *catalog) update(id uint, fn func(thread *models.ThreadDTO)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if t := c.byID[id]; t != nil {
+		fn(&t.thread)
+		c.reindex(t)
+	}
+}
+
+func (c *catalog) addPost(id uint) {
+	c.update(id, func(thread *models.ThreadDTO) { thread.PostCount++ })
+}
+
+// close keeps users from posting to a thread that refused a post
+func (c *catalog) close(id uint) {
+	c.update(id, func(thread *models.ThreadDTO) { thread.Locked = true })
+}
@@ -0,0 +1,222 @@
+package loadgen
+
+import (
+	"bytes"
+	"context"
+	"maps"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"heisei/internal/client/api"
+	"heisei/internal/common/models"
+)
+
+// fakeServer answers like the API server for a section with one board of
+// two threads; thread 8 is locked, and the posts of thread 7 fail
+type fakeServer struct {
+	mu sync.Mutex
+	// sectionListed is set when the threads of the section are requested
+	sectionListed bool
+}
+
+func (f *fakeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/api/v1/threads" && r.URL.Query().Get("category_id") == "1" {
+		f.mu.Lock()
+		f.sectionListed = true
+		f.mu.Unlock()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/api/v1/categories":
+		w.Write([]byte(`[{"id":1,"name":"Boards","slug":"boards"},{"id":2,"parent_id":1,"name":"News","slug":"news","max_posts":1000}]`))
+	case r.Method == http.MethodGet && r.URL.Path == "/api/v1/threads" && r.URL.Query().Get("category_id") == "2":
+		w.Write([]byte(`[{"id":7,"category_id":2,"title":"Open","post_count":3},{"id":8,"category_id":2,"title":"Locked","post_count":1,"locked":true}]`))
+	case r.Method == http.MethodGet && r.URL.Path == "/api/v1/threads/7":
+		w.Write([]byte(`{"id":7,"category_id":2,"title":"Open","post_count":3}`))
+	case r.Method == http.MethodGet && r.URL.Path == "/api/v1/threads/8":
+		w.Write([]byte(`{"id":8,"category_id":2,"title":"Locked","post_count":1,"locked":true}`))
+	case r.Method == http.MethodGet && r.URL.Path == "/api/v1/threads/7/posts":
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(`{"code":"internal_error","message":"boom"}`))
+	case r.Method == http.MethodGet && r.URL.Path == "/api/v1/threads/8/posts":
+		w.Write([]byte(`[{"id":1,"thread_id":8,"content":"closed"}]`))
+	case r.Method == http.MethodPost && r.URL.Path == "/api/v1/posts":
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id":4,"thread_id":7,"content":"posted"}`))
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"code":"not_found","message":"not found"}`))
+	}
+}
+
+func TestRun(t *testing.T) {
+	fake := &fakeServer{}
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+	client := api.NewClient(srv.URL, api.WithHTTPClient(srv.Client()), api.WithRetries(0, 0))
+
+	report, err := New(client, Config{
+		Users:        4,
+		Mix:          Mix{Browse: 1, Read: 1, Post: 1},
+		ThinkTime:    time.Millisecond,
+		Subscribers:  2,
+		Watch:        2,
+		PollInterval: 10 * time.Millisecond,
+		Duration:     200 * time.Millisecond,
+		Seed:         1,
+	}).Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ops := make(map[string]OperationReport)
+	for _, op := range report.Operations {
+		ops[op.Name] = op
+	}
+	for _, name := range []string{OpCategories, OpThreads, OpThread, OpPosts, OpPost, OpPoll} {
+		if ops[name].Requests == 0 {
+			t.Errorf("no %s requests measured", name)
+		}
+	}
+	fake.mu.Lock()
+	if fake.sectionListed {
+		t.Error("requested the threads of the section")
+	}
+	fake.mu.Unlock()
+	// Only the open thread is posted to
+	if ops[OpPost].Errors > 0 {
+		t.Errorf("%d posts failed", ops[OpPost].Errors)
+	}
+	if posts := ops[OpPosts]; posts.Errors == 0 || posts.ErrorKinds["500"] != posts.Errors {
+		t.Errorf("posts errors = %v, want the failures of thread 7 as 500", posts.ErrorKinds)
+	}
+	if report.Total.Requests != sum(report.Operations) || report.Total.Throughput <= 0 {
+		t.Errorf("total = %+v, does not add up the operations", report.Total)
+	}
+	if report.Total.P50 > report.Total.P99 || report.Total.P99 > report.Total.Max {
+		t.Errorf("percentiles out of order: %+v", report.Total)
+	}
+
+	var out bytes.Buffer
+	if err := report.WriteText(&out); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "OPERATION") || !strings.Contains(out.String(), "errors: 500") {
+		t.Errorf("unexpected report:\n%s", out.String())
+	}
+}
+
+func sum(ops []OperationReport) int {
+	var n int
+	for _, op := range ops {
+		n += op.Requests
+	}
+	return n
+}
+
+func TestRunWithoutThreads(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[]`))
+	}))
+	t.Cleanup(srv.Close)
+	client := api.NewClient(srv.URL, api.WithHTTPClient(srv.Client()), api.WithRetries(0, 0))
+
+	_, err := New(client, Config{Users: 1, Mix: Mix{Read: 1}, Duration: time.Second}).Run(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "no threads") {
+		t.Errorf("err = %v, want no threads", err)
+	}
+}
+
+func TestCatalog(t *testing.T) {
+	c := newCatalog()
+	c.boards = []models.CategoryDTO{{ID: 1, MaxPosts: 3}, {ID: 2, ReadOnly: true}}
+	c.setThreads(1, []models.ThreadDTO{{ID: 10, PostCount: 1}, {ID: 11, PostCount: 1}, {ID: 12, Locked: true}})
+	c.setThreads(2, []models.ThreadDTO{{ID: 20}})
+	rng := rand.New(rand.NewSource(1))
+
+	openIDs := func() []uint {
+		seen := make(map[uint]bool)
+		for i := 0; i < 200; i++ {
+			if thread := c.randomThread(rng, true); thread != nil {
+				seen[thread.ID] = true
+			}
+		}
+		ids := slices.Collect(maps.Keys(seen))
+		slices.Sort(ids)
+		return ids
+	}
+	if got := openIDs(); !slices.Equal(got, []uint{10, 11}) {
+		t.Fatalf("open threads = %v, want [10 11]", got)
+	}
+
+	// Thread 10 fills up and thread 11 refuses a post
+	c.addPost(10)
+	c.addPost(10)
+	c.close(11)
+	if thread := c.randomThread(rng, true); thread != nil {
+		t.Fatalf("open thread %d, want none", thread.ID)
+	}
+
+	// Listing the board again replaces its threads; thread 20 moved there
+	c.setThreads(1, []models.ThreadDTO{{ID: 11, PostCount: 2}, {ID: 20}})
+	if got := openIDs(); !slices.Equal(got, []uint{11, 20}) {
+		t.Fatalf("open threads after listing = %v, want [11 20]", got)
+	}
+	if len(c.all) != 2 || len(c.byID) != 2 {
+		t.Errorf("catalog holds %d threads, %d by ID; want 2", len(c.all), len(c.byID))
+	}
+}
+
+func TestParseMix(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Mix
+		wantErr bool
+	}{
+		{in: "browse=5,read=4,post=1", want: Mix{Browse: 5, Read: 4, Post: 1}},
+		{in: "read=1", want: Mix{Read: 1}},
+		{in: " post=2 , read=3", want: Mix{Read: 3, Post: 2}},
+		{in: "write=1", wantErr: true},
+		{in: "read", wantErr: true},
+		{in: "read=-1", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseMix(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseMix(%q) = %v, %v; want %v, error %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	latencies := make([]time.Duration, 100)
+	for i := range latencies {
+		latencies[i] = time.Duration(i+1) * time.Millisecond
+	}
+	tests := []struct {
+		p    float64
+		want time.Duration
+	}{
+		{0.5, 50 * time.Millisecond},
+		{0.9, 90 * time.Millisecond},
+		{0.99, 99 * time.Millisecond},
+		{1, 100 * time.Millisecond},
+		{0, time.Millisecond},
+	}
+	for _, tt := range tests {
+		if got := percentile(latencies, tt.p); got != tt.want {
+			t.Errorf("percentile(%v) = %v, want %v", tt.p, got, tt.want)
+		}
+	}
+	if got := percentile(nil, 0.5); got != 0 {
+		t.Errorf("percentile of no latencies = %v, want 0", got)
+	}
+}
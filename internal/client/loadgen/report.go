@@ -0,0 +1,191 @@
+package loadgen
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"sort"
+	"strconv"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"heisei/internal/client/api"
+)
+
+// Report summarizes the requests of a run
+type Report struct {
+	// Elapsed is the measured time, from the end of the ramp-up
+	Elapsed    time.Duration     `json:"elapsed_ns"`
+	Operations []OperationReport `json:"operations"`
+	Total      OperationReport   `json:"total"`
+}
+
+// OperationReport summarizes the requests of an operation. Latencies
+// include failed requests and are in milliseconds.
+type OperationReport struct {
+	Name     string `json:"name"`
+	Requests int    `json:"requests"`
+	Errors   int    `json:"errors"`
+	// ErrorRate is the share of failed requests, from 0 to 1
+	ErrorRate float64 `json:"error_rate"`
+	// Throughput is the number of requests per second
+	Throughput float64 `json:"throughput"`
+	P50        float64 `json:"p50_ms"`
+	P90        float64 `json:"p90_ms"`
+	P99        float64 `json:"p99_ms"`
+	Max        float64 `json:"max_ms"`
+	// ErrorKinds counts the errors by HTTP status, timeout or network
+	ErrorKinds map[string]int `json:"error_kinds,omitempty"`
+}
+
+// WriteText writes the report as a table with a line per operation
+func (r *Report) WriteText(w io.Writer) error {
+	ops := append(r.Operations[:len(r.Operations):len(r.Operations)], r.Total)
+	// Numbers are aligned right, and names are padded to stay on the left
+	width := len("OPERATION")
+	for _, op := range ops {
+		width = max(width, len(op.Name))
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(tw, "%-*s\tREQUESTS\tERRORS\tERROR RATE\tREQ/S\tP50\tP90\tP99\tMAX\t\n", width, "OPERATION")
+	for _, op := range ops {
+		fmt.Fprintf(tw, "%-*s\t%d\t%d\t%.2f%%\t%.1f\t%s\t%s\t%s\t%s\t\n", width, op.Name, op.Requests, op.Errors, op.ErrorRate*100,
+			op.Throughput, formatMS(op.P50), formatMS(op.P90), formatMS(op.P99), formatMS(op.Max))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "\n%d requests in %s", r.Total.Requests, r.Elapsed.Round(time.Millisecond))
+	if len(r.Total.ErrorKinds) > 0 {
+		kinds := make([]string, 0, len(r.Total.ErrorKinds))
+		for kind := range r.Total.ErrorKinds {
+			kinds = append(kinds, kind)
+		}
+		sort.Strings(kinds)
+		fmt.Fprint(w, "; errors:")
+		for _, kind := range kinds {
+			fmt.Fprintf(w, " %s %d", kind, r.Total.ErrorKinds[kind])
+		}
+	}
+	_, err := fmt.Fprintln(w)
+	return err
+}
+
+func formatMS(ms float64) string {
+	return strconv.FormatFloat(ms, 'f', 1, 64) + "ms"
+}
+
+// recorder collects the latencies and errors of the requests started after
+// start
+type recorder struct {
+	mu    sync.Mutex
+	start time.Time
+	ops   map[string]*samples
+}
+
+type samples struct {
+	latencies []time.Duration
+	errors    map[string]int
+}
+
+func newRecorder(start time.Time) *recorder {
+	return &recorder{start: start, ops: make(map[string]*samples)}
+}
+
+func (r *recorder) record(op string, started time.Time, latency time.Duration, err error) {
+	if started.Before(r.start) {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s := r.ops[op]
+	if s == nil {
+		s = &samples{errors: make(map[string]int)}
+		r.ops[op] = s
+	}
+	s.latencies = append(s.latencies, latency)
+	if err != nil {
+		s.errors[errorKind(err)]++
+	}
+}
+
+// report summarizes the requests recorded until end
+func (r *recorder) report(end time.Time) *Report {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	report := &Report{Elapsed: max(end.Sub(r.start), 0)}
+
+	names := make([]string, 0, len(r.ops))
+	for name := range r.ops {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	total := &samples{errors: make(map[string]int)}
+	for _, name := range names {
+		s := r.ops[name]
+		report.Operations = append(report.Operations, s.summarize(name, report.Elapsed))
+		total.latencies = append(total.latencies, s.latencies...)
+		for kind, n := range s.errors {
+			total.errors[kind] += n
+		}
+	}
+	report.Total = total.summarize("total", report.Elapsed)
+	return report
+}
+
+func (s *samples) summarize(name string, elapsed time.Duration) OperationReport {
+	sort.Slice(s.latencies, func(i, j int) bool { return s.latencies[i] < s.latencies[j] })
+	op := OperationReport{Name: name, Requests: len(s.latencies)}
+	for _, n := range s.errors {
+		op.Errors += n
+	}
+	if len(s.errors) > 0 {
+		op.ErrorKinds = s.errors
+	}
+	if op.Requests == 0 {
+		return op
+	}
+	op.ErrorRate = float64(op.Errors) / float64(op.Requests)
+	if elapsed > 0 {
+		op.Throughput = float64(op.Requests) / elapsed.Seconds()
+	}
+	op.P50 = milliseconds(percentile(s.latencies, 0.50))
+	op.P90 = milliseconds(percentile(s.latencies, 0.90))
+	op.P99 = milliseconds(percentile(s.latencies, 0.99))
+	op.Max = milliseconds(s.latencies[len(s.latencies)-1])
+	return op
+}
+
+// percentile returns the nearest-rank percentile p, from 0 to 1, of sorted
+// latencies
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p*float64(len(sorted)))) - 1
+	return sorted[min(max(rank, 0), len(sorted)-1)]
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// errorKind classifies an error by its HTTP status, or as a timeout or a
+// network error
+func errorKind(err error) string {
+	var apiErr *api.APIError
+	var netErr net.Error
+	switch {
+	case errors.As(err, &apiErr):
+		return strconv.Itoa(apiErr.StatusCode)
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return "timeout"
+	default:
+		return "network"
+	}
+}
@@ -0,0 +1,111 @@
+package state
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"heisei/internal/client/cache"
+	"heisei/internal/common/models"
+)
+
+const readMarksKey = "read"
+
+// ReadMark records how far a thread has been read
+type ReadMark struct {
+	// PostCount is the post count of the thread when it was last read
+	PostCount int `json:"post_count"`
+	// LastPostID is the newest post seen, zero if the thread was only marked read
+	LastPostID uint `json:"last_post_id"`
+	// LastPostAt is the creation time of the newest post seen, to find the
+	// new posts if it was deleted since
+	LastPostAt time.Time `json:"last_post_at"`
+}
+
+// ReadMarks tracks the read position of each thread of one server
+type ReadMarks struct {
+	store *cache.Store
+	mu    sync.Mutex
+	marks map[uint]ReadMark
+}
+
+// LoadReadMarks reads the marks saved in store
+func LoadReadMarks(store *cache.Store) (*ReadMarks, error) {
+	marks := make(map[uint]ReadMark)
+	if _, err := store.Get(readMarksKey, &marks); err != nil && !errors.Is(err, cache.ErrMiss) {
+		return nil, err
+	}
+	return &ReadMarks{store: store, marks: marks}, nil
+}
+
+// Unread returns the number of posts added to the thread since it was last read
+func (r *ReadMarks) Unread(thread *models.ThreadDTO) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return max(thread.PostCount-r.marks[thread.ID].PostCount, 0)
+}
+
+// FirstUnread returns the index of the first unread post of the thread, or -1
+// if all posts have been read. posts are the posts of the thread as listed by
+// the server, oldest first.
+func (r *ReadMarks) FirstUnread(thread *models.ThreadDTO, posts []models.PostDTO) int {
+	r.mu.Lock()
+	mark := r.marks[thread.ID]
+	r.mu.Unlock()
+
+	if mark.LastPostID == 0 {
+		// Only the count is known; the posts listed are the ones counted
+		unread := len(posts) - mark.PostCount
+		if unread <= 0 {
+			return -1
+		}
+		return max(len(posts)-unread, 0)
+	}
+	// The posts after the newest one seen are new
+	for i, post := range posts {
+		if post.ID == mark.LastPostID {
+			if i+1 < len(posts) {
+				return i + 1
+			}
+			return -1
+		}
+	}
+	// It was deleted, so the new posts are the ones written after it
+	for i, post := range posts {
+		if post.CreatedAt.After(mark.LastPostAt) {
+			return i
+		}
+	}
+	return -1
+}
+
+// MarkRead records that the thread has been read up to lastPost, the newest
+// post shown, or nil if it has no posts
+func (r *ReadMarks) MarkRead(thread *models.ThreadDTO, lastPost *models.PostDTO) error {
+	return r.update(func(marks map[uint]ReadMark) {
+		mark := marks[thread.ID]
+		mark.PostCount = thread.PostCount
+		if lastPost != nil && !lastPost.CreatedAt.Before(mark.LastPostAt) {
+			mark.LastPostID = lastPost.ID
+			mark.LastPostAt = lastPost.CreatedAt
+		}
+		marks[thread.ID] = mark
+	})
+}
+
+// MarkAllRead records that all given threads have been read
+func (r *ReadMarks) MarkAllRead(threads []models.ThreadDTO) error {
+	return r.update(func(marks map[uint]ReadMark) {
+		for _, thread := range threads {
+			// The newest post is unknown, so unread posts are located by count
+			marks[thread.ID] = ReadMark{PostCount: thread.PostCount}
+		}
+	})
+}
+
+func (r *ReadMarks) update(fn func(map[uint]ReadMark)) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	fn(r.marks)
+	return r.store.Put(readMarksKey, r.marks)
+}
@@ -0,0 +1,119 @@
+package state
+
+import (
+	"testing"
+	"time"
+
+	"heisei/internal/client/cache"
+	"heisei/internal/common/models"
+)
+
+func newReadMarks(t *testing.T, dir string) *ReadMarks {
+	t.Helper()
+	store, err := cache.NewStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	marks, err := LoadReadMarks(store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return marks
+}
+
+// posts returns posts in thread order, written a minute apart
+func posts(ids ...uint) []models.PostDTO {
+	start := time.Date(2024, 4, 1, 12, 0, 0, 0, time.UTC)
+	result := make([]models.PostDTO, len(ids))
+	for i, id := range ids {
+		result[i] = models.PostDTO{ID: id, CreatedAt: start.Add(time.Duration(i) * time.Minute)}
+	}
+	return result
+}
+
+func TestUnreadSinceLastVisit(t *testing.T) {
+	dir := t.TempDir()
+	marks := newReadMarks(t, dir)
+	thread := &models.ThreadDTO{ID: 1, PostCount: 3}
+
+	if n := marks.Unread(thread); n != 3 {
+		t.Errorf("unread of unvisited thread = %d, want 3", n)
+	}
+	if i := marks.FirstUnread(thread, posts(1, 2, 3)); i != 0 {
+		t.Errorf("first unread of unvisited thread = %d, want 0", i)
+	}
+
+	if err := marks.MarkRead(thread, &posts(1, 2, 3)[2]); err != nil {
+		t.Fatal(err)
+	}
+	thread.PostCount = 5
+
+	// Marks survive a restart
+	marks = newReadMarks(t, dir)
+	if n := marks.Unread(thread); n != 2 {
+		t.Errorf("unread = %d, want 2", n)
+	}
+	if i := marks.FirstUnread(thread, posts(1, 2, 3, 7, 9)); i != 3 {
+		t.Errorf("first unread = %d, want 3", i)
+	}
+}
+
+func TestMarkAllRead(t *testing.T) {
+	marks := newReadMarks(t, t.TempDir())
+	threads := []models.ThreadDTO{{ID: 1, PostCount: 4}, {ID: 2, PostCount: 1}}
+
+	if err := marks.MarkAllRead(threads); err != nil {
+		t.Fatal(err)
+	}
+	for _, thread := range threads {
+		if n := marks.Unread(&thread); n != 0 {
+			t.Errorf("thread %d unread = %d, want 0", thread.ID, n)
+		}
+		if i := marks.FirstUnread(&thread, posts(1)); i != -1 {
+			t.Errorf("thread %d first unread = %d, want -1", thread.ID, i)
+		}
+	}
+
+	// Without a known last post, the new posts are the last ones
+	thread := &models.ThreadDTO{ID: 1, PostCount: 6}
+	if i := marks.FirstUnread(thread, posts(1, 2, 3, 4, 5, 6)); i != 4 {
+		t.Errorf("first unread = %d, want 4", i)
+	}
+}
+
+func TestFirstUnreadFollowsThreadOrder(t *testing.T) {
+	marks := newReadMarks(t, t.TempDir())
+	thread := &models.ThreadDTO{ID: 1, PostCount: 3}
+
+	// Imported posts can have IDs out of thread order
+	read := posts(8, 2, 5)
+	if err := marks.MarkRead(thread, &read[2]); err != nil {
+		t.Fatal(err)
+	}
+	thread.PostCount = 5
+	if i := marks.FirstUnread(thread, posts(8, 2, 5, 4, 9)); i != 3 {
+		t.Errorf("first unread = %d, want 3", i)
+	}
+	if i := marks.FirstUnread(thread, read); i != -1 {
+		t.Errorf("first unread without new posts = %d, want -1", i)
+	}
+}
+
+func TestFirstUnreadAfterDeletedPost(t *testing.T) {
+	marks := newReadMarks(t, t.TempDir())
+	thread := &models.ThreadDTO{ID: 1, PostCount: 3}
+	read := posts(1, 2, 3)
+	if err := marks.MarkRead(thread, &read[2]); err != nil {
+		t.Fatal(err)
+	}
+
+	// The last post read is gone and two posts were added
+	current := []models.PostDTO{read[0], read[1],
+		{ID: 4, CreatedAt: read[2].CreatedAt.Add(time.Minute)},
+		{ID: 5, CreatedAt: read[2].CreatedAt.Add(2 * time.Minute)},
+	}
+	thread.PostCount = 4
+	if i := marks.FirstUnread(thread, current); i != 2 {
+		t.Errorf("first unread = %d, want 2", i)
+	}
+}
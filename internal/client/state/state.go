@@ -0,0 +1,34 @@
+// Package state keeps per-server client state, such as read marks, that must
+// survive clearing the cache
+package state
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"heisei/internal/client/cache"
+)
+
+// DefaultDir returns the state directory of a server,
+// $XDG_STATE_HOME/heisei/<server> or ~/.local/state/heisei/<server>
+func DefaultDir(serverURL string) (string, error) {
+	base := os.Getenv("XDG_STATE_HOME")
+	if base == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to locate user state directory: %w", err)
+		}
+		base = filepath.Join(home, ".local", "state")
+	}
+	return filepath.Join(base, "heisei", cache.ServerKey(serverURL)), nil
+}
+
+// Open creates the state store of a server in its default directory
+func Open(serverURL string) (*cache.Store, error) {
+	dir, err := DefaultDir(serverURL)
+	if err != nil {
+		return nil, err
+	}
+	return cache.NewStore(dir)
+}
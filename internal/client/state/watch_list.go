@@ -0,0 +1,98 @@
+package state
+
+import (
+	"errors"
+	"sort"
+	"sync"
+
+	"heisei/internal/client/cache"
+	"heisei/internal/common/models"
+)
+
+const watchListKey = "watched"
+
+// WatchList holds the starred threads of one server with the state last seen
+// by the poller
+type WatchList struct {
+	store   *cache.Store
+	mu      sync.Mutex
+	threads map[uint]models.ThreadDTO
+}
+
+// LoadWatchList reads the watch list saved in store
+func LoadWatchList(store *cache.Store) (*WatchList, error) {
+	threads := make(map[uint]models.ThreadDTO)
+	if _, err := store.Get(watchListKey, &threads); err != nil && !errors.Is(err, cache.ErrMiss) {
+		return nil, err
+	}
+	return &WatchList{store: store, threads: threads}, nil
+}
+
+// IsWatched reports whether the thread is starred
+func (w *WatchList) IsWatched(threadID uint) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	_, ok := w.threads[threadID]
+	return ok
+}
+
+// Toggle stars or unstars the thread and reports whether it is now watched
+func (w *WatchList) Toggle(thread *models.ThreadDTO) (bool, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	_, watched := w.threads[thread.ID]
+	if watched {
+		delete(w.threads, thread.ID)
+	} else {
+		w.threads[thread.ID] = *thread
+	}
+	return !watched, w.store.Put(watchListKey, w.threads)
+}
+
+// Remove unstars the thread, e.g. after it was deleted
+func (w *WatchList) Remove(threadID uint) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if _, ok := w.threads[threadID]; !ok {
+		return nil
+	}
+	delete(w.threads, threadID)
+	return w.store.Put(watchListKey, w.threads)
+}
+
+// Threads returns the watched threads, most recently active first
+func (w *WatchList) Threads() []models.ThreadDTO {
+	w.mu.Lock()
+	threads := make([]models.ThreadDTO, 0, len(w.threads))
+	for _, thread := range w.threads {
+		threads = append(threads, thread)
+	}
+	w.mu.Unlock()
+
+	sort.Slice(threads, func(i, j int) bool {
+		if !threads[i].LastPostAt.Equal(threads[j].LastPostAt) {
+			return threads[i].LastPostAt.After(threads[j].LastPostAt)
+		}
+		return threads[i].ID > threads[j].ID
+	})
+	return threads
+}
+
+// Update stores the latest state of a watched thread and returns how many
+// posts were added since the previous update. Unwatched threads are ignored.
+func (w *WatchList) Update(thread *models.ThreadDTO) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	previous, ok := w.threads[thread.ID]
+	if !ok {
+		return 0, nil
+	}
+	if previous == *thread {
+		return 0, nil
+	}
+	w.threads[thread.ID] = *thread
+	return max(thread.PostCount-previous.PostCount, 0), w.store.Put(watchListKey, w.threads)
+}
@@ -0,0 +1,73 @@
+package state
+
+import (
+	"testing"
+	"time"
+
+	"heisei/internal/client/cache"
+	"heisei/internal/common/models"
+)
+
+func TestWatchList(t *testing.T) {
+	dir := t.TempDir()
+	store, err := cache.NewStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	watched, err := LoadWatchList(store)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	older := &models.ThreadDTO{ID: 1, Title: "older", PostCount: 2, LastPostAt: now.Add(-time.Hour)}
+	newer := &models.ThreadDTO{ID: 2, Title: "newer", PostCount: 5, LastPostAt: now}
+	for _, thread := range []*models.ThreadDTO{older, newer} {
+		if ok, err := watched.Toggle(thread); err != nil || !ok {
+			t.Fatalf("Toggle(%d) = %v, %v", thread.ID, ok, err)
+		}
+	}
+
+	// Reload to check that the list is persisted
+	watched, err = LoadWatchList(store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	threads := watched.Threads()
+	if len(threads) != 2 || threads[0].ID != 2 || threads[1].ID != 1 {
+		t.Fatalf("threads not sorted by last post: %+v", threads)
+	}
+
+	replied := *older
+	replied.PostCount = 4
+	replied.LastPostAt = now.Add(time.Minute)
+	if added, err := watched.Update(&replied); err != nil || added != 2 {
+		t.Errorf("Update = %d, %v, want 2 new posts", added, err)
+	}
+	if added, _ := watched.Update(&replied); added != 0 {
+		t.Errorf("repeated Update = %d, want 0", added)
+	}
+	if threads := watched.Threads(); threads[0].ID != 1 {
+		t.Errorf("replied thread should be first: %+v", threads)
+	}
+
+	if ok, err := watched.Toggle(newer); err != nil || ok {
+		t.Fatalf("unwatch = %v, %v", ok, err)
+	}
+	if watched.IsWatched(newer.ID) {
+		t.Error("thread is still watched")
+	}
+	if added, _ := watched.Update(newer); added != 0 {
+		t.Errorf("Update of unwatched thread = %d, want 0", added)
+	}
+
+	if err := watched.Remove(older.ID); err != nil {
+		t.Fatal(err)
+	}
+	if watched, err = LoadWatchList(store); err != nil {
+		t.Fatal(err)
+	}
+	if threads := watched.Threads(); len(threads) != 0 {
+		t.Errorf("threads after Remove = %+v, want none", threads)
+	}
+}
@@ -1,29 +1,118 @@
 package tui
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
 	"heisei/internal/client/api"
+	"heisei/internal/client/cache"
 	"heisei/internal/client/config"
+	"heisei/internal/client/i18n"
+	"heisei/internal/client/state"
+	"heisei/internal/client/tui/keys"
+	"heisei/internal/client/tui/screens"
+	"heisei/internal/client/tui/theme"
+	"heisei/internal/client/tui/widgets"
+	"heisei/internal/common/models"
 
+	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
 	"go.uber.org/zap"
 )
 
+// Page names
+const (
+	pageCategories = "categories"
+	pageThreads    = "threads"
+	pageThread     = "thread"
+	pageWatched    = "watched"
+)
+
 type App struct {
 	*tview.Application
 	Config    *config.Config
 	APIClient *api.Client
 	Logger    *zap.Logger
 
+	client  *cache.Client
+	marks   *state.ReadMarks
+	watched *state.WatchList
+	keymap  *keys.Keymap
+
 	// Main layout
-	mainFlex *tview.Flex
+	mainFlex  *tview.Flex
+	pages     *tview.Pages
+	statusBar *tview.TextView
+
+	categoryList *screens.CategoryList
+	threadList   *screens.ThreadList
+	threadDetail *screens.ThreadDetail
+	watchedList  *screens.WatchedList
+	help         *widgets.Help
+
+	// threadReturnPage is the page that Escape returns to from a thread
+	threadReturnPage string
+	// screen is the terminal, captured on draw for the bell
+	screen tcell.Screen
+
+	// notice is shown in the status bar until the next navigation
+	notice string
 }
 
 func NewApp(cfg *config.Config, apiClient *api.Client, logger *zap.Logger) (*App, error) {
+	if err := i18n.SetLanguage(cfg.Client.UI.Language); err != nil {
+		return nil, err
+	}
+	i18n.SetAmbiguousWide(cfg.Client.UI.AmbiguousWide)
+	if err := theme.Apply(cfg.Client.UI.Theme); err != nil {
+		return nil, err
+	}
+	bindings := make(map[string][]string, len(cfg.Client.UI.Keymap.Bindings))
+	for action, names := range cfg.Client.UI.Keymap.Bindings {
+		bindings[action] = names
+	}
+	keymap, err := keys.New(cfg.Client.UI.Keymap.Preset, bindings)
+	if err != nil {
+		return nil, err
+	}
+
+	dir, err := cache.DefaultDir(cfg.Client.ServerURL)
+	if err != nil {
+		return nil, err
+	}
+	store, err := cache.NewStore(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	stateStore, err := state.Open(cfg.Client.ServerURL)
+	if err != nil {
+		return nil, err
+	}
+	marks, err := state.LoadReadMarks(stateStore)
+	if err != nil {
+		return nil, err
+	}
+	watched, err := state.LoadWatchList(stateStore)
+	if err != nil {
+		return nil, err
+	}
+
 	app := &App{
 		Application: tview.NewApplication(),
 		Config:      cfg,
 		APIClient:   apiClient,
 		Logger:      logger,
+		client:      cache.NewClient(apiClient, store, stateStore, logger),
+		marks:       marks,
+		watched:     watched,
+		keymap:      keymap,
 	}
 
 	if err := app.initUI(); err != nil {
@@ -34,20 +123,411 @@ func NewApp(cfg *config.Config, apiClient *api.Client, logger *zap.Logger) (*App
 }
 
 func (a *App) initUI() error {
-	a.mainFlex = tview.NewFlex().SetDirection(tview.FlexRow)
+	a.categoryList = screens.NewCategoryList(a.client, a.Logger)
+	a.threadList = screens.NewThreadList(a.client, a.marks, a.watched, a.Logger)
+	a.threadDetail = screens.NewThreadDetail(a.client, a.marks, a.keymap, a.Logger)
+	a.watchedList = screens.NewWatchedList(a.watched, a.marks, a.Logger)
+	a.help = widgets.NewHelp(a.keymap)
+
+	a.categoryList.SetSelectedFunc(a.openCategory)
+	a.threadList.SetSelectedFunc(a.openThread)
+	a.watchedList.SetSelectedFunc(a.openThread)
+	a.threadDetail.SetSubmitFunc(a.submitPost)
+	a.threadDetail.SetOpenURLFunc(a.openURL)
+
+	a.categoryList.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event = a.handleGlobalKey(event); event == nil {
+			return nil
+		}
+		return a.keymap.Navigate(event)
+	})
+	a.threadList.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch {
+		case a.keymap.Is(event, keys.MarkRead):
+			a.markCategoryRead()
+			return nil
+		case a.keymap.Is(event, keys.Star):
+			a.toggleWatch(a.threadList.Current())
+			return nil
+		case a.keymap.Is(event, keys.Sort):
+			a.cycleSort()
+			return nil
+		}
+		if event = a.handleGlobalKey(event); event == nil {
+			return nil
+		}
+		if event = a.backTo(pageCategories)(event); event == nil {
+			return nil
+		}
+		return a.keymap.Navigate(event)
+	})
+	a.watchedList.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if a.keymap.Is(event, keys.Star) {
+			a.toggleWatch(a.watchedList.Current())
+			return nil
+		}
+		if event = a.handleGlobalKey(event); event == nil {
+			return nil
+		}
+		if event = a.backTo(pageCategories)(event); event == nil {
+			return nil
+		}
+		return a.keymap.Navigate(event)
+	})
+	a.threadDetail.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch {
+		case a.keymap.Is(event, keys.Compose):
+			a.threadDetail.OpenComposer(a.Application, a.pages, false)
+			return nil
+		case a.keymap.Is(event, keys.Reply):
+			a.threadDetail.OpenComposer(a.Application, a.pages, true)
+			return nil
+		case a.keymap.Is(event, keys.Back):
+			a.threadList.Refresh()
+			a.watchedList.Refresh()
+			return a.backTo(a.threadReturnPage)(event)
+		}
+		return a.handleGlobalKey(event)
+	})
+
+	a.pages = tview.NewPages().
+		AddPage(pageCategories, a.categoryList, true, true).
+		AddPage(pageThreads, a.threadList, true, false).
+		AddPage(pageThread, a.threadDetail, true, false).
+		AddPage(pageWatched, a.watchedList, true, false)
 
-	// Add a placeholder text view
-	textView := tview.NewTextView().
-		SetText("Welcome to Heisei BBS").
-		SetTextAlign(tview.AlignCenter).
-		SetDynamicColors(true)
+	a.statusBar = tview.NewTextView().SetDynamicColors(true)
 
-	a.mainFlex.AddItem(textView, 0, 1, true)
+	a.mainFlex = tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(a.pages, 0, 1, true).
+		AddItem(a.statusBar, 1, 0, false)
 	a.SetRoot(a.mainFlex, true)
+	a.SetBeforeDrawFunc(func(screen tcell.Screen) bool {
+		a.screen = screen
+		return false
+	})
+
+	if err := a.categoryList.LoadCategories(); err != nil {
+		a.setNotice(theme.Error(tview.Escape(err.Error())))
+	}
+	a.updateStatus()
 
 	return nil
 }
 
 func (a *App) Run() error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go a.poll(ctx)
+
 	return a.Application.Run()
 }
+
+// handleGlobalKey handles the actions available on every screen and passes
+// other keys on
+func (a *App) handleGlobalKey(event *tcell.EventKey) *tcell.EventKey {
+	switch {
+	case a.keymap.Is(event, keys.Help):
+		a.help.Show(a.Application, a.pages)
+	case a.keymap.Is(event, keys.Language):
+		a.switchLanguage()
+	case a.keymap.Is(event, keys.Watched):
+		a.openWatched()
+	case a.keymap.Is(event, keys.Quit):
+		a.Stop()
+	default:
+		return event
+	}
+	return nil
+}
+
+// backTo returns an input capture that switches to page on the back action
+func (a *App) backTo(page string) func(event *tcell.EventKey) *tcell.EventKey {
+	return func(event *tcell.EventKey) *tcell.EventKey {
+		if a.keymap.Is(event, keys.Back) {
+			a.setNotice("")
+			a.pages.SwitchToPage(page)
+			return nil
+		}
+		return event
+	}
+}
+
+func (a *App) openCategory(category *models.CategoryDTO) {
+	a.setNotice("")
+	if err := a.threadList.LoadThreads(category); err != nil {
+		a.setNotice(theme.Error(tview.Escape(err.Error())))
+		return
+	}
+	a.pages.SwitchToPage(pageThreads)
+	a.updateStatus()
+}
+
+func (a *App) openThread(thread *models.ThreadDTO) {
+	a.setNotice("")
+	a.threadDetail.ClearError()
+	if err := a.threadDetail.LoadPosts(thread); err != nil {
+		a.setNotice(theme.Error(tview.Escape(err.Error())))
+		return
+	}
+	if _, err := a.watched.Update(thread); err != nil {
+		a.Logger.Warn("Failed to update watch list", zap.Error(err))
+	}
+	a.threadReturnPage, _ = a.pages.GetFrontPage()
+	a.pages.SwitchToPage(pageThread)
+	a.updateStatus()
+}
+
+func (a *App) openWatched() {
+	a.setNotice("")
+	a.watchedList.Refresh()
+	a.pages.SwitchToPage(pageWatched)
+}
+
+// toggleWatch stars or unstars a thread
+func (a *App) toggleWatch(thread *models.ThreadDTO) {
+	if thread == nil {
+		return
+	}
+	watched, err := a.watched.Toggle(thread)
+	if err != nil {
+		a.setNotice(theme.Error(tview.Escape(err.Error())))
+		return
+	}
+	a.threadList.Refresh()
+	a.watchedList.Refresh()
+	if watched {
+		a.setNotice(i18n.T("watched.started", tview.Escape(thread.Title)))
+	} else {
+		a.setNotice(i18n.T("watched.stopped", tview.Escape(thread.Title)))
+	}
+}
+
+// markCategoryRead marks all threads of the listed category as read
+func (a *App) markCategoryRead() {
+	threads := a.threadList.Threads()
+	if err := a.marks.MarkAllRead(threads); err != nil {
+		a.setNotice(theme.Error(tview.Escape(err.Error())))
+		return
+	}
+	a.threadList.Refresh()
+	a.setNotice(i18n.N("threads.marked_read", len(threads)))
+}
+
+// cycleSort lists the threads in the next sort order
+func (a *App) cycleSort() {
+	sort, err := a.threadList.CycleSort()
+	if err != nil {
+		a.setNotice(theme.Error(tview.Escape(err.Error())))
+		return
+	}
+	if sort != "" {
+		a.setNotice(i18n.T("threads.sorted", i18n.T("sort."+sort)))
+	}
+}
+
+func (a *App) submitPost(text string, sage bool) error {
+	thread := a.threadDetail.Thread()
+	if thread == nil {
+		return nil
+	}
+
+	post, err := a.client.CreatePost(context.Background(), models.CreatePostRequest{ThreadID: thread.ID, Content: text, Sage: sage})
+	if errors.Is(err, cache.ErrDraftQueued) {
+		a.threadDetail.Refresh()
+		a.setNotice(theme.Warning(i18n.T("status.draft_saved")))
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	a.threadDetail.AddPost(post)
+	a.updateStatus()
+	return nil
+}
+
+// openURL opens a web link of a post in $BROWSER or the desktop's browser
+func (a *App) openURL(url string) {
+	var cmd *exec.Cmd
+	switch browser := os.Getenv("BROWSER"); {
+	case browser != "":
+		cmd = exec.Command(browser, url)
+	case runtime.GOOS == "darwin":
+		cmd = exec.Command("open", url)
+	case runtime.GOOS == "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	default:
+		cmd = exec.Command("xdg-open", url)
+	}
+	if err := cmd.Start(); err != nil {
+		a.setNotice(theme.Error(tview.Escape(err.Error())))
+		return
+	}
+	// Reap the process without blocking the UI
+	go cmd.Wait()
+	a.setNotice(i18n.T("thread.opening", tview.Escape(url)))
+}
+
+// poll submits queued drafts and checks watched threads every refresh
+// interval until ctx is done
+func (a *App) poll(ctx context.Context) {
+	interval := a.Config.Client.UI.RefreshRate
+	if interval < time.Second {
+		interval = time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		a.submitDrafts(ctx)
+		a.checkWatched(ctx)
+	}
+}
+
+func (a *App) submitDrafts(ctx context.Context) {
+	if a.client.PendingDrafts() == 0 {
+		return
+	}
+	sent, conflicts, err := a.client.SubmitDrafts(ctx)
+	if err != nil && !errors.Is(err, api.ErrUnavailable) && ctx.Err() == nil {
+		a.Logger.Warn("Failed to submit drafts", zap.Error(err))
+	}
+	if len(sent) == 0 && len(conflicts) == 0 {
+		a.QueueUpdateDraw(a.updateStatus)
+		return
+	}
+
+	// The threads that got posts are fetched here rather than on the UI
+	// goroutine, which would freeze while waiting for the server
+	posts := make(map[uint][]models.PostDTO)
+	for _, post := range sent {
+		if _, ok := posts[post.ThreadID]; ok {
+			continue
+		}
+		threadPosts, err := a.client.GetPostsByThread(ctx, post.ThreadID)
+		if err != nil {
+			a.Logger.Warn("Failed to reload posts", zap.Error(err), zap.Uint("threadID", post.ThreadID))
+			continue
+		}
+		posts[post.ThreadID] = threadPosts
+	}
+
+	a.QueueUpdateDraw(func() {
+		if thread := a.threadDetail.Thread(); thread != nil {
+			if threadPosts, ok := posts[thread.ID]; ok {
+				a.threadDetail.SetPosts(threadPosts)
+			} else {
+				// Shows the drafts that were rejected
+				a.threadDetail.Refresh()
+			}
+		}
+		switch {
+		case len(conflicts) > 0:
+			c := conflicts[0]
+			a.setNotice(theme.Error(i18n.N("status.drafts_rejected",
+				len(conflicts), c.Draft.ThreadID, tview.Escape(c.Err.Error()))))
+		default:
+			a.setNotice(theme.Success(i18n.N("status.drafts_sent", len(sent))))
+		}
+	})
+}
+
+// checkWatched fetches the watched threads, announces new replies and stops
+// watching the threads that were deleted
+func (a *App) checkWatched(ctx context.Context) {
+	var replies, deleted []string
+	for _, watched := range a.watched.Threads() {
+		thread, err := a.client.GetThreadByID(ctx, watched.ID)
+		if ctx.Err() != nil || a.client.Offline() {
+			// Cached threads tell nothing new, so wait for the next round
+			break
+		}
+		if errors.Is(err, api.ErrNotFound) {
+			if err := a.watched.Remove(watched.ID); err != nil {
+				a.Logger.Warn("Failed to update watch list", zap.Error(err))
+			}
+			deleted = append(deleted, fmt.Sprintf("%q", watched.Title))
+			continue
+		}
+		if err != nil {
+			a.Logger.Warn("Failed to poll watched thread", zap.Error(err), zap.Uint("threadID", watched.ID))
+			continue
+		}
+
+		added, err := a.watched.Update(thread)
+		if err != nil {
+			a.Logger.Warn("Failed to update watch list", zap.Error(err))
+		}
+		if added > 0 {
+			replies = append(replies, fmt.Sprintf("%q (+%d)", thread.Title, added))
+		}
+	}
+	if len(replies) == 0 && len(deleted) == 0 {
+		return
+	}
+
+	a.QueueUpdateDraw(func() {
+		a.watchedList.Refresh()
+		var notices []string
+		if len(replies) > 0 {
+			notices = append(notices, theme.Success(i18n.T("watched.new_replies", tview.Escape(strings.Join(replies, ", ")))))
+		}
+		if len(deleted) > 0 {
+			notices = append(notices, i18n.T("watched.deleted", tview.Escape(strings.Join(deleted, ", "))))
+		}
+		a.setNotice(strings.Join(notices, "  "))
+		if len(replies) > 0 && a.Config.Client.UI.Bell && a.screen != nil {
+			a.screen.Beep()
+		}
+	})
+}
+
+// switchLanguage cycles through the supported languages and redraws all
+// screens, also asking the server for messages in the new language
+func (a *App) switchLanguage() {
+	languages := i18n.Languages()
+	next := languages[0]
+	for i, lang := range languages {
+		if lang == i18n.Language() {
+			next = languages[(i+1)%len(languages)]
+		}
+	}
+	if err := i18n.SetLanguage(next); err != nil {
+		a.setNotice(theme.Error(tview.Escape(err.Error())))
+		return
+	}
+	a.APIClient.SetLanguage(next)
+
+	a.categoryList.Retranslate()
+	a.threadList.Retranslate()
+	a.threadDetail.Retranslate()
+	a.watchedList.Retranslate()
+	a.setNotice(i18n.T("status.language"))
+}
+
+func (a *App) setNotice(notice string) {
+	a.notice = notice
+	a.updateStatus()
+}
+
+// updateStatus shows the connection state, pending drafts and the current notice
+func (a *App) updateStatus() {
+	status := theme.Success(i18n.T("status.online"))
+	if a.client.Offline() {
+		status = theme.Warning(i18n.T("status.offline"))
+	}
+	if pending := a.client.PendingDrafts(); pending > 0 {
+		status += " | " + i18n.N("status.drafts", pending)
+	}
+	status += " | " + theme.Muted(tview.Escape(i18n.T("status.help", a.keymap.Describe(keys.Help))))
+	if a.notice != "" {
+		status += " | " + a.notice
+	}
+	a.statusBar.SetText(status)
+}
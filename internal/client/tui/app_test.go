@@ -0,0 +1,166 @@
+package tui
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"slices"
+	"testing"
+	"time"
+
+	"heisei/internal/common/models"
+)
+
+func TestMain(m *testing.M) {
+	// Dates are shown in local time
+	time.Local = time.UTC
+	os.Exit(m.Run())
+}
+
+func TestCategoryList(t *testing.T) {
+	h := newHarness(t)
+	h.snapshot("categories")
+}
+
+func TestNavigation(t *testing.T) {
+	h := newHarness(t)
+
+	h.press("j", "Enter")
+	h.snapshot("threads")
+
+	h.press("Enter")
+	h.snapshot("thread")
+
+	h.press("Esc")
+	h.contains("Welcome", "Closed for repairs")
+	h.press("Esc")
+	h.contains("News", "Archive")
+}
+
+func TestPost(t *testing.T) {
+	h := newHarness(t)
+	h.press("j", "Enter", "Enter", "c")
+	h.typeText("Nice to meet you")
+	h.snapshot("composer")
+
+	h.press("Ctrl+S")
+	h.snapshot("posted")
+
+	posts := h.server.postsOf(1)
+	if got := posts[len(posts)-1].Content; got != "Nice to meet you" {
+		t.Errorf("server stored %q, want the typed text", got)
+	}
+}
+
+func TestPostErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		// setup prepares the server before the post is sent
+		setup func(f *fakeServer)
+		// open selects the thread from the thread list
+		open []string
+		want string
+	}{
+		{
+			name: "locked",
+			open: []string{"j", "Enter"},
+			want: "Thread 2 is locked",
+		},
+		{
+			name: "validation",
+			setup: func(f *fakeServer) {
+				f.rejectPosts(http.StatusUnprocessableEntity, &models.ErrorResponse{
+					Code:    models.ErrorCodeValidationFailed,
+					Message: "Validation failed",
+					Details: []models.ErrorDetail{{Field: "content", Message: "content is too long"}},
+				})
+			},
+			open: []string{"Enter"},
+			want: "failed to create post: Validation failed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newHarness(t)
+			if tt.setup != nil {
+				tt.setup(h.server)
+			}
+			h.press("j", "Enter")
+			h.press(tt.open...)
+			h.press("c")
+			h.typeText("Hello?")
+			h.press("Ctrl+S")
+
+			h.contains(tt.want)
+			h.snapshot("post_error_" + tt.name)
+		})
+	}
+}
+
+func TestOffline(t *testing.T) {
+	h := newHarness(t)
+	// Read the thread once so that it is cached
+	h.press("j", "Enter", "Enter", "Esc", "Esc")
+
+	h.server.setDown(true)
+	h.press("Enter")
+	h.contains("Offline", "Welcome")
+
+	h.press("Enter", "c")
+	h.typeText("Written offline")
+	h.press("Ctrl+S")
+	h.snapshot("offline_draft")
+
+	if n := len(h.server.postsOf(1)); n != 3 {
+		t.Errorf("server has %d posts in the thread, want the draft kept on the client", n)
+	}
+
+	// Once the server is back, the poll sends the draft and shows the thread
+	// as the server has it
+	h.server.setDown(false)
+	h.app.submitDrafts(context.Background())
+	h.snapshot("offline_draft_sent")
+	if n := len(h.server.postsOf(1)); n != 4 {
+		t.Errorf("server has %d posts in the thread, want the draft sent", n)
+	}
+}
+
+func TestTitlesAreNotMarkup(t *testing.T) {
+	h := newHarness(t)
+	h.server.mu.Lock()
+	h.server.thread(1).Title = "[red]Welcome[-]"
+	h.server.mu.Unlock()
+
+	h.press("j", "Enter")
+	h.contains("[red]Welcome[-]")
+	h.press("Enter")
+	h.contains("[red]Welcome[-]")
+}
+
+func TestPostNumbersSkipDeletedPosts(t *testing.T) {
+	h := newHarness(t)
+	// The second post was deleted, so the last one keeps number 3
+	h.server.mu.Lock()
+	h.server.posts[1] = slices.Delete(h.server.posts[1], 1, 2)
+	h.server.mu.Unlock()
+
+	h.press("j", "Enter", "Enter", "j", "r")
+	h.contains(">>3")
+}
+
+func TestWatchedThreadDeleted(t *testing.T) {
+	h := newHarness(t)
+	if _, err := h.app.watched.Toggle(h.server.thread(2)); err != nil {
+		t.Fatal(err)
+	}
+	h.server.mu.Lock()
+	h.server.threads = h.server.threads[:1]
+	h.server.mu.Unlock()
+
+	h.app.checkWatched(context.Background())
+	h.contains(`Stopped watching deleted threads: "Closed for repairs"`)
+	if h.app.watched.IsWatched(2) {
+		t.Error("deleted thread is still watched")
+	}
+}
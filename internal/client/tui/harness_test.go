@@ -0,0 +1,373 @@
+package tui
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"heisei/internal/client/api"
+	"heisei/internal/client/config"
+	"heisei/internal/client/tui/theme"
+	"heisei/internal/common/models"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/mattn/go-runewidth"
+	"go.uber.org/zap"
+)
+
+// update rewrites the snapshots with the current screens:
+//
+//	go test ./internal/client/tui -update
+var update = flag.Bool("update", false, "rewrite screen snapshots")
+
+// Size of the simulated terminal
+const (
+	screenWidth  = 80
+	screenHeight = 24
+)
+
+// syncKey is injected after each scripted key; when the event loop reaches
+// it, the keys before it have been handled and drawn
+const syncKey = tcell.KeyF64
+
+// boardTime is the creation time of the first fixture post; later posts
+// follow a minute apart
+var boardTime = time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+
+// fakeServer answers like the API server for an in-memory board with a
+// section holding two categories, and stores new posts
+type fakeServer struct {
+	mu         sync.Mutex
+	categories []models.CategoryDTO
+	threads    []models.ThreadDTO
+	posts      map[uint][]models.PostDTO
+	// down makes every request fail with 503
+	down bool
+	// reject makes new posts fail with this status and error response
+	rejectStatus int
+	reject       *models.ErrorResponse
+}
+
+func newFakeServer() *fakeServer {
+	section := uint(1)
+	last := boardTime.Add(2 * time.Minute)
+	f := &fakeServer{
+		categories: []models.CategoryDTO{
+			{ID: 1, Name: "Boards", Slug: "boards", DefaultSort: models.ThreadSortBump},
+			{ID: 2, ParentID: &section, Name: "News", Slug: "news", Description: "Current events",
+				DefaultSort: models.ThreadSortBump, MaxPosts: 1000, ThreadCount: 2, LastPostAt: &last},
+			{ID: 3, ParentID: &section, Position: 1, Name: "Archive", Slug: "archive",
+				DefaultSort: models.ThreadSortCreated, MaxPosts: 1000, ReadOnly: true},
+		},
+		threads: []models.ThreadDTO{
+			{ID: 1, CategoryID: 2, Title: "Welcome", CreatedAt: boardTime, LastPostAt: last, BumpedAt: last, PostCount: 3},
+			{ID: 2, CategoryID: 2, Title: "Closed for repairs", CreatedAt: boardTime, LastPostAt: boardTime, BumpedAt: boardTime, PostCount: 1, Locked: true},
+		},
+		posts: map[uint][]models.PostDTO{
+			1: {
+				{ID: 1, ThreadID: 1, Number: 1, Content: "Hello and welcome!", CreatedAt: boardTime},
+				{ID: 2, ThreadID: 1, Number: 2, Content: ">>1 Thanks, see https://example.com", CreatedAt: boardTime.Add(time.Minute)},
+				{ID: 3, ThreadID: 1, Number: 3, Content: "Quiet reply", CreatedAt: last, Sage: true},
+			},
+			2: {
+				{ID: 4, ThreadID: 2, Number: 1, Content: "Back soon", CreatedAt: boardTime},
+			},
+		},
+	}
+	return f
+}
+
+func (f *fakeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.down {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, "/api/v1")
+	switch {
+	case r.Method == http.MethodGet && path == "/categories":
+		writeJSON(w, http.StatusOK, f.categories)
+	case r.Method == http.MethodGet && path == "/threads":
+		categoryID, _ := strconv.Atoi(r.URL.Query().Get("category_id"))
+		threads := []models.ThreadDTO{}
+		for _, thread := range f.threads {
+			if thread.CategoryID == uint(categoryID) {
+				threads = append(threads, thread)
+			}
+		}
+		writeJSON(w, http.StatusOK, threads)
+	case r.Method == http.MethodGet && strings.HasPrefix(path, "/threads/"):
+		id, _ := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(path, "/threads/"), "/posts"))
+		thread := f.thread(uint(id))
+		switch {
+		case thread == nil:
+			writeJSON(w, http.StatusNotFound, &models.ErrorResponse{Code: models.ErrorCodeNotFound, Message: "thread not found"})
+		case strings.HasSuffix(path, "/posts"):
+			writeJSON(w, http.StatusOK, f.posts[thread.ID])
+		default:
+			writeJSON(w, http.StatusOK, thread)
+		}
+	case r.Method == http.MethodPost && path == "/posts":
+		var req models.CreatePostRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, &models.ErrorResponse{Code: models.ErrorCodeBadRequest, Message: err.Error()})
+			return
+		}
+		thread := f.thread(req.ThreadID)
+		switch {
+		case f.reject != nil:
+			writeJSON(w, f.rejectStatus, f.reject)
+		case thread == nil:
+			writeJSON(w, http.StatusNotFound, &models.ErrorResponse{Code: models.ErrorCodeNotFound, Message: "thread not found"})
+		case thread.Locked:
+			writeJSON(w, http.StatusConflict, &models.ErrorResponse{
+				Code:    models.ErrorCodeConflict,
+				Message: fmt.Sprintf("Thread %d is locked", thread.ID),
+			})
+		default:
+			post := models.PostDTO{
+				ID:        uint(100 + len(f.posts[thread.ID])),
+				ThreadID:  thread.ID,
+				Number:    len(f.posts[thread.ID]) + 1,
+				Content:   req.Content,
+				Sage:      req.Sage,
+				CreatedAt: boardTime.Add(time.Hour),
+			}
+			f.posts[thread.ID] = append(f.posts[thread.ID], post)
+			thread.PostCount++
+			writeJSON(w, http.StatusCreated, post)
+		}
+	default:
+		writeJSON(w, http.StatusNotFound, &models.ErrorResponse{Code: models.ErrorCodeNotFound, Message: "Route not found"})
+	}
+}
+
+func (f *fakeServer) thread(id uint) *models.ThreadDTO {
+	for i := range f.threads {
+		if f.threads[i].ID == id {
+			return &f.threads[i]
+		}
+	}
+	return nil
+}
+
+// setDown makes the server unreachable or brings it back
+func (f *fakeServer) setDown(down bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.down = down
+}
+
+// rejectPosts makes new posts fail with the given error response
+func (f *fakeServer) rejectPosts(status int, resp *models.ErrorResponse) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.rejectStatus = status
+	f.reject = resp
+}
+
+// postsOf returns the posts stored for a thread
+func (f *fakeServer) postsOf(threadID uint) []models.PostDTO {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]models.PostDTO(nil), f.posts[threadID]...)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// harness runs the App on a simulated screen against a fake server
+type harness struct {
+	t      *testing.T
+	app    *App
+	server *fakeServer
+	screen tcell.SimulationScreen
+	synced chan struct{}
+}
+
+func newHarness(t *testing.T) *harness {
+	t.Helper()
+	// Keep the cache and the read marks of the test apart
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	t.Setenv("XDG_CACHE_HOME", filepath.Join(dir, "cache"))
+	t.Setenv("XDG_STATE_HOME", filepath.Join(dir, "state"))
+
+	fake := newFakeServer()
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+
+	cfg := &config.Config{Client: config.ClientConfig{
+		ServerURL: srv.URL,
+		UI: config.UIConfig{
+			Language:       "en",
+			RefreshRate:    time.Hour,
+			MaxThreadsShow: 50,
+			Theme:          theme.DefaultName,
+			Keymap:         config.KeymapConfig{Preset: "vi"},
+		},
+		Connection: config.ConnectionConfig{Timeout: 5 * time.Second},
+	}}
+	apiClient := api.NewClient(srv.URL, api.WithHTTPClient(srv.Client()), api.WithRetries(0, 0), api.WithLanguage("en"))
+	app, err := NewApp(cfg, apiClient, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h := &harness{
+		t:      t,
+		app:    app,
+		server: fake,
+		screen: tcell.NewSimulationScreen("UTF-8"),
+		synced: make(chan struct{}),
+	}
+	app.SetScreen(h.screen)
+	h.screen.SetSize(screenWidth, screenHeight)
+	app.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == syncKey {
+			h.synced <- struct{}{}
+			return nil
+		}
+		return event
+	})
+
+	// The event loop runs without the polling of App.Run, so that nothing
+	// changes the screen between the scripted keys
+	done := make(chan error, 1)
+	go func() { done <- app.Application.Run() }()
+	t.Cleanup(func() {
+		app.Stop()
+		if err := <-done; err != nil {
+			t.Errorf("app failed: %v", err)
+		}
+	})
+	h.sync()
+	return h
+}
+
+// sync waits until the event loop has handled and drawn every injected key
+func (h *harness) sync() {
+	h.t.Helper()
+	h.screen.InjectKey(syncKey, 0, tcell.ModNone)
+	select {
+	case <-h.synced:
+	case <-time.After(5 * time.Second):
+		h.t.Fatal("the event loop did not handle the keys in time")
+	}
+}
+
+// press injects keys by name as written in the keymap, e.g. "j", "Enter" or
+// "Ctrl+S", handling each before the next
+func (h *harness) press(names ...string) {
+	h.t.Helper()
+	for _, name := range names {
+		key, r, mod := parseKeyName(h.t, name)
+		h.screen.InjectKey(key, r, mod)
+		h.sync()
+	}
+}
+
+// typeText types text into the focused primitive
+func (h *harness) typeText(text string) {
+	h.t.Helper()
+	for _, r := range text {
+		if r == '\n' {
+			h.screen.InjectKey(tcell.KeyEnter, 0, tcell.ModNone)
+		} else {
+			h.screen.InjectKey(tcell.KeyRune, r, tcell.ModNone)
+		}
+		h.sync()
+	}
+}
+
+func parseKeyName(t *testing.T, name string) (tcell.Key, rune, tcell.ModMask) {
+	t.Helper()
+	switch name {
+	case "Enter":
+		return tcell.KeyEnter, 0, tcell.ModNone
+	case "Esc":
+		return tcell.KeyEscape, 0, tcell.ModNone
+	case "Tab":
+		return tcell.KeyTab, 0, tcell.ModNone
+	case "Ctrl+S":
+		return tcell.KeyCtrlS, 0, tcell.ModCtrl
+	case "Ctrl+T":
+		return tcell.KeyCtrlT, 0, tcell.ModCtrl
+	}
+	if r := []rune(name); len(r) == 1 {
+		return tcell.KeyRune, r[0], tcell.ModNone
+	}
+	t.Fatalf("unknown key %q", name)
+	return 0, 0, 0
+}
+
+// text returns the screen as lines of text without trailing spaces
+func (h *harness) text() string {
+	var lines []string
+	h.app.QueueUpdate(func() {
+		cells, width, height := h.screen.GetContents()
+		for y := 0; y < height; y++ {
+			var line strings.Builder
+			for x := 0; x < width; x++ {
+				cell := cells[y*width+x]
+				if len(cell.Runes) == 0 {
+					line.WriteByte(' ')
+					continue
+				}
+				line.WriteString(string(cell.Runes))
+				// Wide characters cover the next cell
+				x += runewidth.RuneWidth(cell.Runes[0]) - 1
+			}
+			lines = append(lines, strings.TrimRight(line.String(), " "))
+		}
+	})
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// contains fails the test unless the screen shows all of texts
+func (h *harness) contains(texts ...string) {
+	h.t.Helper()
+	screen := h.text()
+	for _, text := range texts {
+		if !strings.Contains(screen, text) {
+			h.t.Errorf("screen does not show %q:\n%s", text, screen)
+		}
+	}
+}
+
+// snapshot compares the screen with testdata/<name>.txt
+func (h *harness) snapshot(name string) {
+	h.t.Helper()
+	got := h.text()
+	path := filepath.Join("testdata", name+".txt")
+	if *update {
+		if err := os.MkdirAll("testdata", 0o755); err != nil {
+			h.t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			h.t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		h.t.Fatalf("%v; run with -update to create it", err)
+	}
+	if got != string(want) {
+		h.t.Errorf("screen differs from %s; run with -update if the change is intended\ngot:\n%s\nwant:\n%s", path, got, want)
+	}
+}
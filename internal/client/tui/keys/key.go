@@ -0,0 +1,155 @@
+package keys
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// Key is a single key press, optionally with modifiers
+type Key struct {
+	Key  tcell.Key
+	Rune rune
+	Mod  tcell.ModMask
+}
+
+// keyNames are the names of the special keys accepted in the configuration
+var keyNames = map[string]tcell.Key{
+	"Esc":       tcell.KeyEscape,
+	"Enter":     tcell.KeyEnter,
+	"Tab":       tcell.KeyTab,
+	"Backtab":   tcell.KeyBacktab,
+	"Backspace": tcell.KeyBackspace2,
+	"Delete":    tcell.KeyDelete,
+	"Insert":    tcell.KeyInsert,
+	"Up":        tcell.KeyUp,
+	"Down":      tcell.KeyDown,
+	"Left":      tcell.KeyLeft,
+	"Right":     tcell.KeyRight,
+	"Home":      tcell.KeyHome,
+	"End":       tcell.KeyEnd,
+	"PgUp":      tcell.KeyPgUp,
+	"PgDn":      tcell.KeyPgDn,
+	"F1":        tcell.KeyF1,
+	"F2":        tcell.KeyF2,
+	"F3":        tcell.KeyF3,
+	"F4":        tcell.KeyF4,
+	"F5":        tcell.KeyF5,
+	"F6":        tcell.KeyF6,
+	"F7":        tcell.KeyF7,
+	"F8":        tcell.KeyF8,
+	"F9":        tcell.KeyF9,
+	"F10":       tcell.KeyF10,
+	"F11":       tcell.KeyF11,
+	"F12":       tcell.KeyF12,
+}
+
+// Parse reads a key such as "q", "G", "Ctrl+N", "Alt+l", "Ctrl+Enter",
+// "Esc" or "Space"
+func Parse(s string) (Key, error) {
+	var mod tcell.ModMask
+	name := s
+	for {
+		if rest, ok := cutPrefixFold(name, "Ctrl+"); ok && rest != "" {
+			mod |= tcell.ModCtrl
+			name = rest
+		} else if rest, ok := cutPrefixFold(name, "Alt+"); ok && rest != "" {
+			mod |= tcell.ModAlt
+			name = rest
+		} else {
+			break
+		}
+	}
+
+	if strings.EqualFold(name, "Space") {
+		name = " "
+	}
+	if utf8.RuneCountInString(name) == 1 {
+		r, _ := utf8.DecodeRuneInString(name)
+		if mod&tcell.ModCtrl == 0 {
+			return Key{Key: tcell.KeyRune, Rune: r, Mod: mod}, nil
+		}
+		// Ctrl with a letter is a control character of its own
+		if r >= 'a' && r <= 'z' {
+			r -= 'a' - 'A'
+		}
+		if r >= 'A' && r <= 'Z' {
+			return Key{Key: tcell.KeyCtrlA + tcell.Key(r-'A'), Mod: mod &^ tcell.ModCtrl}, nil
+		}
+		return Key{}, fmt.Errorf("invalid key %q: Ctrl only combines with letters", s)
+	}
+
+	for n, key := range keyNames {
+		if strings.EqualFold(n, name) {
+			return Key{Key: key, Mod: mod}, nil
+		}
+	}
+	return Key{}, fmt.Errorf("invalid key %q", s)
+}
+
+// Matches reports whether event is a press of k
+func (k Key) Matches(event *tcell.EventKey) bool {
+	if event.Key() != k.Key {
+		return false
+	}
+	mods := event.Modifiers()
+	switch {
+	case k.Key == tcell.KeyRune:
+		// Shift is part of the rune
+		return event.Rune() == k.Rune && mods&tcell.ModAlt == k.Mod&tcell.ModAlt
+	case isControl(k.Key):
+		// Terminals report control characters with or without ModCtrl
+		return mods&tcell.ModAlt == k.Mod&tcell.ModAlt
+	default:
+		return mods&(tcell.ModCtrl|tcell.ModAlt) == k.Mod&(tcell.ModCtrl|tcell.ModAlt)
+	}
+}
+
+// String returns k in the form accepted by Parse
+func (k Key) String() string {
+	var prefix string
+	if k.Mod&tcell.ModCtrl != 0 {
+		prefix += "Ctrl+"
+	}
+	if k.Mod&tcell.ModAlt != 0 {
+		prefix += "Alt+"
+	}
+
+	switch {
+	case k.Key == tcell.KeyRune && k.Rune == ' ':
+		return prefix + "Space"
+	case k.Key == tcell.KeyRune:
+		return prefix + string(k.Rune)
+	case isControl(k.Key):
+		return "Ctrl+" + prefix + string(rune('A'+k.Key-tcell.KeyCtrlA))
+	}
+	for name, key := range keyNames {
+		if key == k.Key {
+			return prefix + name
+		}
+	}
+	return prefix + tcell.KeyNames[k.Key]
+}
+
+// isControl reports whether key is Ctrl with a letter. Enter and Tab share
+// their codes with Ctrl+M and Ctrl+I but are named keys.
+func isControl(key tcell.Key) bool {
+	if key < tcell.KeyCtrlA || key > tcell.KeyCtrlZ {
+		return false
+	}
+	for _, named := range keyNames {
+		if named == key {
+			return false
+		}
+	}
+	return true
+}
+
+func cutPrefixFold(s, prefix string) (string, bool) {
+	if len(s) < len(prefix) || !strings.EqualFold(s[:len(prefix)], prefix) {
+		return s, false
+	}
+	return s[len(prefix):], true
+}
@@ -0,0 +1,251 @@
+package keys
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// Action is a command of the TUI that can be bound to keys
+type Action string
+
+// General actions, available on every screen
+const (
+	Help     Action = "help"
+	Language Action = "language"
+	Watched  Action = "watched"
+	Back     Action = "back"
+	Quit     Action = "quit"
+)
+
+// Actions of the category, thread and watched lists
+const (
+	Up       Action = "up"
+	Down     Action = "down"
+	Top      Action = "top"
+	Bottom   Action = "bottom"
+	Open     Action = "open"
+	Star     Action = "star"
+	MarkRead Action = "mark_read"
+	Sort     Action = "sort"
+)
+
+// Actions of the thread view
+const (
+	Compose  Action = "compose"
+	Reply    Action = "reply"
+	NextLink Action = "next_link"
+	PrevLink Action = "prev_link"
+	Follow   Action = "follow"
+)
+
+// Actions of the post composer
+const (
+	Send    Action = "send"
+	Quote   Action = "quote"
+	Editor  Action = "editor"
+	Preview Action = "preview"
+	Sage    Action = "sage"
+	Close   Action = "close"
+)
+
+// Group is a set of actions listed together in the help
+type Group struct {
+	Name    string
+	Actions []Action
+}
+
+// Groups lists all actions in the order of the help
+var Groups = []Group{
+	{Name: "general", Actions: []Action{Help, Language, Watched, Back, Quit}},
+	{Name: "lists", Actions: []Action{Up, Down, Top, Bottom, Open, Star, MarkRead, Sort}},
+	{Name: "thread", Actions: []Action{Up, Down, Top, Bottom, NextLink, PrevLink, Follow, Compose, Reply}},
+	{Name: "composer", Actions: []Action{Send, Quote, Editor, Preview, Sage, Close}},
+}
+
+// scopes are the sets of actions active at the same time, whose keys
+// must not overlap
+var scopes = [][]Action{
+	append(append([]Action{}, Groups[0].Actions...), Groups[1].Actions...),
+	append(append([]Action{}, Groups[0].Actions...), Groups[2].Actions...),
+	Groups[3].Actions,
+}
+
+// composerBindings are shared by all presets, as the text area already
+// uses most control keys for editing
+var composerBindings = map[Action][]string{
+	Send:    {"Ctrl+Enter", "Ctrl+J", "Ctrl+S"},
+	Quote:   {"Ctrl+R"},
+	Editor:  {"Ctrl+O"},
+	Preview: {"Ctrl+P"},
+	Sage:    {"Ctrl+T"},
+	Close:   {"Esc"},
+}
+
+var presets = map[string]map[Action][]string{
+	"vi": {
+		Help:     {"?", "F1"},
+		Language: {"L"},
+		Watched:  {"w"},
+		Back:     {"Esc", "h"},
+		Quit:     {"q"},
+		Up:       {"k", "Up"},
+		Down:     {"j", "Down"},
+		Top:      {"g", "Home"},
+		Bottom:   {"G", "End"},
+		Open:     {"Enter", "l"},
+		Star:     {"s"},
+		MarkRead: {"m"},
+		Sort:     {"o"},
+		Compose:  {"c"},
+		Reply:    {"r"},
+		NextLink: {"Tab", "n"},
+		PrevLink: {"Backtab", "N"},
+		Follow:   {"Enter", "o"},
+	},
+	"emacs": {
+		Help:     {"F1", "?"},
+		Language: {"Alt+l"},
+		Watched:  {"Alt+w"},
+		Back:     {"Esc", "Ctrl+G"},
+		Quit:     {"Ctrl+Q"},
+		Up:       {"Ctrl+P", "Up"},
+		Down:     {"Ctrl+N", "Down"},
+		Top:      {"Alt+<", "Home"},
+		Bottom:   {"Alt+>", "End"},
+		Open:     {"Enter", "Ctrl+F"},
+		Star:     {"Alt+s"},
+		MarkRead: {"Alt+m"},
+		Sort:     {"Alt+o"},
+		Compose:  {"Alt+c"},
+		Reply:    {"Alt+r"},
+		NextLink: {"Tab", "Alt+n"},
+		PrevLink: {"Backtab", "Alt+p"},
+		Follow:   {"Enter", "Alt+o"},
+	},
+}
+
+// DefaultPreset is used when no preset is configured
+const DefaultPreset = "vi"
+
+// Keymap maps actions to the keys that trigger them
+type Keymap struct {
+	bindings map[Action][]Key
+}
+
+// Presets returns the names of the built-in keymaps
+func Presets() []string {
+	names := make([]string, 0, len(presets))
+	for name := range presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// New creates the keymap of a preset with the keys of some actions replaced
+// by overrides, e.g. {"quit": {"q", "Ctrl+Q"}}
+func New(preset string, overrides map[string][]string) (*Keymap, error) {
+	if preset == "" {
+		preset = DefaultPreset
+	}
+	bindings, ok := presets[preset]
+	if !ok {
+		return nil, fmt.Errorf("unknown keymap preset %q, expected one of %s", preset, strings.Join(Presets(), ", "))
+	}
+
+	k := &Keymap{bindings: make(map[Action][]Key)}
+	for _, source := range []map[Action][]string{bindings, composerBindings} {
+		for action, names := range source {
+			if err := k.bind(action, names); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for name, names := range overrides {
+		action := Action(name)
+		if _, ok := k.bindings[action]; !ok {
+			return nil, fmt.Errorf("unknown action %q in key bindings", name)
+		}
+		if err := k.bind(action, names); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := k.checkConflicts(); err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+func (k *Keymap) bind(action Action, names []string) error {
+	keys := make([]Key, 0, len(names))
+	for _, name := range names {
+		key, err := Parse(name)
+		if err != nil {
+			return fmt.Errorf("action %q: %w", action, err)
+		}
+		keys = append(keys, key)
+	}
+	k.bindings[action] = keys
+	return nil
+}
+
+// checkConflicts rejects keys bound to two actions that are active at once
+func (k *Keymap) checkConflicts() error {
+	for _, scope := range scopes {
+		seen := make(map[Key]Action)
+		for _, action := range scope {
+			for _, key := range k.bindings[action] {
+				if other, ok := seen[key]; ok && other != action {
+					return fmt.Errorf("key %s is bound to both %q and %q", key, other, action)
+				}
+				seen[key] = action
+			}
+		}
+	}
+	return nil
+}
+
+// Is reports whether event triggers action
+func (k *Keymap) Is(event *tcell.EventKey, action Action) bool {
+	for _, key := range k.bindings[action] {
+		if key.Matches(event) {
+			return true
+		}
+	}
+	return false
+}
+
+// Keys returns the keys bound to action
+func (k *Keymap) Keys(action Action) []Key {
+	return k.bindings[action]
+}
+
+// Describe returns the keys bound to action for display, e.g. "k/Up"
+func (k *Keymap) Describe(action Action) string {
+	names := make([]string, len(k.bindings[action]))
+	for i, key := range k.bindings[action] {
+		names[i] = key.String()
+	}
+	return strings.Join(names, "/")
+}
+
+// Navigate translates the list actions into the keys tview lists understand
+func (k *Keymap) Navigate(event *tcell.EventKey) *tcell.EventKey {
+	switch {
+	case k.Is(event, Up):
+		return tcell.NewEventKey(tcell.KeyUp, 0, tcell.ModNone)
+	case k.Is(event, Down):
+		return tcell.NewEventKey(tcell.KeyDown, 0, tcell.ModNone)
+	case k.Is(event, Top):
+		return tcell.NewEventKey(tcell.KeyHome, 0, tcell.ModNone)
+	case k.Is(event, Bottom):
+		return tcell.NewEventKey(tcell.KeyEnd, 0, tcell.ModNone)
+	case k.Is(event, Open):
+		return tcell.NewEventKey(tcell.KeyEnter, 0, tcell.ModNone)
+	}
+	return event
+}
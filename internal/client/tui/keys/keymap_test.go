@@ -0,0 +1,117 @@
+package keys
+
+import (
+	"strings"
+	"testing"
+
+	"heisei/internal/client/i18n"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in    string
+		event *tcell.EventKey
+		want  string
+	}{
+		{"q", tcell.NewEventKey(tcell.KeyRune, 'q', tcell.ModNone), "q"},
+		{"G", tcell.NewEventKey(tcell.KeyRune, 'G', tcell.ModShift), "G"},
+		{"space", tcell.NewEventKey(tcell.KeyRune, ' ', tcell.ModNone), "Space"},
+		{"ctrl+n", tcell.NewEventKey(tcell.KeyCtrlN, 0, tcell.ModCtrl), "Ctrl+N"},
+		{"Alt+l", tcell.NewEventKey(tcell.KeyRune, 'l', tcell.ModAlt), "Alt+l"},
+		{"Alt+<", tcell.NewEventKey(tcell.KeyRune, '<', tcell.ModAlt), "Alt+<"},
+		{"Esc", tcell.NewEventKey(tcell.KeyEscape, 0, tcell.ModNone), "Esc"},
+		{"enter", tcell.NewEventKey(tcell.KeyEnter, 0, tcell.ModNone), "Enter"},
+		{"Ctrl+Enter", tcell.NewEventKey(tcell.KeyEnter, 0, tcell.ModCtrl), "Ctrl+Enter"},
+		{"F1", tcell.NewEventKey(tcell.KeyF1, 0, tcell.ModNone), "F1"},
+	}
+	for _, tt := range tests {
+		key, err := Parse(tt.in)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.in, err)
+			continue
+		}
+		if !key.Matches(tt.event) {
+			t.Errorf("%q does not match %s", tt.in, tt.event.Name())
+		}
+		if got := key.String(); got != tt.want {
+			t.Errorf("Parse(%q).String() = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+
+	for _, in := range []string{"", "Ctrl+1", "Hyper+x", "Foo"} {
+		if _, err := Parse(in); err == nil {
+			t.Errorf("Parse(%q) succeeded", in)
+		}
+	}
+}
+
+func TestMatchesModifiers(t *testing.T) {
+	enter, _ := Parse("Enter")
+	if enter.Matches(tcell.NewEventKey(tcell.KeyEnter, 0, tcell.ModCtrl)) {
+		t.Error("Enter matches Ctrl+Enter")
+	}
+	l, _ := Parse("l")
+	if l.Matches(tcell.NewEventKey(tcell.KeyRune, 'l', tcell.ModAlt)) {
+		t.Error("l matches Alt+l")
+	}
+	ctrlJ, _ := Parse("Ctrl+J")
+	if !ctrlJ.Matches(tcell.NewEventKey(tcell.KeyCtrlJ, 0, tcell.ModNone)) {
+		t.Error("Ctrl+J does not match a line feed without ModCtrl")
+	}
+}
+
+func TestPresets(t *testing.T) {
+	for _, preset := range Presets() {
+		keymap, err := New(preset, nil)
+		if err != nil {
+			t.Fatalf("preset %s: %v", preset, err)
+		}
+		for _, group := range Groups {
+			for _, action := range group.Actions {
+				if len(keymap.Keys(action)) == 0 {
+					t.Errorf("preset %s: %s has no keys", preset, action)
+				}
+				if key := "keys." + string(action); i18n.T(key) == key {
+					t.Errorf("%s has no description", action)
+				}
+			}
+		}
+	}
+
+	if _, err := New("nano", nil); err == nil {
+		t.Error("unknown preset accepted")
+	}
+}
+
+func TestOverrides(t *testing.T) {
+	keymap, err := New("vi", map[string][]string{"quit": {"Q", "Ctrl+Q"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keymap.Is(tcell.NewEventKey(tcell.KeyRune, 'q', tcell.ModNone), Quit) {
+		t.Error("q still quits")
+	}
+	if !keymap.Is(tcell.NewEventKey(tcell.KeyCtrlQ, 0, tcell.ModCtrl), Quit) {
+		t.Error("Ctrl+Q does not quit")
+	}
+	if got := keymap.Describe(Quit); got != "Q/Ctrl+Q" {
+		t.Errorf("Describe = %q", got)
+	}
+	if got := keymap.Navigate(tcell.NewEventKey(tcell.KeyRune, 'j', tcell.ModNone)); got.Key() != tcell.KeyDown {
+		t.Errorf("j navigates to %s", got.Name())
+	}
+
+	if _, err := New("vi", map[string][]string{"jump": {"J"}}); err == nil {
+		t.Error("unknown action accepted")
+	}
+	_, err = New("vi", map[string][]string{"star": {"j"}})
+	if err == nil || !strings.Contains(err.Error(), "bound to both") {
+		t.Errorf("conflict not detected: %v", err)
+	}
+	// The composer is separate from the lists, so a key may both close it and go back
+	if _, err := New("vi", map[string][]string{"close": {"h"}}); err != nil {
+		t.Errorf("keys of different scopes conflict: %v", err)
+	}
+}
@@ -0,0 +1,147 @@
+package markup
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"heisei/internal/client/tui/theme"
+
+	"github.com/rivo/tview"
+)
+
+// LinkKind tells what a link points to
+type LinkKind int
+
+const (
+	// LinkURL is a web address
+	LinkURL LinkKind = iota
+	// LinkAnchor is a >>N reference to another post of the thread
+	LinkAnchor
+)
+
+// Link is a part of a post that can be focused and followed
+type Link struct {
+	// Region is the tview region of the link text
+	Region string
+	Kind   LinkKind
+	// URL is the address of a LinkURL
+	URL string
+	// Post is the number of the post a LinkAnchor refers to
+	Post int
+}
+
+var (
+	// linkPattern matches URLs and >>N anchors, also written with full-width
+	// characters as Japanese IMEs produce them
+	linkPattern = regexp.MustCompile(`https?://[^\s<>"\[\]{}|\\^` + "`" + `]+|(?:>>|＞＞)([0-9０-９]{1,6})`)
+	// anchorLine matches lines starting with an anchor, which are replies
+	// rather than quotes
+	anchorLine = regexp.MustCompile(`^(?:>>|＞＞)[0-9０-９]`)
+)
+
+// trailingPunctuation is cut off URLs, as it usually ends the sentence
+const trailingPunctuation = ".,:;!?'()。、）"
+
+const codeFence = "```"
+
+// Render turns post content into tview text inside region. Tags in the
+// content are escaped, so user text never changes colors. Quote lines, URLs,
+// >>N anchors and fenced code blocks are styled, and every link gets a region
+// of its own, named region-1, region-2 and so on. Spacing is kept as it is,
+// so that ASCII art lines up.
+func Render(content, region string) (string, []Link) {
+	r := renderer{region: region}
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+
+	inCode := false
+	for i, line := range strings.Split(content, "\n") {
+		if i > 0 {
+			r.b.WriteString("\n")
+		}
+		switch {
+		case strings.HasPrefix(strings.TrimLeft(line, " "), codeFence):
+			inCode = !inCode
+			r.styled(theme.Current().MutedTag, line)
+		case inCode:
+			r.styled(theme.Current().CodeTag, line)
+		case isQuote(line):
+			r.linkify(theme.Current().QuoteTag, line)
+		default:
+			r.linkify("", line)
+		}
+	}
+	return r.b.String(), r.links
+}
+
+type renderer struct {
+	b      strings.Builder
+	region string
+	links  []Link
+}
+
+// styled writes line escaped in the given style
+func (r *renderer) styled(tag, line string) {
+	if line == "" {
+		return
+	}
+	r.b.WriteString(tag + tview.Escape(line) + theme.Reset)
+}
+
+// linkify writes line in the given style with its links in regions
+func (r *renderer) linkify(tag, line string) {
+	last := 0
+	for _, m := range linkPattern.FindAllStringSubmatchIndex(line, -1) {
+		start, end := m[0], m[1]
+		link := Link{Kind: LinkAnchor}
+		if m[2] >= 0 {
+			link.Post = parseNumber(line[m[2]:m[3]])
+		} else {
+			link.Kind = LinkURL
+			link.URL = trimURL(line[start:end])
+			end = start + len(link.URL)
+		}
+
+		r.styled(tag, line[last:start])
+		link.Region = fmt.Sprintf("%s-%d", r.region, len(r.links)+1)
+		r.links = append(r.links, link)
+		fmt.Fprintf(&r.b, `["%s"]%s%s%s["%s"]`, link.Region, theme.Current().LinkTag, tview.Escape(line[start:end]), theme.Reset, r.region)
+		last = end
+	}
+	r.styled(tag, line[last:])
+}
+
+// trimURL cuts off punctuation ending the sentence around a URL, keeping
+// closing parentheses that belong to the URL
+func trimURL(url string) string {
+	for url != "" {
+		r, size := utf8.DecodeLastRuneInString(url)
+		if !strings.ContainsRune(trailingPunctuation, r) {
+			break
+		}
+		if r == ')' && strings.Count(url, "(") >= strings.Count(url, ")") {
+			break
+		}
+		url = url[:len(url)-size]
+	}
+	return url
+}
+
+// isQuote reports whether line quotes another text, starting with >
+func isQuote(line string) bool {
+	return (strings.HasPrefix(line, ">") || strings.HasPrefix(line, "＞")) && !anchorLine.MatchString(line)
+}
+
+// parseNumber reads a number that may be written with full-width digits
+func parseNumber(s string) int {
+	s = strings.Map(func(r rune) rune {
+		if r >= '０' && r <= '９' {
+			return '0' + r - '０'
+		}
+		return r
+	}, s)
+	n, _ := strconv.Atoi(s)
+	return n
+}
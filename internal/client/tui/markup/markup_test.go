@@ -0,0 +1,66 @@
+package markup
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/rivo/tview"
+)
+
+// visible returns the text a TextView shows for the rendered content
+func visible(text string) string {
+	tv := tview.NewTextView().SetDynamicColors(true).SetRegions(true)
+	tv.SetText(text)
+	return tv.GetText(true)
+}
+
+func TestRenderKeepsText(t *testing.T) {
+	contents := []string{
+		"[red]not red[-] and [\"region\"] and [::b]",
+		">quoted [yellow]\nplain",
+		"see https://example.com/a_(b). and >>12, [https://x.test/]",
+		"```go\nfmt.Println(\"[red]\")\n```",
+		"　 ∧＿∧\n　（　´∀｀）\n　（　　　　）\n\t|  |  |",
+		"trailing [",
+	}
+	for _, content := range contents {
+		text, _ := Render(content, "post-1")
+		if got := visible(text); got != content {
+			t.Errorf("Render(%q) shows %q", content, got)
+		}
+	}
+}
+
+func TestRenderLinks(t *testing.T) {
+	text, links := Render(">>3 see https://example.com/wiki/Go_(lang), or ＞＞１２.\n```\nhttps://not.linked\n```", "post-7")
+	want := []Link{
+		{Region: "post-7-1", Kind: LinkAnchor, Post: 3},
+		{Region: "post-7-2", Kind: LinkURL, URL: "https://example.com/wiki/Go_(lang)"},
+		{Region: "post-7-3", Kind: LinkAnchor, Post: 12},
+	}
+	if !reflect.DeepEqual(links, want) {
+		t.Errorf("links = %+v, want %+v", links, want)
+	}
+	// Text after a link belongs to the post again, so that the whole post
+	// can be highlighted
+	if !strings.Contains(text, `["post-7-1"]`) || !strings.Contains(text, `["post-7"]`) {
+		t.Errorf("missing regions in %q", text)
+	}
+}
+
+func TestIsQuote(t *testing.T) {
+	tests := map[string]bool{
+		">quote":          true,
+		"＞引用":             true,
+		">>5 reply":       false,
+		"＞＞５":             false,
+		" >indented":      false,
+		">>not an anchor": true,
+	}
+	for line, want := range tests {
+		if got := isQuote(line); got != want {
+			t.Errorf("isQuote(%q) = %v", line, got)
+		}
+	}
+}
@@ -1,7 +1,10 @@
 package screens
 
 import (
-	"heisei/internal/client/api"
+	"context"
+	"heisei/internal/client/cache"
+	"heisei/internal/client/i18n"
+	"heisei/internal/client/tui/theme"
 	"heisei/internal/common/models"
 
 	"github.com/gdamore/tcell/v2"
@@ -9,48 +12,163 @@ import (
 	"go.uber.org/zap"
 )
 
+// maxDescriptionWidth is the number of terminal cells after which category
+// descriptions are cut off
+const maxDescriptionWidth = 40
+
+// CategoryList shows the boards as a tree, grouped under collapsible sections
 type CategoryList struct {
-	*tview.List
-	api    *api.Client
-	logger *zap.Logger
+	*tview.TreeView
+	api        *cache.Client
+	logger     *zap.Logger
+	root       *tview.TreeNode
+	categories []models.CategoryDTO
+	// collapsed holds the IDs of the collapsed sections across reloads
+	collapsed map[uint]bool
+	selected  func(*models.CategoryDTO)
 }
 
-func NewCategoryList(api *api.Client, logger *zap.Logger) *CategoryList {
+func NewCategoryList(client *cache.Client, logger *zap.Logger) *CategoryList {
 	cl := &CategoryList{
-		List:   tview.NewList().ShowSecondaryText(false),
-		api:    api,
-		logger: logger,
+		TreeView:  tview.NewTreeView(),
+		api:       client,
+		logger:    logger,
+		root:      tview.NewTreeNode(""),
+		collapsed: make(map[uint]bool),
 	}
-	cl.SetBorder(true).SetTitle("Categories")
+	cl.SetRoot(cl.root).SetTopLevel(1)
+	cl.TreeView.SetSelectedFunc(cl.selectNode)
+	cl.SetBorder(true)
+	cl.Retranslate()
 	return cl
 }
 
+// Retranslate updates all texts after a language change
+func (cl *CategoryList) Retranslate() {
+	cl.SetTitle(i18n.T("categories.title"))
+	cl.root.Walk(func(node, parent *tview.TreeNode) bool {
+		if category, ok := node.GetReference().(*models.CategoryDTO); ok {
+			node.SetText(cl.nodeText(node, category))
+		}
+		return true
+	})
+}
+
 func (cl *CategoryList) LoadCategories() error {
-	categories, err := cl.api.GetCategories()
+	categories, err := cl.api.GetCategories(context.Background())
 	if err != nil {
 		cl.logger.Error("Failed to load categories", zap.Error(err))
 		return err
 	}
 
-	cl.Clear()
+	var current uint
+	if category := cl.Current(); category != nil {
+		current = category.ID
+	}
+
+	cl.categories = categories
+	byID := make(map[uint]bool, len(categories))
 	for _, category := range categories {
-		cl.AddItem(category.Name, "", 0, func() {
-			// TODO: Implement category selection
-		})
+		byID[category.ID] = true
+	}
+	// Categories come ordered by position, so children keep that order
+	children := make(map[uint][]*models.CategoryDTO)
+	var top []*models.CategoryDTO
+	for i := range cl.categories {
+		category := &cl.categories[i]
+		if category.ParentID != nil && byID[*category.ParentID] {
+			children[*category.ParentID] = append(children[*category.ParentID], category)
+		} else {
+			top = append(top, category)
+		}
 	}
 
+	cl.root.ClearChildren()
+	var currentNode *tview.TreeNode
+	add := func(parent *tview.TreeNode, category *models.CategoryDTO) *tview.TreeNode {
+		node := tview.NewTreeNode("").SetReference(category)
+		parent.AddChild(node)
+		if category.ID == current || currentNode == nil {
+			currentNode = node
+		}
+		return node
+	}
+	for _, category := range top {
+		node := add(cl.root, category)
+		for _, child := range children[category.ID] {
+			add(node, child)
+		}
+		node.SetExpanded(!cl.collapsed[category.ID])
+		node.SetText(cl.nodeText(node, category))
+		for _, child := range node.GetChildren() {
+			child.SetText(cl.nodeText(child, child.GetReference().(*models.CategoryDTO)))
+		}
+	}
+	cl.SetCurrentNode(currentNode)
+
 	return nil
 }
 
-func (cl *CategoryList) SetSelectedFunc(fn func(*models.CategoryDTO)) {
-	cl.List.SetSelectedFunc(func(index int, name string, secondaryText string, shortcut rune) {
-		categories, _ := cl.api.GetCategories()
-		if index < len(categories) {
-			fn(&categories[index])
+// Current returns the selected category, or nil if there are none
+func (cl *CategoryList) Current() *models.CategoryDTO {
+	node := cl.GetCurrentNode()
+	if node == nil {
+		return nil
+	}
+	category, _ := node.GetReference().(*models.CategoryDTO)
+	return category
+}
+
+// selectNode expands or collapses sections and opens boards
+func (cl *CategoryList) selectNode(node *tview.TreeNode) {
+	category, ok := node.GetReference().(*models.CategoryDTO)
+	if !ok {
+		return
+	}
+	if len(node.GetChildren()) > 0 {
+		node.SetExpanded(!node.IsExpanded())
+		cl.collapsed[category.ID] = !node.IsExpanded()
+		node.SetText(cl.nodeText(node, category))
+		return
+	}
+	if cl.selected != nil {
+		cl.selected(category)
+	}
+}
+
+// nodeText shows a section with its number of boards, and a board with its
+// number of threads and latest activity
+func (cl *CategoryList) nodeText(node *tview.TreeNode, category *models.CategoryDTO) string {
+	name := tview.Escape(category.Name)
+	var info string
+	if boards := len(node.GetChildren()); boards > 0 {
+		marker := "▾ "
+		if !node.IsExpanded() {
+			marker = "▸ "
 		}
-	})
+		name = marker + theme.Accent(name)
+		info = i18n.N("categories.boards", boards)
+	} else {
+		info = i18n.N("categories.threads", int(category.ThreadCount))
+		if category.LastPostAt != nil {
+			info += " · " + i18n.T("categories.last_post", i18n.FormatDateTime(*category.LastPostAt))
+		}
+	}
+	if category.ReadOnly {
+		info += " · " + i18n.T("categories.read_only")
+	}
+
+	text := name + "  " + theme.Muted(info)
+	if category.Description != "" {
+		text += "  " + tview.Escape(i18n.Truncate(category.Description, maxDescriptionWidth))
+	}
+	return text
+}
+
+func (cl *CategoryList) SetSelectedFunc(fn func(*models.CategoryDTO)) {
+	cl.selected = fn
 }
 
 func (cl *CategoryList) SetInputCapture(capture func(event *tcell.EventKey) *tcell.EventKey) {
-	cl.List.SetInputCapture(capture)
+	cl.TreeView.SetInputCapture(capture)
 }
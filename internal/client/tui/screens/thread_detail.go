@@ -1,9 +1,21 @@
 package screens
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"heisei/internal/client/api"
+	"heisei/internal/client/cache"
+	"heisei/internal/client/i18n"
+	"heisei/internal/client/state"
+	"heisei/internal/client/tui/keys"
+	"heisei/internal/client/tui/markup"
+	"heisei/internal/client/tui/theme"
+	"heisei/internal/client/tui/widgets"
 	"heisei/internal/common/models"
+	"io"
+	"strings"
+	"time"
 
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
@@ -12,65 +24,310 @@ import (
 
 type ThreadDetail struct {
 	*tview.Flex
-	api           *api.Client
+	api           *cache.Client
+	marks         *state.ReadMarks
+	keymap        *keys.Keymap
 	logger        *zap.Logger
 	postsList     *tview.TextView
-	inputField    *tview.InputField
+	errorView     *tview.TextView
+	composer      *widgets.Composer
 	currentThread *models.ThreadDTO
+	posts         []models.PostDTO
+	// firstUnread is the index of the first post that was new when the
+	// thread was opened, or -1
+	firstUnread int
+	// selected is the index of the highlighted post, or -1
+	selected int
+	// links are the links of each post
+	links [][]markup.Link
+	// link is the index of the focused link of the selected post, or -1
+	link int
+	// openURL opens web links
+	openURL func(url string)
 }
 
-func NewThreadDetail(api *api.Client, logger *zap.Logger) *ThreadDetail {
+func NewThreadDetail(client *cache.Client, marks *state.ReadMarks, keymap *keys.Keymap, logger *zap.Logger) *ThreadDetail {
 	td := &ThreadDetail{
-		Flex:   tview.NewFlex().SetDirection(tview.FlexRow),
-		api:    api,
-		logger: logger,
+		Flex:        tview.NewFlex().SetDirection(tview.FlexRow),
+		api:         client,
+		marks:       marks,
+		keymap:      keymap,
+		logger:      logger,
+		firstUnread: -1,
+		selected:    -1,
+		link:        -1,
 	}
 
 	td.postsList = tview.NewTextView().
 		SetDynamicColors(true).
 		SetRegions(true).
 		SetScrollable(true)
+	td.postsList.SetInputCapture(td.handleKey)
 
-	td.inputField = tview.NewInputField().
-		SetLabel("New post: ").
-		SetFieldWidth(0)
+	td.errorView = tview.NewTextView().
+		SetDynamicColors(true).
+		SetWrap(true)
+
+	td.composer = widgets.NewComposer(keymap).
+		SetPreviewFunc(td.preview).
+		SetAnchorFunc(td.anchor)
 
-	td.Flex.AddItem(td.postsList, 0, 1, false).
-		AddItem(td.inputField, 1, 0, true)
+	td.Flex.AddItem(td.postsList, 0, 1, true).
+		AddItem(td.errorView, 0, 0, false)
 
 	td.SetBorder(true)
 
 	return td
 }
 
+// Thread returns the thread currently shown, or nil
+func (td *ThreadDetail) Thread() *models.ThreadDTO {
+	return td.currentThread
+}
+
 func (td *ThreadDetail) LoadPosts(thread *models.ThreadDTO) error {
+	if td.currentThread == nil || td.currentThread.ID != thread.ID {
+		// Unsent text belongs to the previous thread
+		td.composer.SetText("")
+	}
 	td.currentThread = thread
-	td.SetTitle(fmt.Sprintf("Thread: %s", thread.Title))
+	td.SetTitle(i18n.T("thread.title", tview.Escape(thread.Title)))
 
-	posts, err := td.api.GetPostsByThread(thread.ID)
+	posts, err := td.api.GetPostsByThread(context.Background(), thread.ID)
 	if err != nil {
 		td.logger.Error("Failed to load posts", zap.Error(err), zap.Uint("threadID", thread.ID))
 		return err
 	}
+	td.SetPosts(posts)
+	return nil
+}
+
+// SetPosts shows posts fetched for the current thread, e.g. by a background
+// poll, and marks them read
+func (td *ThreadDetail) SetPosts(posts []models.PostDTO) {
+	td.posts = posts
+	td.firstUnread = td.marks.FirstUnread(td.currentThread, posts)
+	td.selected = td.firstUnread
+	if td.selected < 0 {
+		td.selected = len(posts) - 1
+	}
+	td.link = -1
+	td.render()
+	td.markRead()
+}
 
+// markRead records that the current thread has been read to the end
+func (td *ThreadDetail) markRead() {
+	var lastPost *models.PostDTO
+	if len(td.posts) > 0 {
+		lastPost = &td.posts[len(td.posts)-1]
+	}
+	if err := td.marks.MarkRead(td.currentThread, lastPost); err != nil {
+		td.logger.Warn("Failed to save read mark", zap.Error(err), zap.Uint("threadID", td.currentThread.ID))
+	}
+}
+
+// Refresh redraws the posts and drafts of the current thread
+func (td *ThreadDetail) Refresh() {
+	if td.currentThread != nil {
+		td.render()
+	}
+}
+
+// Retranslate updates all texts after a language change
+func (td *ThreadDetail) Retranslate() {
+	if td.currentThread != nil {
+		td.SetTitle(i18n.T("thread.title", tview.Escape(td.currentThread.Title)))
+	}
+	td.composer.Retranslate()
+	td.Refresh()
+}
+
+func (td *ThreadDetail) render() {
 	td.postsList.Clear()
-	for _, post := range posts {
-		fmt.Fprintf(td.postsList, "[yellow]%s[white]\n%s\n\n", post.CreatedAt.Format("2006-01-02 15:04:05"), post.Content)
+	td.links = make([][]markup.Link, len(td.posts))
+	for i, post := range td.posts {
+		if i == td.firstUnread {
+			fmt.Fprintf(td.postsList, "%s\n\n", theme.Error(i18n.T("thread.new_posts")))
+		}
+		td.links[i] = writePost(td.postsList, &post)
 	}
 
-	return nil
+	drafts, err := td.api.DraftsForThread(td.currentThread.ID)
+	if err != nil {
+		td.logger.Warn("Failed to read drafts", zap.Error(err))
+	}
+	for i, draft := range drafts {
+		status := theme.Muted(i18n.T("thread.draft_pending"))
+		if draft.Conflict != "" {
+			status = theme.Error(i18n.T("thread.draft_rejected", tview.Escape(draft.Conflict)))
+		}
+		content, _ := markup.Render(draft.Content, fmt.Sprintf("draft-%d", i))
+		fmt.Fprintf(td.postsList, "%s\n%s\n\n", status, content)
+	}
+
+	td.highlightSelected()
 }
 
-func (td *ThreadDetail) SetSubmitFunc(fn func(string)) {
-	td.inputField.SetDoneFunc(func(key tcell.Key) {
-		if key == tcell.KeyEnter {
-			text := td.inputField.GetText()
-			if text != "" {
-				fn(text)
-				td.inputField.SetText("")
+// highlightSelected highlights the focused link, or else the whole selected post
+func (td *ThreadDetail) highlightSelected() {
+	if td.selected >= 0 && td.selected < len(td.posts) {
+		links := td.links[td.selected]
+		if td.link >= 0 && td.link < len(links) {
+			td.postsList.Highlight(links[td.link].Region)
+		} else {
+			regions := []string{postRegion(&td.posts[td.selected])}
+			for _, link := range links {
+				regions = append(regions, link.Region)
 			}
+			td.postsList.Highlight(regions...)
+		}
+		td.postsList.ScrollToHighlight()
+	} else {
+		td.postsList.Highlight()
+		td.postsList.ScrollToEnd()
+	}
+}
+
+func (td *ThreadDetail) handleKey(event *tcell.EventKey) *tcell.EventKey {
+	switch {
+	case td.keymap.Is(event, keys.Down):
+		td.selectPost(td.selected + 1)
+	case td.keymap.Is(event, keys.Up):
+		td.selectPost(td.selected - 1)
+	case td.keymap.Is(event, keys.Top):
+		td.selectPost(0)
+	case td.keymap.Is(event, keys.Bottom):
+		td.selectPost(len(td.posts) - 1)
+	case td.keymap.Is(event, keys.NextLink):
+		td.focusLink(1)
+	case td.keymap.Is(event, keys.PrevLink):
+		td.focusLink(-1)
+	case td.keymap.Is(event, keys.Follow):
+		td.followLink()
+	default:
+		return event
+	}
+	return nil
+}
+
+func (td *ThreadDetail) selectPost(index int) {
+	if index < 0 || index >= len(td.posts) {
+		return
+	}
+	td.selected = index
+	td.link = -1
+	td.highlightSelected()
+}
+
+// focusLink moves the focus by delta through the links of the selected post,
+// wrapping around to the post itself
+func (td *ThreadDetail) focusLink(delta int) {
+	if td.selected < 0 || td.selected >= len(td.posts) {
+		return
+	}
+	links := td.links[td.selected]
+	if len(links) == 0 {
+		return
+	}
+	// -1 is the post itself, so there are len(links)+1 positions
+	n := len(links) + 1
+	td.link = (td.link+1+delta+n)%n - 1
+	td.highlightSelected()
+}
+
+// followLink jumps to the post referenced by the focused anchor or opens
+// the focused URL
+func (td *ThreadDetail) followLink() {
+	if td.selected < 0 || td.selected >= len(td.posts) || td.link < 0 {
+		return
+	}
+	link := td.links[td.selected][td.link]
+	switch link.Kind {
+	case markup.LinkAnchor:
+		td.selectPost(td.postIndex(link.Post))
+	case markup.LinkURL:
+		if td.openURL != nil {
+			td.openURL(link.URL)
+		}
+	}
+}
+
+// postIndex returns the index of the post with the given number, or -1 if it
+// was deleted or does not exist
+func (td *ThreadDetail) postIndex(number int) int {
+	for i := range td.posts {
+		if td.posts[i].Number == number {
+			return i
 		}
-	})
+	}
+	return -1
+}
+
+// SetOpenURLFunc sets the function opening web links
+func (td *ThreadDetail) SetOpenURLFunc(fn func(url string)) {
+	td.openURL = fn
+}
+
+// anchor returns a >>N reference to the selected post
+func (td *ThreadDetail) anchor() string {
+	if td.selected < 0 || td.selected >= len(td.posts) {
+		return ""
+	}
+	return fmt.Sprintf(">>%d\n", td.posts[td.selected].Number)
+}
+
+// preview renders text the way it will appear in the thread
+func (td *ThreadDetail) preview(text string) string {
+	var b strings.Builder
+	// The number is a guess, as deleted posts are not listed
+	number := 1
+	if len(td.posts) > 0 {
+		number = td.posts[len(td.posts)-1].Number + 1
+	}
+	writePost(&b, &models.PostDTO{Number: number, Content: text, CreatedAt: time.Now()})
+	return b.String()
+}
+
+// OpenComposer shows the post composer over the thread. If reply is set,
+// a reference to the selected post is inserted first.
+func (td *ThreadDetail) OpenComposer(app *tview.Application, pages *tview.Pages, reply bool) {
+	if td.currentThread == nil {
+		return
+	}
+	if reply {
+		td.composer.Insert(td.anchor())
+	}
+	td.composer.Show(app, pages)
+}
+
+// SetSubmitFunc sets the function called with the text of a new post.
+// The composer stays open and shows the error if fn fails.
+func (td *ThreadDetail) SetSubmitFunc(fn func(text string, sage bool) error) {
+	td.composer.SetSubmitFunc(fn)
+}
+
+// ShowError displays err below the posts.
+// Validation errors from the server are listed field by field.
+func (td *ThreadDetail) ShowError(err error) {
+	var lines []string
+	var apiErr *api.APIError
+	if errors.As(err, &apiErr) && len(apiErr.Details) > 0 {
+		for _, detail := range apiErr.Details {
+			lines = append(lines, fmt.Sprintf("%s: %s", detail.Field, detail.Message))
+		}
+	} else {
+		lines = []string{err.Error()}
+	}
+
+	td.errorView.SetText(theme.Error(tview.Escape(strings.Join(lines, "\n"))))
+	td.Flex.ResizeItem(td.errorView, len(lines), 0)
+}
+
+// ClearError hides the inline error
+func (td *ThreadDetail) ClearError() {
+	td.errorView.Clear()
+	td.Flex.ResizeItem(td.errorView, 0, 0)
 }
 
 func (td *ThreadDetail) SetInputCapture(capture func(event *tcell.EventKey) *tcell.EventKey) {
@@ -78,6 +335,27 @@ func (td *ThreadDetail) SetInputCapture(capture func(event *tcell.EventKey) *tce
 }
 
 func (td *ThreadDetail) AddPost(post *models.PostDTO) {
-	fmt.Fprintf(td.postsList, "[yellow]%s[white]\n%s\n\n", post.CreatedAt.Format("2006-01-02 15:04:05"), post.Content)
-	td.postsList.ScrollToEnd()
+	td.posts = append(td.posts, *post)
+	td.currentThread.PostCount++
+	td.firstUnread = -1
+	td.selected = len(td.posts) - 1
+	td.link = -1
+	td.render()
+	td.markRead()
+}
+
+func postRegion(post *models.PostDTO) string {
+	return fmt.Sprintf("post-%d", post.ID)
+}
+
+// writePost prints a post with its number and returns its links
+func writePost(w io.Writer, post *models.PostDTO) []markup.Link {
+	region := postRegion(post)
+	content, links := markup.Render(post.Content, region)
+	header := theme.Accent(fmt.Sprintf("%d %s", post.Number, i18n.FormatDateTime(post.CreatedAt)))
+	if post.Sage {
+		header += " " + theme.Muted("sage")
+	}
+	fmt.Fprintf(w, "[\"%s\"]%s\n%s[\"\"]\n\n", region, header, content)
+	return links
 }
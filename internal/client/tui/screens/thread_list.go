@@ -1,53 +1,139 @@
 package screens
 
 import (
-	"fmt"
-	"heisei/internal/client/api"
+	"context"
+	"heisei/internal/client/cache"
+	"heisei/internal/client/i18n"
+	"heisei/internal/client/state"
+	"heisei/internal/client/tui/theme"
 	"heisei/internal/common/models"
+	"slices"
 
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
 	"go.uber.org/zap"
 )
 
+// maxTitleWidth is the number of terminal cells after which titles are cut off
+const maxTitleWidth = 60
+
 type ThreadList struct {
 	*tview.List
-	api    *api.Client
-	logger *zap.Logger
+	api     *cache.Client
+	marks   *state.ReadMarks
+	watched *state.WatchList
+	logger  *zap.Logger
+	threads []models.ThreadDTO
+	// category is the listed category, nil before the first load
+	category *models.CategoryDTO
+	// sort is the order of the listed threads
+	sort string
 }
 
-func NewThreadList(api *api.Client, logger *zap.Logger) *ThreadList {
+func NewThreadList(client *cache.Client, marks *state.ReadMarks, watched *state.WatchList, logger *zap.Logger) *ThreadList {
 	tl := &ThreadList{
-		List:   tview.NewList().ShowSecondaryText(true),
-		api:    api,
-		logger: logger,
+		List:    tview.NewList().ShowSecondaryText(true),
+		api:     client,
+		marks:   marks,
+		watched: watched,
+		logger:  logger,
 	}
-	tl.SetBorder(true).SetTitle("Threads")
+	tl.SetBorder(true)
+	tl.Retranslate()
 	return tl
 }
 
-func (tl *ThreadList) LoadThreads(categoryID uint) error {
-	threads, err := tl.api.GetThreadsByCategory(categoryID)
+// Retranslate updates all texts after a language change
+func (tl *ThreadList) Retranslate() {
+	if tl.category != nil {
+		tl.SetTitle(i18n.T("threads.title_category", tview.Escape(tl.category.Name), i18n.T("sort."+tl.sort)))
+	} else {
+		tl.SetTitle(i18n.T("threads.title"))
+	}
+	tl.Refresh()
+}
+
+// LoadThreads lists the threads of category in its default order
+func (tl *ThreadList) LoadThreads(category *models.CategoryDTO) error {
+	return tl.load(category, category.DefaultSort)
+}
+
+// CycleSort lists the threads again in the next sort order and returns it
+func (tl *ThreadList) CycleSort() (string, error) {
+	if tl.category == nil {
+		return "", nil
+	}
+	next := models.ThreadSorts[(slices.Index(models.ThreadSorts, tl.sort)+1)%len(models.ThreadSorts)]
+	if err := tl.load(tl.category, next); err != nil {
+		return "", err
+	}
+	return next, nil
+}
+
+func (tl *ThreadList) load(category *models.CategoryDTO, sort string) error {
+	if !slices.Contains(models.ThreadSorts, sort) {
+		sort = models.ThreadSortBump
+	}
+	threads, err := tl.api.GetThreadsByCategory(context.Background(), category.ID, sort)
 	if err != nil {
-		tl.logger.Error("Failed to load threads", zap.Error(err), zap.Uint("categoryID", categoryID))
+		tl.logger.Error("Failed to load threads", zap.Error(err), zap.Uint("categoryID", category.ID))
 		return err
 	}
 
+	c := *category
+	tl.category = &c
+	tl.sort = sort
+	tl.threads = threads
 	tl.Clear()
 	for _, thread := range threads {
-		tl.AddItem(thread.Title, fmt.Sprintf("Posts: %d", thread.PostCount), 0, func() {
-			// TODO: Implement thread selection
-		})
+		tl.AddItem(tl.mainText(&thread), tl.secondaryText(&thread), 0, nil)
 	}
+	tl.Retranslate()
 
 	return nil
 }
 
+// Threads returns the threads currently listed
+func (tl *ThreadList) Threads() []models.ThreadDTO {
+	return tl.threads
+}
+
+// Current returns the selected thread, or nil if the list is empty
+func (tl *ThreadList) Current() *models.ThreadDTO {
+	index := tl.GetCurrentItem()
+	if index < 0 || index >= len(tl.threads) {
+		return nil
+	}
+	return &tl.threads[index]
+}
+
+// Refresh updates unread counts and stars without reloading the threads
+func (tl *ThreadList) Refresh() {
+	for i := range tl.threads {
+		tl.SetItemText(i, tl.mainText(&tl.threads[i]), tl.secondaryText(&tl.threads[i]))
+	}
+}
+
+func (tl *ThreadList) mainText(thread *models.ThreadDTO) string {
+	title := tview.Escape(i18n.Truncate(thread.Title, maxTitleWidth))
+	if tl.watched.IsWatched(thread.ID) {
+		return "★ " + title
+	}
+	return title
+}
+
+func (tl *ThreadList) secondaryText(thread *models.ThreadDTO) string {
+	text := i18n.T("threads.posts", thread.PostCount)
+	if unread := tl.marks.Unread(thread); unread > 0 {
+		text += "  " + theme.Accent(i18n.N("threads.new", unread))
+	}
+	return text
+}
+
 func (tl *ThreadList) SetSelectedFunc(fn func(*models.ThreadDTO)) {
 	tl.List.SetSelectedFunc(func(index int, name string, secondaryText string, shortcut rune) {
-		threads, _ := tl.api.GetThreadsByCategory(0) // TODO: Store current category ID
-		if index < len(threads) {
-			fn(&threads[index])
+		if index < len(tl.threads) {
+			fn(&tl.threads[index])
 		}
 	})
 }
@@ -0,0 +1,77 @@
+package screens
+
+import (
+	"heisei/internal/client/i18n"
+	"heisei/internal/client/state"
+	"heisei/internal/client/tui/theme"
+	"heisei/internal/common/models"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"go.uber.org/zap"
+)
+
+// WatchedList lists the starred threads, most recently active first
+type WatchedList struct {
+	*tview.List
+	watched *state.WatchList
+	marks   *state.ReadMarks
+	logger  *zap.Logger
+	threads []models.ThreadDTO
+}
+
+func NewWatchedList(watched *state.WatchList, marks *state.ReadMarks, logger *zap.Logger) *WatchedList {
+	wl := &WatchedList{
+		List:    tview.NewList().ShowSecondaryText(true),
+		watched: watched,
+		marks:   marks,
+		logger:  logger,
+	}
+	wl.SetBorder(true)
+	wl.Retranslate()
+	return wl
+}
+
+// Retranslate updates all texts after a language change
+func (wl *WatchedList) Retranslate() {
+	wl.SetTitle(i18n.T("watched.title"))
+	wl.Refresh()
+}
+
+// Refresh rebuilds the list from the watch list, keeping the selection
+func (wl *WatchedList) Refresh() {
+	current := wl.GetCurrentItem()
+	wl.threads = wl.watched.Threads()
+	wl.Clear()
+	for _, thread := range wl.threads {
+		text := i18n.T("threads.posts", thread.PostCount) + "  " + i18n.T("watched.last_post", i18n.FormatDateTime(thread.LastPostAt))
+		if unread := wl.marks.Unread(&thread); unread > 0 {
+			text += "  " + theme.Accent(i18n.N("threads.new", unread))
+		}
+		wl.AddItem(tview.Escape(i18n.Truncate(thread.Title, maxTitleWidth)), text, 0, nil)
+	}
+	if current < len(wl.threads) {
+		wl.SetCurrentItem(current)
+	}
+}
+
+// Current returns the selected thread, or nil if the list is empty
+func (wl *WatchedList) Current() *models.ThreadDTO {
+	index := wl.GetCurrentItem()
+	if index < 0 || index >= len(wl.threads) {
+		return nil
+	}
+	return &wl.threads[index]
+}
+
+func (wl *WatchedList) SetSelectedFunc(fn func(*models.ThreadDTO)) {
+	wl.List.SetSelectedFunc(func(index int, name string, secondaryText string, shortcut rune) {
+		if index < len(wl.threads) {
+			fn(&wl.threads[index])
+		}
+	})
+}
+
+func (wl *WatchedList) SetInputCapture(capture func(event *tcell.EventKey) *tcell.EventKey) {
+	wl.List.SetInputCapture(capture)
+}
@@ -0,0 +1,151 @@
+package theme
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// Reset ends the style started by one of the tags of a theme
+const Reset = "[-:-:-]"
+
+// Theme is a color scheme of the TUI
+type Theme struct {
+	// Styles are the colors of borders, titles, lists and text views
+	Styles tview.Theme
+	// The tags below style parts of texts, e.g. "[yellow]" or "[::b]"
+	AccentTag  string
+	SuccessTag string
+	WarningTag string
+	ErrorTag   string
+	MutedTag   string
+	// Tags of the markup in posts
+	QuoteTag string
+	LinkTag  string
+	CodeTag  string
+}
+
+var themes = map[string]Theme{
+	"default": {
+		Styles:     tview.Styles,
+		AccentTag:  "[yellow]",
+		SuccessTag: "[green]",
+		WarningTag: "[yellow]",
+		ErrorTag:   "[red]",
+		MutedTag:   "[gray]",
+		QuoteTag:   "[green]",
+		LinkTag:    "[aqua::u]",
+		CodeTag:    "[silver:#262626]",
+	},
+	// monochrome is for high contrast and terminals without colors, so it
+	// relies on text attributes alone
+	"monochrome": {
+		Styles: tview.Theme{
+			PrimitiveBackgroundColor:    tcell.ColorBlack,
+			ContrastBackgroundColor:     tcell.ColorWhite,
+			MoreContrastBackgroundColor: tcell.ColorWhite,
+			BorderColor:                 tcell.ColorWhite,
+			TitleColor:                  tcell.ColorWhite,
+			GraphicsColor:               tcell.ColorWhite,
+			PrimaryTextColor:            tcell.ColorWhite,
+			SecondaryTextColor:          tcell.ColorWhite,
+			TertiaryTextColor:           tcell.ColorWhite,
+			InverseTextColor:            tcell.ColorBlack,
+			ContrastSecondaryTextColor:  tcell.ColorBlack,
+		},
+		AccentTag:  "[::b]",
+		SuccessTag: "[::b]",
+		WarningTag: "[::bu]",
+		ErrorTag:   "[::r]",
+		MutedTag:   "[::d]",
+		QuoteTag:   "[::i]",
+		LinkTag:    "[::u]",
+		CodeTag:    "[::b]",
+	},
+	"green-phosphor": {
+		Styles: tview.Theme{
+			PrimitiveBackgroundColor:    tcell.ColorBlack,
+			ContrastBackgroundColor:     tcell.NewHexColor(0x0a3d0a),
+			MoreContrastBackgroundColor: tcell.NewHexColor(0x145214),
+			BorderColor:                 tcell.NewHexColor(0x1f9e1f),
+			TitleColor:                  tcell.NewHexColor(0x66ff66),
+			GraphicsColor:               tcell.NewHexColor(0x1f9e1f),
+			PrimaryTextColor:            tcell.NewHexColor(0x33ff33),
+			SecondaryTextColor:          tcell.NewHexColor(0x99ff99),
+			TertiaryTextColor:           tcell.NewHexColor(0x22bb22),
+			InverseTextColor:            tcell.ColorBlack,
+			ContrastSecondaryTextColor:  tcell.NewHexColor(0x66ff66),
+		},
+		AccentTag:  "[#99ff99::b]",
+		SuccessTag: "[#66ff66]",
+		WarningTag: "[#ccff66::b]",
+		ErrorTag:   "[#33ff33::r]",
+		MutedTag:   "[#1f9e1f]",
+		QuoteTag:   "[#22bb22]",
+		LinkTag:    "[#99ff99::u]",
+		CodeTag:    "[#33ff33:#0a3d0a]",
+	},
+}
+
+// DefaultName is the theme used when none is configured
+const DefaultName = "default"
+
+var current = themes[DefaultName]
+
+// Names returns the names of the built-in themes
+func Names() []string {
+	names := make([]string, 0, len(themes))
+	for name := range themes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Apply makes the named theme current. Primitives take their colors when they
+// are created, so Apply must be called before the screens are built.
+func Apply(name string) error {
+	if name == "" {
+		name = DefaultName
+	}
+	t, ok := themes[name]
+	if !ok {
+		return fmt.Errorf("unknown theme %q, expected one of %s", name, strings.Join(Names(), ", "))
+	}
+	current = t
+	tview.Styles = t.Styles
+	return nil
+}
+
+// Current returns the current theme
+func Current() Theme {
+	return current
+}
+
+// Accent highlights text such as post headers and unread counts
+func Accent(text string) string {
+	return current.AccentTag + text + Reset
+}
+
+// Success styles text reporting a completed action
+func Success(text string) string {
+	return current.SuccessTag + text + Reset
+}
+
+// Warning styles text reporting a degraded state
+func Warning(text string) string {
+	return current.WarningTag + text + Reset
+}
+
+// Error styles text reporting a failure
+func Error(text string) string {
+	return current.ErrorTag + text + Reset
+}
+
+// Muted styles secondary text such as hints
+func Muted(text string) string {
+	return current.MutedTag + text + Reset
+}
@@ -0,0 +1,279 @@
+package widgets
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"unicode/utf8"
+
+	"heisei/internal/client/i18n"
+	"heisei/internal/client/tui/keys"
+	"heisei/internal/client/tui/theme"
+	"heisei/pkg/utils"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+const composerPage = "composer"
+
+// Composer is a modal multi-line editor for posts
+type Composer struct {
+	*tview.Flex
+	textArea *tview.TextArea
+	preview  *tview.TextView
+	status   *tview.TextView
+	keymap   *keys.Keymap
+
+	app   *tview.Application
+	pages *tview.Pages
+
+	submitFunc  func(string, bool) error
+	previewFunc func(string) string
+	anchorFunc  func() string
+	previewing  bool
+	// sage posts without bumping the thread; it stays set for later posts
+	sage    bool
+	message string
+}
+
+func NewComposer(keymap *keys.Keymap) *Composer {
+	c := &Composer{
+		keymap:   keymap,
+		Flex:     tview.NewFlex().SetDirection(tview.FlexRow),
+		textArea: tview.NewTextArea(),
+		preview:  tview.NewTextView().SetDynamicColors(true).SetWrap(true),
+		status:   tview.NewTextView().SetDynamicColors(true),
+	}
+	c.preview.SetBorder(true)
+
+	c.Flex.AddItem(c.textArea, 0, 1, true).
+		AddItem(c.preview, 0, 0, false).
+		AddItem(c.status, 1, 0, false)
+	c.SetBorder(true)
+
+	c.textArea.SetChangedFunc(c.update)
+	c.textArea.SetInputCapture(c.handleKey)
+	c.Retranslate()
+	return c
+}
+
+// Retranslate updates all texts after a language change
+func (c *Composer) Retranslate() {
+	c.textArea.SetPlaceholder(i18n.T("composer.placeholder"))
+	c.preview.SetTitle(i18n.T("composer.preview"))
+	c.SetTitle(i18n.T("composer.title"))
+	c.update()
+}
+
+// SetSubmitFunc sets the function called with the text and the sage option
+// on Ctrl+Enter. The composer closes and clears if it succeeds and shows the
+// error otherwise.
+func (c *Composer) SetSubmitFunc(fn func(text string, sage bool) error) *Composer {
+	c.submitFunc = fn
+	return c
+}
+
+// SetPreviewFunc sets the function rendering the text for the preview pane
+func (c *Composer) SetPreviewFunc(fn func(text string) string) *Composer {
+	c.previewFunc = fn
+	return c
+}
+
+// SetAnchorFunc sets the function returning the text inserted by Ctrl+R,
+// usually a >>N reference to the selected post
+func (c *Composer) SetAnchorFunc(fn func() string) *Composer {
+	c.anchorFunc = fn
+	return c
+}
+
+// SetText replaces the text and moves the cursor to its end
+func (c *Composer) SetText(text string) *Composer {
+	c.textArea.SetText(text, true)
+	return c
+}
+
+// GetText returns the current text
+func (c *Composer) GetText() string {
+	return c.textArea.GetText()
+}
+
+// Insert inserts text at the cursor
+func (c *Composer) Insert(text string) {
+	_, start, end := c.textArea.GetSelection()
+	c.textArea.Replace(start, end, text)
+}
+
+func (c *Composer) Show(app *tview.Application, pages *tview.Pages) {
+	c.app = app
+	c.pages = pages
+	c.message = ""
+	c.update()
+	pages.AddPage(composerPage, centered(c, 3, 4), true, true)
+	app.SetFocus(c.textArea)
+}
+
+func (c *Composer) Hide() {
+	if c.pages != nil {
+		c.pages.RemovePage(composerPage)
+	}
+}
+
+func (c *Composer) handleKey(event *tcell.EventKey) *tcell.EventKey {
+	switch {
+	case c.keymap.Is(event, keys.Send):
+		c.submit()
+	case c.keymap.Is(event, keys.Quote):
+		if c.anchorFunc != nil {
+			c.Insert(c.anchorFunc())
+		}
+	case c.keymap.Is(event, keys.Editor):
+		c.edit()
+	case c.keymap.Is(event, keys.Preview):
+		c.togglePreview()
+	case c.keymap.Is(event, keys.Sage):
+		c.sage = !c.sage
+		c.update()
+	case c.keymap.Is(event, keys.Close):
+		c.Hide()
+	default:
+		return event
+	}
+	return nil
+}
+
+func (c *Composer) submit() {
+	text := c.textArea.GetText()
+	if strings.TrimSpace(text) == "" {
+		c.setMessage(theme.Error(i18n.T("composer.empty")))
+		return
+	}
+	if !utils.ValidatePostContent(text) {
+		c.setMessage(theme.Error(i18n.N("composer.too_long", utils.MaxPostContentLength)))
+		return
+	}
+	if c.submitFunc == nil {
+		return
+	}
+	if err := c.submitFunc(text, c.sage); err != nil {
+		c.setMessage(theme.Error(tview.Escape(err.Error())))
+		return
+	}
+	c.textArea.SetText("", false)
+	c.Hide()
+}
+
+// edit opens the text in $VISUAL or $EDITOR while the TUI is suspended
+func (c *Composer) edit() {
+	if c.app == nil {
+		return
+	}
+	var text string
+	var err error
+	c.app.Suspend(func() {
+		text, err = editInEditor(c.textArea.GetText())
+	})
+	if err != nil {
+		c.setMessage(theme.Error(tview.Escape(err.Error())))
+		return
+	}
+	c.SetText(text)
+}
+
+func (c *Composer) togglePreview() {
+	c.previewing = !c.previewing
+	if c.previewing {
+		c.Flex.ResizeItem(c.preview, 0, 1)
+	} else {
+		c.Flex.ResizeItem(c.preview, 0, 0)
+	}
+	c.update()
+}
+
+func (c *Composer) setMessage(message string) {
+	c.message = message
+	c.update()
+}
+
+// update refreshes the character counter, the message and the preview
+func (c *Composer) update() {
+	text := c.textArea.GetText()
+	length := utf8.RuneCountInString(text)
+	counter := fmt.Sprintf("%d/%d", length, utils.MaxPostContentLength)
+	if length > utils.MaxPostContentLength {
+		counter = theme.Error(counter)
+	}
+
+	status := counter + "  "
+	if c.sage {
+		status += theme.Warning("sage") + "  "
+	}
+	if c.message != "" {
+		status += c.message
+	} else {
+		status += theme.Muted(tview.Escape(i18n.T("composer.help",
+			c.keymap.Describe(keys.Send), c.keymap.Describe(keys.Quote), c.keymap.Describe(keys.Editor),
+			c.keymap.Describe(keys.Preview), c.keymap.Describe(keys.Sage), c.keymap.Describe(keys.Close))))
+	}
+	c.status.SetText(status)
+
+	if c.previewing {
+		if c.previewFunc != nil {
+			c.preview.SetText(c.previewFunc(text))
+		} else {
+			c.preview.SetText(tview.Escape(text))
+		}
+	}
+}
+
+func editInEditor(text string) (string, error) {
+	f, err := os.CreateTemp("", "heisei-post-*.txt")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.WriteString(text); err != nil {
+		f.Close()
+		return "", fmt.Errorf("failed to write temporary file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return "", fmt.Errorf("failed to write temporary file: %w", err)
+	}
+
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+
+	// Run through the shell so that editors with arguments such as "code -w" work
+	cmd := exec.Command("sh", "-c", editor+` "$1"`, "sh", f.Name())
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("editor %q failed: %w", editor, err)
+	}
+
+	data, err := os.ReadFile(f.Name())
+	if err != nil {
+		return "", fmt.Errorf("failed to read temporary file: %w", err)
+	}
+	// Editors terminate the last line, which is not part of the post
+	return strings.TrimSuffix(string(data), "\n"), nil
+}
+
+// centered places p in the middle of the screen, taking num/den of each dimension
+func centered(p tview.Primitive, num, den int) tview.Primitive {
+	column := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(nil, 0, den-num, false).
+		AddItem(p, 0, 2*num, true).
+		AddItem(nil, 0, den-num, false)
+	return tview.NewFlex().
+		AddItem(nil, 0, den-num, false).
+		AddItem(column, 0, 2*num, true).
+		AddItem(nil, 0, den-num, false)
+}
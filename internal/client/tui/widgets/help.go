@@ -0,0 +1,82 @@
+package widgets
+
+import (
+	"fmt"
+	"strings"
+
+	"heisei/internal/client/i18n"
+	"heisei/internal/client/tui/keys"
+	"heisei/internal/client/tui/theme"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+const helpPage = "help"
+
+// Help is an overlay listing the active key bindings
+type Help struct {
+	*tview.TextView
+	keymap *keys.Keymap
+	pages  *tview.Pages
+}
+
+func NewHelp(keymap *keys.Keymap) *Help {
+	h := &Help{
+		TextView: tview.NewTextView().SetDynamicColors(true).SetScrollable(true),
+		keymap:   keymap,
+	}
+	h.SetBorder(true)
+	h.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if h.keymap.Is(event, keys.Help) || h.keymap.Is(event, keys.Back) || h.keymap.Is(event, keys.Quit) {
+			h.Hide()
+			return nil
+		}
+		return event
+	})
+	return h
+}
+
+// Show opens the overlay, rendered in the current language
+func (h *Help) Show(app *tview.Application, pages *tview.Pages) {
+	h.pages = pages
+	h.SetTitle(i18n.T("help.title"))
+	h.SetText(h.render())
+	h.ScrollToBeginning()
+	pages.AddPage(helpPage, centered(h, 3, 4), true, true)
+	app.SetFocus(h)
+}
+
+func (h *Help) Hide() {
+	if h.pages != nil {
+		h.pages.RemovePage(helpPage)
+	}
+}
+
+// Visible reports whether the overlay is open
+func (h *Help) Visible() bool {
+	return h.pages != nil && h.pages.HasPage(helpPage)
+}
+
+func (h *Help) render() string {
+	width := 0
+	for _, group := range keys.Groups {
+		for _, action := range group.Actions {
+			width = max(width, i18n.Width(h.keymap.Describe(action)))
+		}
+	}
+
+	var b strings.Builder
+	for i, group := range keys.Groups {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintln(&b, theme.Accent(tview.Escape(i18n.T("help."+group.Name))))
+		for _, action := range group.Actions {
+			described := h.keymap.Describe(action)
+			padding := strings.Repeat(" ", width-i18n.Width(described))
+			fmt.Fprintf(&b, "  %s%s  %s\n", tview.Escape(described), padding, tview.Escape(i18n.T("keys."+string(action))))
+		}
+	}
+	return b.String()
+}
@@ -4,7 +4,8 @@ import (
 	"fmt"
 	"time"
 
-	"github.com/gdamore/tcell/v2"
+	"heisei/internal/client/i18n"
+
 	"github.com/rivo/tview"
 )
 
@@ -18,13 +19,11 @@ func NewLoadingIndicator() *LoadingIndicator {
 		TextView: tview.NewTextView().
 			SetDynamicColors(true).
 			SetTextAlign(tview.AlignCenter).
-			SetText("Loading..."),
+			SetText(i18n.T("loading.text")),
 		stopChan: make(chan struct{}),
 	}
 	li.SetBorder(true)
-	li.SetTitle("Please wait")
-	li.SetBackgroundColor(tcell.ColorBlack)
-	li.SetTextColor(tcell.ColorWhite)
+	li.SetTitle(i18n.T("loading.title"))
 	return li
 }
 
@@ -37,7 +36,7 @@ func (li *LoadingIndicator) Start(app *tview.Application) {
 				return
 			default:
 				for _, frame := range frames {
-					li.SetText(fmt.Sprintf("%s Loading...", frame))
+					li.SetText(fmt.Sprintf("%s %s", frame, i18n.T("loading.text")))
 					app.Draw()
 					time.Sleep(100 * time.Millisecond)
 				}
@@ -1,7 +1,8 @@
 package widgets
 
 import (
-	"github.com/gdamore/tcell/v2"
+	"heisei/internal/client/i18n"
+
 	"github.com/rivo/tview"
 )
 
@@ -13,8 +14,8 @@ func NewMessageBox() *MessageBox {
 	m := &MessageBox{
 		Modal: tview.NewModal(),
 	}
-	m.SetBackgroundColor(tcell.ColorBlack)
-	m.SetTextColor(tcell.ColorWhite)
+	m.SetBackgroundColor(tview.Styles.PrimitiveBackgroundColor)
+	m.SetTextColor(tview.Styles.PrimaryTextColor)
 	m.SetDoneFunc(func(buttonIndex int, buttonLabel string) {
 		if buttonIndex == 0 {
 			m.Hide()
@@ -34,7 +35,7 @@ func (m *MessageBox) SetTitle(title string) *MessageBox {
 }
 
 func (m *MessageBox) Show(app *tview.Application, pages *tview.Pages) {
-	m.AddButtons([]string{"OK"})
+	m.AddButtons([]string{i18n.T("message.ok")})
 	pages.AddPage("message", m, true, true)
 	app.SetFocus(m)
 }
@@ -45,14 +46,14 @@ func (m *MessageBox) Hide() {
 
 func ShowError(app *tview.Application, pages *tview.Pages, message string) {
 	NewMessageBox().
-		SetTitle("Error").
+		SetTitle(i18n.T("message.error")).
 		SetMessage(message).
 		Show(app, pages)
 }
 
 func ShowInfo(app *tview.Application, pages *tview.Pages, message string) {
 	NewMessageBox().
-		SetTitle("Information").
+		SetTitle(i18n.T("message.info")).
 		SetMessage(message).
 		Show(app, pages)
 }
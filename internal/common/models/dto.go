@@ -4,9 +4,18 @@ import "time"
 
 // CategoryDTO represents the data transfer object for a category
 type CategoryDTO struct {
-	ID   uint   `json:"id"`
-	Name string `json:"name"`
-	Slug string `json:"slug"`
+	ID          uint   `json:"id"`
+	ParentID    *uint  `json:"parent_id"`
+	Position    int    `json:"position"`
+	Name        string `json:"name"`
+	Slug        string `json:"slug"`
+	Description string `json:"description"`
+	DefaultSort string `json:"default_sort"`
+	MaxPosts    int    `json:"max_posts"`
+	ReadOnly    bool   `json:"read_only"`
+	ThreadCount int64  `json:"thread_count"`
+	// LastPostAt is the time of the latest post in the category, nil if it has no threads
+	LastPostAt *time.Time `json:"last_post_at"`
 }
 
 // ThreadDTO represents the data transfer object for a thread
@@ -17,28 +26,79 @@ type ThreadDTO struct {
 	CreatedAt  time.Time `json:"created_at"`
 	UpdatedAt  time.Time `json:"updated_at"`
 	LastPostAt time.Time `json:"last_post_at"`
-	PostCount  int       `json:"post_count"`
+	// BumpedAt is the time of the latest post not marked sage
+	BumpedAt  time.Time `json:"bumped_at"`
+	PostCount int       `json:"post_count"`
+	Locked    bool      `json:"locked"`
 }
 
 // PostDTO represents the data transfer object for a post
 type PostDTO struct {
-	ID        uint      `json:"id"`
-	ThreadID  uint      `json:"thread_id"`
+	ID       uint `json:"id"`
+	ThreadID uint `json:"thread_id"`
+	// Number is the position of the post in its thread, kept when earlier
+	// posts are deleted; >>N references refer to it
+	Number    int       `json:"number"`
 	Content   string    `json:"content"`
 	CreatedAt time.Time `json:"created_at"`
+	Sage      bool      `json:"sage"`
 	AuthorIP  string    `json:"author_ip,omitempty"` // オプショナル、管理者のみ表示
 }
 
+// CategoryRequest represents the request body for creating or updating a category
+type CategoryRequest struct {
+	ParentID    *uint  `json:"parent_id"`
+	Position    int    `json:"position"`
+	Name        string `json:"name" validate:"required,max=50"`
+	Slug        string `json:"slug" validate:"required,max=50,slug"`
+	Description string `json:"description" validate:"max=500"`
+	DefaultSort string `json:"default_sort" validate:"omitempty,oneof=bump created post_count activity_24h"`
+	MaxPosts    *int   `json:"max_posts" validate:"omitempty,min=0"`
+	ReadOnly    bool   `json:"read_only"`
+}
+
+// Thread sort orders of a category
+const (
+	// ThreadSortBump lists the threads with the latest posts not marked sage first
+	ThreadSortBump = "bump"
+	// ThreadSortCreated lists the newest threads first
+	ThreadSortCreated = "created"
+	// ThreadSortPostCount lists the threads with the most posts first
+	ThreadSortPostCount = "post_count"
+	// ThreadSortActivity lists the threads with the most posts in the last 24 hours first
+	ThreadSortActivity = "activity_24h"
+)
+
+// ThreadSorts lists the thread sort orders
+var ThreadSorts = []string{ThreadSortBump, ThreadSortCreated, ThreadSortPostCount, ThreadSortActivity}
+
+// DefaultMaxPosts is the post limit of threads in categories that set none;
+// a limit of 0 lets threads grow without bound
+const DefaultMaxPosts = 1000
+
 // CreateThreadRequest represents the request body for creating a new thread
 type CreateThreadRequest struct {
-	CategoryID uint   `json:"category_id"`
-	Title      string `json:"title"`
+	CategoryID uint   `json:"category_id" validate:"required"`
+	Title      string `json:"title" validate:"required,max=200"`
+}
+
+// UpdateThreadRequest represents the request body for updating a thread
+type UpdateThreadRequest struct {
+	CategoryID uint   `json:"category_id" validate:"required"`
+	Title      string `json:"title" validate:"required,max=200"`
 }
 
 // CreatePostRequest represents the request body for creating a new post
 type CreatePostRequest struct {
-	ThreadID uint   `json:"thread_id"`
-	Content  string `json:"content"`
+	ThreadID uint   `json:"thread_id" validate:"required"`
+	Content  string `json:"content" validate:"required,max=10000"`
+	// Sage posts without bumping the thread
+	Sage bool `json:"sage,omitempty"`
+}
+
+// UpdatePostRequest represents the request body for updating a post
+type UpdatePostRequest struct {
+	Content string `json:"content" validate:"required,max=10000"`
 }
 
 // PaginatedResponse represents a generic paginated response
@@ -49,3 +109,22 @@ type PaginatedResponse struct {
 	PerPage     int         `json:"per_page"`
 	Data        interface{} `json:"data"`
 }
+
+// HealthCheckResult represents the outcome of a single health check
+type HealthCheckResult struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// HealthResponse represents the response body of the health endpoints
+type HealthResponse struct {
+	Status string              `json:"status"`
+	Checks []HealthCheckResult `json:"checks,omitempty"`
+}
+
+// Health check statuses
+const (
+	HealthStatusOK   = "ok"
+	HealthStatusFail = "fail"
+)
@@ -4,8 +4,9 @@ import "fmt"
 
 // AppError represents a custom error type for the application
 type AppError struct {
-	Code    int    `json:"code"`
-	Message string `json:"message"`
+	Code    int           `json:"code"`
+	Message string        `json:"message"`
+	Details []ErrorDetail `json:"details,omitempty"`
 }
 
 func (e *AppError) Error() string {
@@ -26,7 +27,13 @@ const (
 	ErrCodeUnauthorized        = 401
 	ErrCodeForbidden           = 403
 	ErrCodeNotFound            = 404
+	ErrCodeMethodNotAllowed    = 405
+	ErrCodeConflict            = 409
+	ErrCodePayloadTooLarge     = 413
+	ErrCodeUnprocessableEntity = 422
+	ErrCodeTooManyRequests     = 429
 	ErrCodeInternalServerError = 500
+	ErrCodeServiceUnavailable  = 503
 )
 
 // Common errors
@@ -50,3 +57,78 @@ func ErrDuplicateEntry(field string) *AppError {
 func ErrResourceNotFound(resource string) *AppError {
 	return NewAppError(ErrCodeNotFound, fmt.Sprintf("%s not found", resource))
 }
+
+func ErrThreadLocked(threadID uint) *AppError {
+	return NewAppError(ErrCodeConflict, fmt.Sprintf("Thread %d is locked", threadID))
+}
+
+func ErrThreadFull(threadID uint) *AppError {
+	return NewAppError(ErrCodeConflict, fmt.Sprintf("Thread %d reached the post limit", threadID))
+}
+
+func ErrCategoryReadOnly(categoryID uint) *AppError {
+	return NewAppError(ErrCodeForbidden, fmt.Sprintf("Category %d is read-only", categoryID))
+}
+
+func ErrAuthorBanned() *AppError {
+	return NewAppError(ErrCodeForbidden, "Posting from this address is banned")
+}
+
+// ErrorDetail describes a single problem with a request, usually tied to a field
+type ErrorDetail struct {
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+// ErrorResponse represents the JSON body of every error response of the API
+type ErrorResponse struct {
+	Code      string        `json:"code"`
+	Message   string        `json:"message"`
+	Details   []ErrorDetail `json:"details,omitempty"`
+	RequestID string        `json:"request_id,omitempty"`
+}
+
+// Machine readable error codes used in ErrorResponse.Code
+const (
+	ErrorCodeBadRequest       = "bad_request"
+	ErrorCodeUnauthorized     = "unauthorized"
+	ErrorCodeForbidden        = "forbidden"
+	ErrorCodeNotFound         = "not_found"
+	ErrorCodeMethodNotAllowed = "method_not_allowed"
+	ErrorCodeConflict         = "conflict"
+	ErrorCodePayloadTooLarge  = "payload_too_large"
+	ErrorCodeValidationFailed = "validation_failed"
+	ErrorCodeTooManyRequests  = "too_many_requests"
+	ErrorCodeInternal         = "internal_error"
+	ErrorCodeUnavailable      = "service_unavailable"
+)
+
+// ErrorCodeForStatus returns the error code used for an HTTP status code
+func ErrorCodeForStatus(status int) string {
+	switch status {
+	case ErrCodeBadRequest:
+		return ErrorCodeBadRequest
+	case ErrCodeUnauthorized:
+		return ErrorCodeUnauthorized
+	case ErrCodeForbidden:
+		return ErrorCodeForbidden
+	case ErrCodeNotFound:
+		return ErrorCodeNotFound
+	case ErrCodeMethodNotAllowed:
+		return ErrorCodeMethodNotAllowed
+	case ErrCodeConflict:
+		return ErrorCodeConflict
+	case ErrCodePayloadTooLarge:
+		return ErrorCodePayloadTooLarge
+	case ErrCodeUnprocessableEntity:
+		return ErrorCodeValidationFailed
+	case ErrCodeTooManyRequests:
+		return ErrorCodeTooManyRequests
+	case ErrCodeServiceUnavailable:
+		return ErrorCodeUnavailable
+	}
+	if status >= 500 {
+		return ErrorCodeInternal
+	}
+	return ErrorCodeBadRequest
+}
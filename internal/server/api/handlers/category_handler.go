@@ -1,11 +1,10 @@
 package handlers
 
 import (
-	"encoding/json"
 	"net/http"
-	"strconv"
 
 	"heisei/internal/common/models"
+	"heisei/internal/server/api/response"
 	"heisei/internal/server/services"
 
 	"github.com/gorilla/mux"
@@ -33,98 +32,101 @@ func (h *CategoryHandler) RegisterRoutes(r *mux.Router) {
 }
 
 func (h *CategoryHandler) GetCategories(w http.ResponseWriter, r *http.Request) {
-	categories, err := h.service.GetAllCategories()
+	categories, err := h.service.GetAllCategories(r.Context())
 	if err != nil {
-		h.logger.Error("Failed to get categories", zap.Error(err))
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		response.Error(w, r, h.logger, err)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(categories)
+	response.JSON(w, http.StatusOK, categories)
 }
 
 func (h *CategoryHandler) CreateCategory(w http.ResponseWriter, r *http.Request) {
-	var category models.CategoryDTO
-	if err := json.NewDecoder(r.Body).Decode(&category); err != nil {
-		h.logger.Error("Failed to decode category", zap.Error(err))
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	var req models.CategoryRequest
+	if err := decodeBody(w, r, &req); err != nil {
+		response.Error(w, r, h.logger, err)
 		return
 	}
 
-	createdCategory, err := h.service.CreateCategory(category)
+	createdCategory, err := h.service.CreateCategory(r.Context(), categoryFromRequest(&req))
 	if err != nil {
-		h.logger.Error("Failed to create category", zap.Error(err))
-		http.Error(w, "Failed to create category", http.StatusInternalServerError)
+		response.Error(w, r, h.logger, err)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(createdCategory)
+	response.JSON(w, http.StatusCreated, createdCategory)
 }
 
 func (h *CategoryHandler) GetCategory(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	id, err := strconv.Atoi(vars["id"])
+	id, err := parseID(r, "id")
 	if err != nil {
-		h.logger.Error("Invalid category ID", zap.Error(err))
-		http.Error(w, "Invalid category ID", http.StatusBadRequest)
+		response.Error(w, r, h.logger, err)
 		return
 	}
 
-	category, err := h.service.GetCategoryByID(uint(id))
+	category, err := h.service.GetCategoryByID(r.Context(), id)
 	if err != nil {
-		h.logger.Error("Failed to get category", zap.Error(err))
-		http.Error(w, "Category not found", http.StatusNotFound)
+		response.Error(w, r, h.logger, err)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(category)
+	response.JSON(w, http.StatusOK, category)
 }
 
 func (h *CategoryHandler) UpdateCategory(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	id, err := strconv.Atoi(vars["id"])
+	id, err := parseID(r, "id")
 	if err != nil {
-		h.logger.Error("Invalid category ID", zap.Error(err))
-		http.Error(w, "Invalid category ID", http.StatusBadRequest)
+		response.Error(w, r, h.logger, err)
 		return
 	}
 
-	var category models.CategoryDTO
-	if err := json.NewDecoder(r.Body).Decode(&category); err != nil {
-		h.logger.Error("Failed to decode category", zap.Error(err))
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	var req models.CategoryRequest
+	if err := decodeBody(w, r, &req); err != nil {
+		response.Error(w, r, h.logger, err)
 		return
 	}
 
-	updatedCategory, err := h.service.UpdateCategory(uint(id), category)
+	updatedCategory, err := h.service.UpdateCategory(r.Context(), id, categoryFromRequest(&req))
 	if err != nil {
-		h.logger.Error("Failed to update category", zap.Error(err))
-		http.Error(w, "Failed to update category", http.StatusInternalServerError)
+		response.Error(w, r, h.logger, err)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(updatedCategory)
+	response.JSON(w, http.StatusOK, updatedCategory)
 }
 
 func (h *CategoryHandler) DeleteCategory(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	id, err := strconv.Atoi(vars["id"])
+	id, err := parseID(r, "id")
 	if err != nil {
-		h.logger.Error("Invalid category ID", zap.Error(err))
-		http.Error(w, "Invalid category ID", http.StatusBadRequest)
+		response.Error(w, r, h.logger, err)
 		return
 	}
 
-	if err := h.service.DeleteCategory(uint(id)); err != nil {
-		h.logger.Error("Failed to delete category", zap.Error(err))
-		http.Error(w, "Failed to delete category", http.StatusInternalServerError)
+	if err := h.service.DeleteCategory(r.Context(), id); err != nil {
+		response.Error(w, r, h.logger, err)
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// categoryFromRequest fills in the defaults of the settings left out of req
+func categoryFromRequest(req *models.CategoryRequest) models.CategoryDTO {
+	dto := models.CategoryDTO{
+		ParentID:    req.ParentID,
+		Position:    req.Position,
+		Name:        req.Name,
+		Slug:        req.Slug,
+		Description: req.Description,
+		DefaultSort: req.DefaultSort,
+		MaxPosts:    models.DefaultMaxPosts,
+		ReadOnly:    req.ReadOnly,
+	}
+	if dto.DefaultSort == "" {
+		dto.DefaultSort = models.ThreadSortBump
+	}
+	if req.MaxPosts != nil {
+		dto.MaxPosts = *req.MaxPosts
+	}
+	return dto
+}
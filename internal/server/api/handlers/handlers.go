@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"net/http"
+
+	"heisei/internal/server/api/middleware"
+	"heisei/internal/server/api/openapi"
+	"heisei/internal/server/api/response"
+	"heisei/internal/server/config"
+	"heisei/internal/server/services"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+)
+
+// Handlers groups the HTTP handlers of the server
+type Handlers struct {
+	Category *CategoryHandler
+	Thread   *ThreadHandler
+	Post     *PostHandler
+	Health   *HealthHandler
+	OpenAPI  *OpenAPIHandler
+}
+
+// NewHandlers creates all handlers on top of the given services
+func NewHandlers(s *services.Services, logger *zap.Logger) *Handlers {
+	return &Handlers{
+		Category: NewCategoryHandler(s.Category, logger),
+		Thread:   NewThreadHandler(s.Thread, logger),
+		Post:     NewPostHandler(s.Post, logger),
+		Health:   NewHealthHandler(s.Health, logger),
+		OpenAPI:  NewOpenAPIHandler(),
+	}
+}
+
+// NewHandler returns the HTTP handler of the server: all routes behind the
+// tracing, request ID, logging and query timeout middleware
+func NewHandler(s *services.Services, cfg *config.Config, logger *zap.Logger) http.Handler {
+	m := middleware.NewMiddleware(logger)
+	mux := NewHandlers(s, logger).SetupRoutes()
+	return m.TracingMiddleware(m.RequestIDMiddleware(m.LoggingMiddleware(m.QueryTimeoutMiddleware(cfg.Database.QueryTimeout, mux))))
+}
+
+// SetupRoutes registers every handler on a new router.
+// Health endpoints live at the root, everything else under the versioned API prefix.
+func (h *Handlers) SetupRoutes() *mux.Router {
+	r := mux.NewRouter()
+	r.Use(middleware.RouteTracing)
+	r.NotFoundHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		response.ErrorStatus(w, r, http.StatusNotFound, "Route not found")
+	})
+	r.MethodNotAllowedHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		response.ErrorStatus(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+	})
+	h.Health.RegisterRoutes(r)
+
+	api := r.PathPrefix(openapi.BasePath).Subrouter()
+	h.OpenAPI.RegisterRoutes(api)
+	h.Category.RegisterRoutes(api)
+	h.Thread.RegisterRoutes(api)
+	h.Post.RegisterRoutes(api)
+
+	return r
+}
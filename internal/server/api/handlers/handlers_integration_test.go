@@ -0,0 +1,54 @@
+//go:build integration
+
+package handlers_test
+
+import (
+	"net/http"
+	"testing"
+
+	"heisei/internal/server/testserver"
+	"heisei/pkg/database/dbtest"
+)
+
+func TestMain(m *testing.M) { dbtest.Main(m) }
+
+// TestGoldenResponses checks the JSON served for the fixtures against
+// testdata/*.golden.json
+func TestGoldenResponses(t *testing.T) {
+	srv := testserver.New(t)
+	srv.Seed(t)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		status int
+	}{
+		{"categories", http.MethodGet, "/categories", "", http.StatusOK},
+		{"category", http.MethodGet, "/categories/2", "", http.StatusOK},
+		{"threads_by_category", http.MethodGet, "/threads?category_id=2", "", http.StatusOK},
+		{"thread", http.MethodGet, "/threads/1", "", http.StatusOK},
+		{"posts_by_thread", http.MethodGet, "/threads/1/posts", "", http.StatusOK},
+		{"thread_not_found", http.MethodGet, "/threads/999", "", http.StatusNotFound},
+		{"route_not_found", http.MethodGet, "/nowhere", "", http.StatusNotFound},
+		{"post_to_locked_thread", http.MethodPost, "/posts", `{"thread_id":2,"content":"Let me in"}`, http.StatusConflict},
+		{"thread_in_read_only_category", http.MethodPost, "/threads", `{"category_id":3,"title":"New"}`, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body []byte
+			if tt.body != "" {
+				body = []byte(tt.body)
+			}
+			resp, data := srv.Do(t, tt.method, tt.path, body)
+			if resp.StatusCode != tt.status {
+				t.Fatalf("status = %d, want %d: %s", resp.StatusCode, tt.status, data)
+			}
+			if ct := resp.Header.Get("Content-Type"); ct != "application/json" {
+				t.Errorf("Content-Type = %q, want application/json", ct)
+			}
+			testserver.AssertGolden(t, tt.name, data)
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"heisei/internal/server/api/response"
+	"heisei/internal/server/services"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+)
+
+// readinessTimeout bounds the time spent on dependency checks per probe
+const readinessTimeout = 3 * time.Second
+
+type HealthHandler struct {
+	service *services.HealthService
+	logger  *zap.Logger
+}
+
+func NewHealthHandler(service *services.HealthService, logger *zap.Logger) *HealthHandler {
+	return &HealthHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+func (h *HealthHandler) RegisterRoutes(r *mux.Router) {
+	r.HandleFunc("/healthz", h.Liveness).Methods("GET")
+	r.HandleFunc("/readyz", h.Readiness).Methods("GET")
+}
+
+func (h *HealthHandler) Liveness(w http.ResponseWriter, r *http.Request) {
+	response.JSON(w, http.StatusOK, h.service.Liveness())
+}
+
+func (h *HealthHandler) Readiness(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), readinessTimeout)
+	defer cancel()
+
+	report, ready := h.service.Readiness(ctx)
+
+	status := http.StatusOK
+	if !ready {
+		status = http.StatusServiceUnavailable
+	}
+	response.JSON(w, status, report)
+}
@@ -0,0 +1,17 @@
+package handlers
+
+import (
+	"heisei/internal/server/api/openapi"
+
+	"github.com/gorilla/mux"
+)
+
+type OpenAPIHandler struct{}
+
+func NewOpenAPIHandler() *OpenAPIHandler {
+	return &OpenAPIHandler{}
+}
+
+func (h *OpenAPIHandler) RegisterRoutes(r *mux.Router) {
+	r.HandleFunc("/openapi.json", openapi.ServeSpec).Methods("GET")
+}
@@ -1,11 +1,10 @@
 package handlers
 
 import (
-	"encoding/json"
 	"net/http"
-	"strconv"
 
 	"heisei/internal/common/models"
+	"heisei/internal/server/api/response"
 	"heisei/internal/server/services"
 
 	"github.com/gorilla/mux"
@@ -33,104 +32,90 @@ func (h *PostHandler) RegisterRoutes(r *mux.Router) {
 }
 
 func (h *PostHandler) CreatePost(w http.ResponseWriter, r *http.Request) {
-	var post models.PostDTO
-	if err := json.NewDecoder(r.Body).Decode(&post); err != nil {
-		h.logger.Error("Failed to decode post", zap.Error(err))
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	var req models.CreatePostRequest
+	if err := decodeBody(w, r, &req); err != nil {
+		response.Error(w, r, h.logger, err)
 		return
 	}
 
-	createdPost, err := h.service.CreatePost(post)
+	post := models.PostDTO{
+		ThreadID: req.ThreadID,
+		Content:  req.Content,
+		Sage:     req.Sage,
+		AuthorIP: clientIP(r),
+	}
+	createdPost, err := h.service.CreatePost(r.Context(), post)
 	if err != nil {
-		h.logger.Error("Failed to create post", zap.Error(err))
-		http.Error(w, "Failed to create post", http.StatusInternalServerError)
+		response.Error(w, r, h.logger, err)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(createdPost)
+	response.JSON(w, http.StatusCreated, createdPost)
 }
 
 func (h *PostHandler) GetPostsByThread(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	threadID, err := strconv.Atoi(vars["threadId"])
+	threadID, err := parseID(r, "threadId")
 	if err != nil {
-		h.logger.Error("Invalid thread ID", zap.Error(err))
-		http.Error(w, "Invalid thread ID", http.StatusBadRequest)
+		response.Error(w, r, h.logger, err)
 		return
 	}
 
-	posts, err := h.service.GetPostsByThread(uint(threadID))
+	posts, err := h.service.GetPostsByThread(r.Context(), threadID)
 	if err != nil {
-		h.logger.Error("Failed to get posts", zap.Error(err))
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		response.Error(w, r, h.logger, err)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(posts)
+	response.JSON(w, http.StatusOK, posts)
 }
 
 func (h *PostHandler) GetPost(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	id, err := strconv.Atoi(vars["id"])
+	id, err := parseID(r, "id")
 	if err != nil {
-		h.logger.Error("Invalid post ID", zap.Error(err))
-		http.Error(w, "Invalid post ID", http.StatusBadRequest)
+		response.Error(w, r, h.logger, err)
 		return
 	}
 
-	post, err := h.service.GetPostByID(uint(id))
+	post, err := h.service.GetPostByID(r.Context(), id)
 	if err != nil {
-		h.logger.Error("Failed to get post", zap.Error(err))
-		http.Error(w, "Post not found", http.StatusNotFound)
+		response.Error(w, r, h.logger, err)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(post)
+	response.JSON(w, http.StatusOK, post)
 }
 
 func (h *PostHandler) UpdatePost(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	id, err := strconv.Atoi(vars["id"])
+	id, err := parseID(r, "id")
 	if err != nil {
-		h.logger.Error("Invalid post ID", zap.Error(err))
-		http.Error(w, "Invalid post ID", http.StatusBadRequest)
+		response.Error(w, r, h.logger, err)
 		return
 	}
 
-	var post models.PostDTO
-	if err := json.NewDecoder(r.Body).Decode(&post); err != nil {
-		h.logger.Error("Failed to decode post", zap.Error(err))
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	var req models.UpdatePostRequest
+	if err := decodeBody(w, r, &req); err != nil {
+		response.Error(w, r, h.logger, err)
 		return
 	}
 
-	updatedPost, err := h.service.UpdatePost(uint(id), post)
+	updatedPost, err := h.service.UpdatePost(r.Context(), id, models.PostDTO{Content: req.Content})
 	if err != nil {
-		h.logger.Error("Failed to update post", zap.Error(err))
-		http.Error(w, "Failed to update post", http.StatusInternalServerError)
+		response.Error(w, r, h.logger, err)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(updatedPost)
+	response.JSON(w, http.StatusOK, updatedPost)
 }
 
 func (h *PostHandler) DeletePost(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	id, err := strconv.Atoi(vars["id"])
+	id, err := parseID(r, "id")
 	if err != nil {
-		h.logger.Error("Invalid post ID", zap.Error(err))
-		http.Error(w, "Invalid post ID", http.StatusBadRequest)
+		response.Error(w, r, h.logger, err)
 		return
 	}
 
-	if err := h.service.DeletePost(uint(id)); err != nil {
-		h.logger.Error("Failed to delete post", zap.Error(err))
-		http.Error(w, "Failed to delete post", http.StatusInternalServerError)
+	if err := h.service.DeletePost(r.Context(), id); err != nil {
+		response.Error(w, r, h.logger, err)
 		return
 	}
 
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"heisei/internal/common/models"
+	servermodels "heisei/internal/server/models"
+
+	"github.com/gorilla/mux"
+)
+
+// maxRequestBodySize bounds request bodies; the largest valid body is a
+// post of 10000 runes, which fits comfortably
+const maxRequestBodySize = 64 << 10
+
+// parseID reads a numeric ID from the route variable with the given name
+func parseID(r *http.Request, name string) (uint, error) {
+	id, err := strconv.ParseUint(mux.Vars(r)[name], 10, 32)
+	if err != nil {
+		return 0, models.ErrInvalidInput(name)
+	}
+	return uint(id), nil
+}
+
+// decodeBody decodes the JSON request body into v and validates it.
+// Unknown fields, trailing data and bodies over maxRequestBodySize are rejected.
+func decodeBody(w http.ResponseWriter, r *http.Request, v interface{}) error {
+	r.Body = http.MaxBytesReader(w, r.Body, maxRequestBodySize)
+
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return decodeError(err)
+	}
+	if err := dec.Decode(&struct{}{}); !errors.Is(err, io.EOF) {
+		return models.NewAppError(models.ErrCodeBadRequest, "Request body must contain a single JSON object")
+	}
+
+	return servermodels.ValidateStruct(v)
+}
+
+// decodeError converts a JSON decoding error into an AppError
+func decodeError(err error) error {
+	var maxBytesErr *http.MaxBytesError
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+
+	switch {
+	case errors.As(err, &maxBytesErr):
+		return models.NewAppError(models.ErrCodePayloadTooLarge,
+			fmt.Sprintf("Request body must not be larger than %d bytes", maxBytesErr.Limit))
+	case errors.Is(err, io.EOF):
+		return models.NewAppError(models.ErrCodeBadRequest, "Request body is required")
+	case errors.As(err, &syntaxErr):
+		return models.NewAppError(models.ErrCodeBadRequest,
+			fmt.Sprintf("Malformed JSON at position %d", syntaxErr.Offset))
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return models.NewAppError(models.ErrCodeBadRequest, "Malformed JSON")
+	case errors.As(err, &typeErr):
+		appErr := models.NewAppError(models.ErrCodeBadRequest, "Invalid request body")
+		appErr.Details = []models.ErrorDetail{{
+			Field:   typeErr.Field,
+			Message: fmt.Sprintf("must be a JSON %s", typeErr.Type.Kind()),
+		}}
+		return appErr
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		field := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		appErr := models.NewAppError(models.ErrCodeBadRequest, "Invalid request body")
+		appErr.Details = []models.ErrorDetail{{Field: field, Message: "unknown field"}}
+		return appErr
+	}
+	return models.NewAppError(models.ErrCodeBadRequest, "Invalid request body")
+}
+
+// clientIP returns the IP address of the client that sent the request
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -1,17 +1,23 @@
 package handlers
 
 import (
-	"encoding/json"
 	"net/http"
+	"slices"
 	"strconv"
+	"strings"
+	"unicode/utf8"
 
 	"heisei/internal/common/models"
+	"heisei/internal/server/api/response"
 	"heisei/internal/server/services"
 
 	"github.com/gorilla/mux"
 	"go.uber.org/zap"
 )
 
+// maxSearchQueryLength bounds the q parameter of thread searches in runes
+const maxSearchQueryLength = 100
+
 type ThreadHandler struct {
 	service *services.ThreadService
 	logger  *zap.Logger
@@ -33,111 +39,108 @@ func (h *ThreadHandler) RegisterRoutes(r *mux.Router) {
 }
 
 func (h *ThreadHandler) GetThreads(w http.ResponseWriter, r *http.Request) {
-	categoryID := r.URL.Query().Get("category_id")
-	var threads []models.ThreadDTO
-	var err error
-
-	if categoryID != "" {
-		id, err := strconv.Atoi(categoryID)
-		if err != nil {
-			h.logger.Error("Invalid category ID", zap.Error(err))
-			http.Error(w, "Invalid category ID", http.StatusBadRequest)
+	var categoryID uint
+	if value := r.URL.Query().Get("category_id"); value != "" {
+		id, err := strconv.ParseUint(value, 10, 32)
+		if err != nil || id == 0 {
+			response.Error(w, r, h.logger, models.ErrInvalidInput("category_id"))
 			return
 		}
-		threads, err = h.service.GetThreadsByCategory(uint(id))
-	} else {
-		threads, err = h.service.GetAllThreads()
+		categoryID = uint(id)
+	}
+	sort := r.URL.Query().Get("sort")
+	if sort != "" && !slices.Contains(models.ThreadSorts, sort) {
+		response.Error(w, r, h.logger, models.ErrInvalidInput("sort"))
+		return
+	}
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	if utf8.RuneCountInString(query) > maxSearchQueryLength {
+		response.Error(w, r, h.logger, models.ErrInvalidInput("q"))
+		return
+	}
+
+	var threads []models.ThreadDTO
+	var err error
+	switch {
+	case query != "":
+		threads, err = h.service.SearchThreads(r.Context(), categoryID, query)
+	case categoryID != 0:
+		threads, err = h.service.GetThreadsByCategory(r.Context(), categoryID, sort)
+	default:
+		threads, err = h.service.GetAllThreads(r.Context())
 	}
 
 	if err != nil {
-		h.logger.Error("Failed to get threads", zap.Error(err))
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		response.Error(w, r, h.logger, err)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(threads)
+	response.JSON(w, http.StatusOK, threads)
 }
 
 func (h *ThreadHandler) CreateThread(w http.ResponseWriter, r *http.Request) {
-	var thread models.ThreadDTO
-	if err := json.NewDecoder(r.Body).Decode(&thread); err != nil {
-		h.logger.Error("Failed to decode thread", zap.Error(err))
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	var req models.CreateThreadRequest
+	if err := decodeBody(w, r, &req); err != nil {
+		response.Error(w, r, h.logger, err)
 		return
 	}
 
-	createdThread, err := h.service.CreateThread(thread)
+	createdThread, err := h.service.CreateThread(r.Context(), models.ThreadDTO{CategoryID: req.CategoryID, Title: req.Title})
 	if err != nil {
-		h.logger.Error("Failed to create thread", zap.Error(err))
-		http.Error(w, "Failed to create thread", http.StatusInternalServerError)
+		response.Error(w, r, h.logger, err)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(createdThread)
+	response.JSON(w, http.StatusCreated, createdThread)
 }
 
 func (h *ThreadHandler) GetThread(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	id, err := strconv.Atoi(vars["id"])
+	id, err := parseID(r, "id")
 	if err != nil {
-		h.logger.Error("Invalid thread ID", zap.Error(err))
-		http.Error(w, "Invalid thread ID", http.StatusBadRequest)
+		response.Error(w, r, h.logger, err)
 		return
 	}
 
-	thread, err := h.service.GetThreadByID(uint(id))
+	thread, err := h.service.GetThreadByID(r.Context(), id)
 	if err != nil {
-		h.logger.Error("Failed to get thread", zap.Error(err))
-		http.Error(w, "Thread not found", http.StatusNotFound)
+		response.Error(w, r, h.logger, err)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(thread)
+	response.JSON(w, http.StatusOK, thread)
 }
 
 func (h *ThreadHandler) UpdateThread(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	id, err := strconv.Atoi(vars["id"])
+	id, err := parseID(r, "id")
 	if err != nil {
-		h.logger.Error("Invalid thread ID", zap.Error(err))
-		http.Error(w, "Invalid thread ID", http.StatusBadRequest)
+		response.Error(w, r, h.logger, err)
 		return
 	}
 
-	var thread models.ThreadDTO
-	if err := json.NewDecoder(r.Body).Decode(&thread); err != nil {
-		h.logger.Error("Failed to decode thread", zap.Error(err))
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	var req models.UpdateThreadRequest
+	if err := decodeBody(w, r, &req); err != nil {
+		response.Error(w, r, h.logger, err)
 		return
 	}
 
-	updatedThread, err := h.service.UpdateThread(uint(id), thread)
+	updatedThread, err := h.service.UpdateThread(r.Context(), id, models.ThreadDTO{CategoryID: req.CategoryID, Title: req.Title})
 	if err != nil {
-		h.logger.Error("Failed to update thread", zap.Error(err))
-		http.Error(w, "Failed to update thread", http.StatusInternalServerError)
+		response.Error(w, r, h.logger, err)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(updatedThread)
+	response.JSON(w, http.StatusOK, updatedThread)
 }
 
 func (h *ThreadHandler) DeleteThread(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	id, err := strconv.Atoi(vars["id"])
+	id, err := parseID(r, "id")
 	if err != nil {
-		h.logger.Error("Invalid thread ID", zap.Error(err))
-		http.Error(w, "Invalid thread ID", http.StatusBadRequest)
+		response.Error(w, r, h.logger, err)
 		return
 	}
 
-	if err := h.service.DeleteThread(uint(id)); err != nil {
-		h.logger.Error("Failed to delete thread", zap.Error(err))
-		http.Error(w, "Failed to delete thread", http.StatusInternalServerError)
+	if err := h.service.DeleteThread(r.Context(), id); err != nil {
+		response.Error(w, r, h.logger, err)
 		return
 	}
 
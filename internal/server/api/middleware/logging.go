@@ -4,6 +4,8 @@ import (
 	"net/http"
 	"time"
 
+	"heisei/pkg/utils"
+
 	"go.uber.org/zap"
 )
 
@@ -31,14 +33,15 @@ func (m *LoggingMiddleware) Logging(next http.Handler) http.Handler {
 		next.ServeHTTP(wrappedWriter, r)
 
 		// Log the request details
-		m.logger.Info("HTTP request",
+		fields := []zap.Field{
 			zap.String("method", r.Method),
 			zap.String("path", r.URL.Path),
 			zap.Int("status", wrappedWriter.statusCode),
 			zap.Duration("duration", time.Since(start)),
 			zap.String("remote_addr", r.RemoteAddr),
 			zap.String("user_agent", r.UserAgent()),
-		)
+		}
+		m.logger.Info("HTTP request", append(fields, utils.RequestFields(r.Context())...)...)
 	})
 }
 
@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Middleware groups the HTTP middlewares used by the server
+type Middleware struct {
+	logging *LoggingMiddleware
+}
+
+// NewMiddleware creates the middlewares used by the server
+func NewMiddleware(logger *zap.Logger) *Middleware {
+	return &Middleware{
+		logging: NewLoggingMiddleware(logger),
+	}
+}
+
+// LoggingMiddleware logs every request handled by next
+func (m *Middleware) LoggingMiddleware(next http.Handler) http.Handler {
+	return m.logging.Logging(next)
+}
+
+// TracingMiddleware starts a span for every request handled by next
+func (m *Middleware) TracingMiddleware(next http.Handler) http.Handler {
+	return Tracing(next)
+}
+
+// RequestIDMiddleware assigns a request ID to every request handled by next
+func (m *Middleware) RequestIDMiddleware(next http.Handler) http.Handler {
+	return RequestID(next)
+}
+
+// QueryTimeoutMiddleware bounds the database time of every request handled by next
+func (m *Middleware) QueryTimeoutMiddleware(timeout time.Duration, next http.Handler) http.Handler {
+	return QueryTimeout(timeout, next)
+}
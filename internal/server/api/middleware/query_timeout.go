@@ -0,0 +1,22 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// QueryTimeout gives every request a deadline, which the services pass on to
+// their database queries, so that slow queries are canceled in PostgreSQL.
+// A timeout of 0 leaves requests without deadline. Queries are also canceled
+// when the client disconnects, since the request context is canceled then.
+func QueryTimeout(timeout time.Duration, next http.Handler) http.Handler {
+	if timeout <= 0 {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
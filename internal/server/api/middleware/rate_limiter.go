@@ -5,13 +5,15 @@ import (
 	"sync"
 	"time"
 
+	"heisei/internal/server/api/response"
+
 	"go.uber.org/zap"
 	"golang.org/x/time/rate"
 )
 
 type RateLimiterMiddleware struct {
 	logger   *zap.Logger
-	visitors map[string]*rate.Limiter
+	visitors map[string]*visitor
 	mu       sync.RWMutex
 	r        rate.Limit
 	b        int
@@ -20,23 +22,30 @@ type RateLimiterMiddleware struct {
 func NewRateLimiterMiddleware(r rate.Limit, b int, logger *zap.Logger) *RateLimiterMiddleware {
 	return &RateLimiterMiddleware{
 		logger:   logger,
-		visitors: make(map[string]*rate.Limiter),
+		visitors: make(map[string]*visitor),
 		r:        r,
 		b:        b,
 	}
 }
 
+// visitor holds the rate limiter for a single client and when it was last seen
+type visitor struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
 func (m *RateLimiterMiddleware) getVisitor(ip string) *rate.Limiter {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	limiter, exists := m.visitors[ip]
+	v, exists := m.visitors[ip]
 	if !exists {
-		limiter = rate.NewLimiter(m.r, m.b)
-		m.visitors[ip] = limiter
+		v = &visitor{limiter: rate.NewLimiter(m.r, m.b)}
+		m.visitors[ip] = v
 	}
+	v.lastSeen = time.Now()
 
-	return limiter
+	return v.limiter
 }
 
 func (m *RateLimiterMiddleware) RateLimit(next http.Handler) http.Handler {
@@ -49,7 +58,7 @@ func (m *RateLimiterMiddleware) RateLimit(next http.Handler) http.Handler {
 				zap.String("ip", ip),
 				zap.String("path", r.URL.Path),
 			)
-			http.Error(w, "Too Many Requests", http.StatusTooManyRequests)
+			response.ErrorStatus(w, r, http.StatusTooManyRequests, "Too many requests")
 			return
 		}
 
@@ -61,8 +70,8 @@ func (m *RateLimiterMiddleware) CleanupVisitors() {
 	for {
 		time.Sleep(time.Minute)
 		m.mu.Lock()
-		for ip, limiter := range m.visitors {
-			if time.Since(limiter.LastEvent()) > time.Hour {
+		for ip, v := range m.visitors {
+			if time.Since(v.lastSeen) > time.Hour {
 				delete(m.visitors, ip)
 			}
 		}
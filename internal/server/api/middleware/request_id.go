@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"net/http"
+
+	"heisei/pkg/utils"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RequestIDHeader is the header carrying the request ID in both directions
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength bounds the length of request IDs accepted from clients
+const maxRequestIDLength = 64
+
+// RequestID assigns an ID to every request, reusing a well-formed ID sent by
+// the client, and echoes it in the response header
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get(RequestIDHeader)
+		if !isValidRequestID(requestID) {
+			requestID = utils.NewRequestID()
+		}
+
+		w.Header().Set(RequestIDHeader, requestID)
+		trace.SpanFromContext(r.Context()).SetAttributes(attribute.String("http.request_id", requestID))
+
+		ctx := utils.ContextWithRequestID(r.Context(), requestID)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// isValidRequestID accepts short IDs made of letters, digits, '-', '_' and '.'
+func isValidRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-', c == '_', c == '.':
+		default:
+			return false
+		}
+	}
+	return true
+}
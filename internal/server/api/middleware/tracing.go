@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// untracedPaths are probed frequently and would only add noise to traces
+var untracedPaths = map[string]bool{
+	"/healthz": true,
+	"/readyz":  true,
+}
+
+// Tracing starts a server span for every request, continuing the trace
+// propagated by the caller through the W3C traceparent header
+func Tracing(next http.Handler) http.Handler {
+	return otelhttp.NewHandler(next, "http.server",
+		otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+			return r.Method
+		}),
+		otelhttp.WithFilter(func(r *http.Request) bool {
+			return !untracedPaths[r.URL.Path]
+		}),
+	)
+}
+
+// RouteTracing names the current span after the matched route template.
+// It must be installed on the router so that the route is known.
+func RouteTracing(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if route := mux.CurrentRoute(r); route != nil {
+			if tmpl, err := route.GetPathTemplate(); err == nil {
+				span := trace.SpanFromContext(r.Context())
+				span.SetName(r.Method + " " + tmpl)
+				span.SetAttributes(attribute.String("http.route", tmpl))
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
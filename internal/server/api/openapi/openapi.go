@@ -0,0 +1,91 @@
+package openapi
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Spec is the hand-maintained OpenAPI 3 specification of the public API.
+// Keep it in sync with the handlers; the contract tests fail otherwise.
+//
+//go:embed openapi.json
+var Spec []byte
+
+// BasePath is the prefix of every versioned API route
+const BasePath = "/api/v1"
+
+// Operation identifies an operation by method and full path template
+type Operation struct {
+	Method string
+	Path   string
+}
+
+func (o Operation) String() string {
+	return o.Method + " " + o.Path
+}
+
+type document struct {
+	Servers []server            `json:"servers"`
+	Paths   map[string]pathItem `json:"paths"`
+}
+
+type server struct {
+	URL string `json:"url"`
+}
+
+type pathItem map[string]json.RawMessage
+
+// Operations lists the operations of the specification with the server URL
+// of each path prepended, sorted by path and method
+func Operations() ([]Operation, error) {
+	var doc document
+	if err := json.Unmarshal(Spec, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse OpenAPI spec: %w", err)
+	}
+
+	var ops []Operation
+	for p, item := range doc.Paths {
+		base := ""
+		if len(doc.Servers) > 0 {
+			base = doc.Servers[0].URL
+		}
+		if raw, ok := item["servers"]; ok {
+			var servers []server
+			if err := json.Unmarshal(raw, &servers); err != nil {
+				return nil, fmt.Errorf("failed to parse servers of %s: %w", p, err)
+			}
+			if len(servers) > 0 {
+				base = servers[0].URL
+			}
+		}
+
+		for method := range item {
+			switch method {
+			case "get", "put", "post", "delete", "patch", "head", "options":
+				ops = append(ops, Operation{
+					Method: strings.ToUpper(method),
+					Path:   path.Join(base, p),
+				})
+			}
+		}
+	}
+
+	sort.Slice(ops, func(i, j int) bool {
+		if ops[i].Path != ops[j].Path {
+			return ops[i].Path < ops[j].Path
+		}
+		return ops[i].Method < ops[j].Method
+	})
+	return ops, nil
+}
+
+// ServeSpec writes the specification as JSON
+func ServeSpec(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(Spec)
+}
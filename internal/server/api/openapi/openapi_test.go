@@ -0,0 +1,170 @@
+package openapi_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"heisei/internal/common/models"
+	"heisei/internal/server/api/handlers"
+	"heisei/internal/server/api/openapi"
+	"heisei/internal/server/repositories"
+	"heisei/internal/server/services"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+)
+
+func newRouter() *mux.Router {
+	logger := zap.NewNop()
+	repos := repositories.NewRepositories(nil)
+	return handlers.NewHandlers(services.NewServices(repos, logger), logger).SetupRoutes()
+}
+
+func routerOperations(t *testing.T, r *mux.Router) map[openapi.Operation]bool {
+	t.Helper()
+	ops := make(map[openapi.Operation]bool)
+	err := r.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		methods, err := route.GetMethods()
+		if err != nil {
+			// Subrouter prefixes have no methods
+			return nil
+		}
+		tmpl, err := route.GetPathTemplate()
+		if err != nil {
+			return err
+		}
+		for _, method := range methods {
+			ops[openapi.Operation{Method: method, Path: tmpl}] = true
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed to walk routes: %v", err)
+	}
+	return ops
+}
+
+func specOperations(t *testing.T) map[openapi.Operation]bool {
+	t.Helper()
+	list, err := openapi.Operations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ops := make(map[openapi.Operation]bool)
+	for _, op := range list {
+		ops[op] = true
+	}
+	return ops
+}
+
+func TestRoutesAreDocumented(t *testing.T) {
+	spec := specOperations(t)
+	for op := range routerOperations(t, newRouter()) {
+		if !spec[op] {
+			t.Errorf("route %s is registered but missing from openapi.json", op)
+		}
+	}
+}
+
+func TestDocumentedOperationsAreRouted(t *testing.T) {
+	routes := routerOperations(t, newRouter())
+	for op := range specOperations(t) {
+		if !routes[op] {
+			t.Errorf("operation %s is documented but not registered by any handler", op)
+		}
+	}
+}
+
+func TestVersionedPrefix(t *testing.T) {
+	for op := range routerOperations(t, newRouter()) {
+		if op.Path == "/healthz" || op.Path == "/readyz" {
+			continue
+		}
+		if !strings.HasPrefix(op.Path, openapi.BasePath+"/") {
+			t.Errorf("route %s is outside %s", op, openapi.BasePath)
+		}
+	}
+}
+
+func TestServeSpec(t *testing.T) {
+	rec := httptest.NewRecorder()
+	newRouter().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, openapi.BasePath+"/openapi.json", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", ct)
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("served spec is not valid JSON: %v", err)
+	}
+	if doc["openapi"] == nil {
+		t.Error("served document has no openapi version")
+	}
+}
+
+// TestSchemasMatchModels checks that the documented schema properties are the
+// JSON fields of the types the server encodes and decodes
+func TestSchemasMatchModels(t *testing.T) {
+	var doc struct {
+		Components struct {
+			Schemas map[string]struct {
+				Properties map[string]json.RawMessage `json:"properties"`
+			} `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(openapi.Spec, &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	types := map[string]interface{}{
+		"Category":            models.CategoryDTO{},
+		"Thread":              models.ThreadDTO{},
+		"Post":                models.PostDTO{},
+		"CategoryRequest":     models.CategoryRequest{},
+		"CreateThreadRequest": models.CreateThreadRequest{},
+		"UpdateThreadRequest": models.UpdateThreadRequest{},
+		"CreatePostRequest":   models.CreatePostRequest{},
+		"UpdatePostRequest":   models.UpdatePostRequest{},
+		"ErrorDetail":         models.ErrorDetail{},
+		"ErrorResponse":       models.ErrorResponse{},
+		"HealthCheckResult":   models.HealthCheckResult{},
+		"HealthResponse":      models.HealthResponse{},
+	}
+
+	for name, v := range types {
+		schema, ok := doc.Components.Schemas[name]
+		if !ok {
+			t.Errorf("schema %s is missing", name)
+			continue
+		}
+		var documented []string
+		for prop := range schema.Properties {
+			documented = append(documented, prop)
+		}
+		sort.Strings(documented)
+
+		if fields := jsonFields(reflect.TypeOf(v)); !reflect.DeepEqual(documented, fields) {
+			t.Errorf("schema %s has properties %v, but %T encodes %v", name, documented, v, fields)
+		}
+	}
+}
+
+func jsonFields(t reflect.Type) []string {
+	var fields []string
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.SplitN(t.Field(i).Tag.Get("json"), ",", 2)[0]
+		if name != "" && name != "-" {
+			fields = append(fields, name)
+		}
+	}
+	sort.Strings(fields)
+	return fields
+}
@@ -0,0 +1,138 @@
+package response
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"heisei/internal/common/models"
+	servermodels "heisei/internal/server/models"
+	"heisei/internal/server/repositories"
+	"heisei/pkg/utils"
+
+	"github.com/go-playground/validator/v10"
+	"go.uber.org/zap"
+)
+
+// statusByError maps repository errors to HTTP status codes
+var statusByError = map[error]int{
+	repositories.ErrCategoryNotFound: http.StatusNotFound,
+	repositories.ErrThreadNotFound:   http.StatusNotFound,
+	repositories.ErrPostNotFound:     http.StatusNotFound,
+	repositories.ErrBanNotFound:      http.StatusNotFound,
+	repositories.ErrCategoryExists:   http.StatusConflict,
+	repositories.ErrThreadExists:     http.StatusConflict,
+	repositories.ErrPostExists:       http.StatusConflict,
+}
+
+// StatusClientClosedRequest is logged for requests whose client disconnected
+// before the response was written, as nginx does
+const StatusClientClosedRequest = 499
+
+// errRequestTimeout is reported for requests that ran out of time
+var errRequestTimeout = models.NewAppError(models.ErrCodeServiceUnavailable, "Request timed out")
+
+// JSON writes v as a JSON response with the given status code
+func JSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if v != nil {
+		json.NewEncoder(w).Encode(v)
+	}
+}
+
+// Error maps err to a status code and writes it as an ErrorResponse.
+// Errors that are not known to be safe to expose are reported as internal errors.
+func Error(w http.ResponseWriter, r *http.Request, logger *zap.Logger, err error) {
+	// Queries fail with driver errors when their context ends, so the state of
+	// the request tells what happened
+	switch r.Context().Err() {
+	case context.Canceled:
+		logger.Info("Request canceled by client", append([]zap.Field{
+			zap.Error(err),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+		}, utils.RequestFields(r.Context())...)...)
+		w.WriteHeader(StatusClientClosedRequest)
+		return
+	case context.DeadlineExceeded:
+		err = fmt.Errorf("%w: %v", errRequestTimeout, err)
+	}
+
+	status, body := toErrorResponse(err, PreferredLanguage(r))
+	body.RequestID = utils.RequestIDFromContext(r.Context())
+
+	fields := append([]zap.Field{
+		zap.Error(err),
+		zap.Int("status", status),
+		zap.String("method", r.Method),
+		zap.String("path", r.URL.Path),
+	}, utils.RequestFields(r.Context())...)
+	if status >= http.StatusInternalServerError {
+		logger.Error("Request failed", fields...)
+	} else {
+		logger.Info("Request rejected", fields...)
+	}
+
+	JSON(w, status, body)
+}
+
+// ErrorStatus writes an ErrorResponse with the given status code and message
+func ErrorStatus(w http.ResponseWriter, r *http.Request, status int, message string) {
+	JSON(w, status, &models.ErrorResponse{
+		Code:      models.ErrorCodeForStatus(status),
+		Message:   message,
+		RequestID: utils.RequestIDFromContext(r.Context()),
+	})
+}
+
+func toErrorResponse(err error, lang string) (int, *models.ErrorResponse) {
+	var appErr *models.AppError
+	if errors.As(err, &appErr) {
+		return appErr.Code, &models.ErrorResponse{
+			Code:    models.ErrorCodeForStatus(appErr.Code),
+			Message: appErr.Message,
+			Details: appErr.Details,
+		}
+	}
+
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		return http.StatusUnprocessableEntity, &models.ErrorResponse{
+			Code:    models.ErrorCodeValidationFailed,
+			Message: "Validation failed",
+			Details: servermodels.TranslateValidationErrors(validationErrs, lang),
+		}
+	}
+
+	for target, status := range statusByError {
+		if errors.Is(err, target) {
+			return status, &models.ErrorResponse{
+				Code:    models.ErrorCodeForStatus(status),
+				Message: target.Error(),
+			}
+		}
+	}
+
+	return http.StatusInternalServerError, &models.ErrorResponse{
+		Code:    models.ErrorCodeInternal,
+		Message: "Internal server error",
+	}
+}
+
+// PreferredLanguage returns the first language in the Accept-Language header
+// that has translations, or the default language
+func PreferredLanguage(r *http.Request) string {
+	for _, part := range strings.Split(r.Header.Get("Accept-Language"), ",") {
+		tag := strings.TrimSpace(strings.SplitN(part, ";", 2)[0])
+		lang := strings.ToLower(strings.SplitN(tag, "-", 2)[0])
+		switch lang {
+		case "en", "ja":
+			return lang
+		}
+	}
+	return servermodels.DefaultLanguage
+}
@@ -0,0 +1,42 @@
+package response
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"heisei/internal/common/models"
+
+	"go.uber.org/zap"
+)
+
+func TestErrorOfEndedRequests(t *testing.T) {
+	queryErr := errors.New("canceling statement due to user request")
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	r := httptest.NewRequest(http.MethodGet, "/api/v1/categories", nil).WithContext(canceled)
+	w := httptest.NewRecorder()
+	Error(w, r, zap.NewNop(), queryErr)
+	if w.Code != StatusClientClosedRequest || w.Body.Len() != 0 {
+		t.Errorf("canceled request: status %d with body %q, want %d without body", w.Code, w.Body, StatusClientClosedRequest)
+	}
+
+	expired, cancel := context.WithTimeout(context.Background(), -time.Second)
+	defer cancel()
+	r = httptest.NewRequest(http.MethodGet, "/api/v1/categories", nil).WithContext(expired)
+	w = httptest.NewRecorder()
+	Error(w, r, zap.NewNop(), queryErr)
+	var body models.ErrorResponse
+	if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if w.Code != http.StatusServiceUnavailable || body.Code != models.ErrorCodeUnavailable {
+		t.Errorf("timed out request: status %d with code %q, want %d with %q",
+			w.Code, body.Code, http.StatusServiceUnavailable, models.ErrorCodeUnavailable)
+	}
+}
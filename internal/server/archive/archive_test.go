@@ -0,0 +1,142 @@
+package archive
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"heisei/internal/common/models"
+)
+
+func TestRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	records := []*Record{
+		{Type: TypeHeader, Header: &Header{Format: Format, Version: Version, ExportedAt: time.Now().UTC(), IPMode: IPMask}},
+		{Type: TypeCategory, Category: &models.CategoryDTO{ID: 1, Name: "News", Slug: "news"}},
+		{Type: TypeThread, Thread: &models.ThreadDTO{ID: 7, CategoryID: 1, Title: "Hello"}},
+		{Type: TypePost, Post: &Post{PostDTO: models.PostDTO{ID: 9, ThreadID: 7, Content: ">>1 <b>&"}, Deleted: true}},
+	}
+	for _, record := range records {
+		if err := w.Write(record); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !strings.Contains(buf.String(), "<b>&") {
+		t.Errorf("post content was escaped: %s", buf.String())
+	}
+	// Empty lines are ignored
+	buf.WriteString("\n")
+
+	r, err := NewReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Header().IPMode != IPMask {
+		t.Errorf("header IP mode = %q, want %q", r.Header().IPMode, IPMask)
+	}
+	var post *Post
+	for _, want := range records[1:] {
+		got, err := r.Read()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Type != want.Type {
+			t.Fatalf("record type = %q, want %q", got.Type, want.Type)
+		}
+		post = got.Post
+	}
+	if r.Line() != 4 {
+		t.Errorf("line = %d, want 4", r.Line())
+	}
+	if _, err := r.Read(); !errors.Is(err, io.EOF) {
+		t.Errorf("read after the last record = %v, want EOF", err)
+	}
+	if !post.Deleted || post.Content != ">>1 <b>&" {
+		t.Errorf("post = %+v", post)
+	}
+}
+
+func TestReaderRejectsInvalidArchives(t *testing.T) {
+	tests := []struct {
+		name    string
+		archive string
+		want    string
+	}{
+		{"empty", "", "empty archive"},
+		{"no header", `{"type":"category","category":{"id":1}}`, "no header"},
+		{"other format", `{"type":"header","header":{"format":"other","version":1}}`, "no header"},
+		{"newer version", `{"type":"header","header":{"format":"heisei-archive","version":2}}`, "unsupported archive version 2"},
+		{"invalid JSON", "{", "line 1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewReader(strings.NewReader(tt.archive))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+
+	archive := `{"type":"header","header":{"format":"heisei-archive","version":1}}
+{"type":"post"}
+`
+	r, err := NewReader(strings.NewReader(archive))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Read(); err == nil || !strings.Contains(err.Error(), "line 2: post record without post") {
+		t.Errorf("error = %v, want a record without data on line 2", err)
+	}
+}
+
+func TestIPModes(t *testing.T) {
+	tests := []struct {
+		mode IPMode
+		ip   string
+		want string
+	}{
+		{IPKeep, "192.0.2.33", "192.0.2.33"},
+		{IPMask, "192.0.2.33", "192.0.2.0"},
+		{IPMask, "192.0.2.33/32", "192.0.2.0"},
+		{IPMask, "::ffff:192.0.2.33", "192.0.2.0"},
+		{IPMask, "2001:db8:1:2::5", "2001:db8:1::"},
+		{IPMask, "invalid", ""},
+		{IPDrop, "192.0.2.33", ""},
+	}
+	for _, tt := range tests {
+		if got := tt.mode.Apply(tt.ip, "salt"); got != tt.want {
+			t.Errorf("%s(%q) = %q, want %q", tt.mode, tt.ip, got, tt.want)
+		}
+	}
+
+	hash := IPHash.Apply("192.0.2.33", "salt")
+	if !strings.HasPrefix(hash, "sha256:") || hash == IPHash.Apply("192.0.2.33", "other") {
+		t.Errorf("hash = %q, want a salted sha256 hash", hash)
+	}
+	if hash != IPHash.Apply("192.0.2.33", "salt") {
+		t.Error("hashes of the same address differ")
+	}
+
+	if _, err := ParseIPMode("scramble"); err == nil {
+		t.Error("unknown IP mode was accepted")
+	}
+}
+
+func TestImportIP(t *testing.T) {
+	tests := map[string]string{
+		"192.0.2.33":    "192.0.2.33",
+		"192.0.2.0":     "192.0.2.0",
+		"2001:db8::/48": "2001:db8::",
+		"sha256:abcd":   unknownIP,
+		"":              unknownIP,
+	}
+	for ip, want := range tests {
+		if got := importIP(ip); got != want {
+			t.Errorf("importIP(%q) = %q, want %q", ip, got, want)
+		}
+	}
+}
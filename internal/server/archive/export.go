@@ -0,0 +1,109 @@
+package archive
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"heisei/internal/server/models"
+
+	"gorm.io/gorm"
+)
+
+// threadBatchSize is the number of threads loaded at once during an export
+const threadBatchSize = 100
+
+// Stats counts the records of an export or import
+type Stats struct {
+	Categories int
+	Threads    int
+	Posts      int
+	// Skipped counts the records of an import that were already present
+	Skipped int
+}
+
+// ExportOptions configure Export
+type ExportOptions struct {
+	IPMode IPMode
+	// Salt is mixed into the hashes of IPHash
+	Salt string
+	// Categories limits the export to the categories with these slugs
+	Categories []string
+}
+
+// Export streams categories, threads and posts to w, including deleted posts
+func Export(ctx context.Context, db *gorm.DB, w io.Writer, opts ExportOptions) (*Stats, error) {
+	if opts.IPMode == "" {
+		opts.IPMode = IPKeep
+	}
+	db = db.WithContext(ctx)
+	aw := NewWriter(w)
+	stats := &Stats{}
+
+	header := &Header{Format: Format, Version: Version, ExportedAt: time.Now().UTC(), IPMode: opts.IPMode}
+	if err := aw.Write(&Record{Type: TypeHeader, Header: header}); err != nil {
+		return stats, err
+	}
+
+	// Sections come first, so that their boards can refer to them on import
+	var categories []models.Category
+	query := db.Order("parent_id IS NOT NULL, position, id")
+	if len(opts.Categories) > 0 {
+		query = query.Where("slug IN ?", opts.Categories)
+	}
+	if err := query.Find(&categories).Error; err != nil {
+		return stats, fmt.Errorf("failed to read categories: %w", err)
+	}
+	if len(opts.Categories) > 0 && len(categories) < len(opts.Categories) {
+		return stats, fmt.Errorf("found %d of %d categories", len(categories), len(opts.Categories))
+	}
+	categoryIDs := make([]uint, len(categories))
+	for i := range categories {
+		categoryIDs[i] = categories[i].ID
+		if err := aw.Write(&Record{Type: TypeCategory, Category: categories[i].ToDTO()}); err != nil {
+			return stats, err
+		}
+		stats.Categories++
+	}
+	if len(categoryIDs) == 0 {
+		return stats, nil
+	}
+
+	var threads []models.Thread
+	err := db.Where("category_id IN ?", categoryIDs).Order("id").
+		FindInBatches(&threads, threadBatchSize, func(tx *gorm.DB, batch int) error {
+			for i := range threads {
+				if err := exportThread(db, aw, &threads[i], opts, stats); err != nil {
+					return err
+				}
+			}
+			return nil
+		}).Error
+	if err != nil {
+		return stats, fmt.Errorf("failed to export threads: %w", err)
+	}
+	return stats, nil
+}
+
+// exportThread writes a thread followed by its posts
+func exportThread(db *gorm.DB, aw *Writer, thread *models.Thread, opts ExportOptions, stats *Stats) error {
+	if err := aw.Write(&Record{Type: TypeThread, Thread: thread.ToDTO()}); err != nil {
+		return err
+	}
+	stats.Threads++
+
+	var posts []models.Post
+	if err := db.Where("thread_id = ?", thread.ID).Order("number").Find(&posts).Error; err != nil {
+		return fmt.Errorf("failed to read posts of thread %d: %w", thread.ID, err)
+	}
+	for i := range posts {
+		post := Post{PostDTO: *posts[i].ToDTO(), Deleted: posts[i].IsDeleted}
+		post.AuthorIP = opts.IPMode.Apply(posts[i].AuthorIP, opts.Salt)
+		if err := aw.Write(&Record{Type: TypePost, Post: &post}); err != nil {
+			return err
+		}
+		stats.Posts++
+	}
+	return nil
+}
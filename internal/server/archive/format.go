@@ -0,0 +1,153 @@
+package archive
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"heisei/internal/common/models"
+)
+
+// Format identifies heisei archives in their header
+const Format = "heisei-archive"
+
+// Version is the version of the archive format written by Export. Import
+// reads archives up to this version.
+const Version = 1
+
+// Record types
+const (
+	TypeHeader   = "header"
+	TypeCategory = "category"
+	TypeThread   = "thread"
+	TypePost     = "post"
+)
+
+// Header is the first record of an archive
+type Header struct {
+	Format     string    `json:"format"`
+	Version    int       `json:"version"`
+	ExportedAt time.Time `json:"exported_at"`
+	// IPMode tells how the author IPs of the posts were written
+	IPMode IPMode `json:"ip_mode"`
+}
+
+// Post is a post with its deletion flag, which the API does not expose
+type Post struct {
+	models.PostDTO
+	Deleted bool `json:"deleted"`
+}
+
+// Record is a line of an archive. Categories come before their boards, and
+// each thread is followed by its posts.
+type Record struct {
+	Type     string              `json:"type"`
+	Header   *Header             `json:"header,omitempty"`
+	Category *models.CategoryDTO `json:"category,omitempty"`
+	Thread   *models.ThreadDTO   `json:"thread,omitempty"`
+	Post     *Post               `json:"post,omitempty"`
+}
+
+// Writer writes records as JSON Lines
+type Writer struct {
+	enc *json.Encoder
+}
+
+func NewWriter(w io.Writer) *Writer {
+	enc := json.NewEncoder(w)
+	// Posts are written as they are, e.g. with "<" in quotes
+	enc.SetEscapeHTML(false)
+	return &Writer{enc: enc}
+}
+
+// Write writes a record on a line of its own
+func (w *Writer) Write(record *Record) error {
+	return w.enc.Encode(record)
+}
+
+// Reader reads the records of an archive
+type Reader struct {
+	r      *bufio.Reader
+	line   int
+	header *Header
+}
+
+// NewReader reads and checks the header of an archive
+func NewReader(r io.Reader) (*Reader, error) {
+	ar := &Reader{r: bufio.NewReader(r)}
+	record, err := ar.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("empty archive")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if record.Type != TypeHeader || record.Header == nil || record.Header.Format != Format {
+		return nil, fmt.Errorf("not a %s: the first line is no header", Format)
+	}
+	if record.Header.Version < 1 || record.Header.Version > Version {
+		return nil, fmt.Errorf("unsupported archive version %d, expected at most %d", record.Header.Version, Version)
+	}
+	ar.header = record.Header
+	return ar, nil
+}
+
+// Header returns the header of the archive
+func (r *Reader) Header() *Header {
+	return r.header
+}
+
+// Line returns the number of the line of the last record read
+func (r *Reader) Line() int {
+	return r.line
+}
+
+// Read returns the next record, or io.EOF at the end of the archive.
+// Empty lines are skipped.
+func (r *Reader) Read() (*Record, error) {
+	for {
+		data, err := r.r.ReadBytes('\n')
+		if len(data) == 0 && err != nil {
+			return nil, err
+		}
+		r.line++
+		data = bytes.TrimSpace(data)
+		if len(data) == 0 {
+			continue
+		}
+
+		var record Record
+		if err := json.Unmarshal(data, &record); err != nil {
+			return nil, fmt.Errorf("line %d: %w", r.line, err)
+		}
+		if err := record.check(); err != nil {
+			return nil, fmt.Errorf("line %d: %w", r.line, err)
+		}
+		return &record, nil
+	}
+}
+
+// check makes sure that the record carries the data of its type
+func (r *Record) check() error {
+	var ok bool
+	switch r.Type {
+	case TypeHeader:
+		ok = r.Header != nil
+	case TypeCategory:
+		ok = r.Category != nil
+	case TypeThread:
+		ok = r.Thread != nil
+	case TypePost:
+		ok = r.Post != nil
+	default:
+		return fmt.Errorf("unknown record type %q", r.Type)
+	}
+	if !ok {
+		return fmt.Errorf("%s record without %s", r.Type, r.Type)
+	}
+	return nil
+}
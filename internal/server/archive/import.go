@@ -0,0 +1,194 @@
+package archive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	common "heisei/internal/common/models"
+	"heisei/internal/server/models"
+
+	"gorm.io/gorm"
+)
+
+// importer restores an archive, remembering the database IDs of the
+// archived categories and threads
+type importer struct {
+	db         *gorm.DB
+	stats      *Stats
+	categories map[uint]uint
+	// thread is the thread being read, imported once all its posts are read
+	thread *common.ThreadDTO
+	posts  []Post
+}
+
+// Import restores the archive read from r. Archive IDs are mapped to new
+// database IDs. Records already in the database are skipped, so an import
+// can be run again, e.g. after it failed halfway: categories are matched by
+// slug, threads by category, title and creation time, and posts by thread,
+// creation time and content. Each thread is imported with its posts in a
+// transaction.
+func Import(ctx context.Context, db *gorm.DB, r io.Reader) (*Stats, error) {
+	ar, err := NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	im := &importer{
+		db:         db.WithContext(ctx),
+		stats:      &Stats{},
+		categories: make(map[uint]uint),
+	}
+
+	for {
+		record, err := ar.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return im.stats, err
+		}
+		if err := im.add(record); err != nil {
+			return im.stats, fmt.Errorf("line %d: %w", ar.Line(), err)
+		}
+	}
+	if err := im.flushThread(); err != nil {
+		return im.stats, err
+	}
+	return im.stats, nil
+}
+
+func (im *importer) add(record *Record) error {
+	if record.Type != TypePost {
+		if err := im.flushThread(); err != nil {
+			return err
+		}
+	}
+
+	switch record.Type {
+	case TypeCategory:
+		return im.importCategory(record.Category)
+	case TypeThread:
+		im.thread = record.Thread
+		im.posts = nil
+	case TypePost:
+		if im.thread == nil || record.Post.ThreadID != im.thread.ID {
+			return fmt.Errorf("post %d does not follow its thread %d", record.Post.ID, record.Post.ThreadID)
+		}
+		im.posts = append(im.posts, *record.Post)
+	case TypeHeader:
+		return fmt.Errorf("unexpected header")
+	}
+	return nil
+}
+
+func (im *importer) importCategory(dto *common.CategoryDTO) error {
+	var existing models.Category
+	err := im.db.Where("slug = ?", dto.Slug).First(&existing).Error
+	if err == nil {
+		im.categories[dto.ID] = existing.ID
+		im.stats.Skipped++
+		return nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("failed to look up category %q: %w", dto.Slug, err)
+	}
+
+	category := models.CategoryFromDTO(dto)
+	category.ID = 0
+	// Sections missing from the archive leave their boards at the top level
+	category.ParentID = nil
+	if dto.ParentID != nil {
+		if parentID, ok := im.categories[*dto.ParentID]; ok {
+			category.ParentID = &parentID
+		}
+	}
+	if category.DefaultSort == "" {
+		category.DefaultSort = common.ThreadSortBump
+	}
+	if err := im.db.Create(category).Error; err != nil {
+		return fmt.Errorf("failed to create category %q: %w", dto.Slug, err)
+	}
+	im.categories[dto.ID] = category.ID
+	im.stats.Categories++
+	return nil
+}
+
+// flushThread imports the thread read last with its posts
+func (im *importer) flushThread() error {
+	if im.thread == nil {
+		return nil
+	}
+	dto, posts := im.thread, im.posts
+	im.thread, im.posts = nil, nil
+
+	categoryID, ok := im.categories[dto.CategoryID]
+	if !ok {
+		return fmt.Errorf("thread %d belongs to category %d, which is not in the archive", dto.ID, dto.CategoryID)
+	}
+	return im.db.Transaction(func(tx *gorm.DB) error {
+		var thread models.Thread
+		err := tx.Where("category_id = ? AND title = ? AND created_at = ?", categoryID, dto.Title, dto.CreatedAt).First(&thread).Error
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			// The counters are set by the triggers of the posts table as the
+			// posts are inserted
+			thread = *models.ThreadFromDTO(dto)
+			thread.ID = 0
+			thread.CategoryID = categoryID
+			thread.PostCount = 0
+			thread.LastPostAt = thread.CreatedAt
+			thread.BumpedAt = thread.CreatedAt
+			if err := tx.Create(&thread).Error; err != nil {
+				return fmt.Errorf("failed to create thread %d: %w", dto.ID, err)
+			}
+			im.stats.Threads++
+		case err != nil:
+			return fmt.Errorf("failed to look up thread %d: %w", dto.ID, err)
+		default:
+			im.stats.Skipped++
+		}
+
+		if err := im.importPosts(tx, thread.ID, posts); err != nil {
+			return fmt.Errorf("thread %d: %w", dto.ID, err)
+		}
+		return nil
+	})
+}
+
+// importPosts adds the posts missing from a thread, in the order of the archive
+func (im *importer) importPosts(tx *gorm.DB, threadID uint, posts []Post) error {
+	var existing []models.Post
+	if err := tx.Select("created_at", "content").Where("thread_id = ?", threadID).Find(&existing).Error; err != nil {
+		return fmt.Errorf("failed to read posts: %w", err)
+	}
+	seen := make(map[string]bool, len(existing))
+	for _, post := range existing {
+		seen[postKey(post.CreatedAt, post.Content)] = true
+	}
+
+	for _, archived := range posts {
+		if seen[postKey(archived.CreatedAt, archived.Content)] {
+			im.stats.Skipped++
+			continue
+		}
+		post := models.Post{
+			BaseModel: models.BaseModel{CreatedAt: archived.CreatedAt},
+			ThreadID:  threadID,
+			Content:   archived.Content,
+			AuthorIP:  importIP(archived.AuthorIP),
+			Sage:      archived.Sage,
+			IsDeleted: archived.Deleted,
+		}
+		if err := tx.Create(&post).Error; err != nil {
+			return fmt.Errorf("failed to create post %d: %w", archived.ID, err)
+		}
+		im.stats.Posts++
+	}
+	return nil
+}
+
+func postKey(createdAt time.Time, content string) string {
+	return createdAt.UTC().Format(time.RFC3339Nano) + "\x00" + content
+}
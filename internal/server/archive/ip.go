@@ -0,0 +1,93 @@
+package archive
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/netip"
+	"strings"
+)
+
+// IPMode tells how author IPs are written to an archive
+type IPMode string
+
+const (
+	// IPKeep writes the addresses as they are
+	IPKeep IPMode = "keep"
+	// IPMask keeps the network of an address: /24 for IPv4 and /48 for IPv6
+	IPMask IPMode = "mask"
+	// IPHash replaces an address by a salted hash, which still tells the
+	// posts of an author apart but cannot be imported as an address
+	IPHash IPMode = "hash"
+	// IPDrop leaves the addresses out
+	IPDrop IPMode = "drop"
+)
+
+// IPModes lists the IP modes
+var IPModes = []IPMode{IPKeep, IPMask, IPHash, IPDrop}
+
+// unknownIP is stored for posts imported without a usable address
+const unknownIP = "0.0.0.0"
+
+// ParseIPMode returns the IP mode named s
+func ParseIPMode(s string) (IPMode, error) {
+	for _, mode := range IPModes {
+		if string(mode) == s {
+			return mode, nil
+		}
+	}
+	names := make([]string, len(IPModes))
+	for i, mode := range IPModes {
+		names[i] = string(mode)
+	}
+	return "", fmt.Errorf("unknown IP mode %q, expected one of %s", s, strings.Join(names, ", "))
+}
+
+// Apply returns ip as written in this mode. Hashes mix in salt, so that they
+// cannot be reversed by hashing all addresses.
+func (m IPMode) Apply(ip, salt string) string {
+	switch m {
+	case IPMask:
+		addr, ok := parseIP(ip)
+		if !ok {
+			return ""
+		}
+		bits := 48
+		if addr.Is4() {
+			bits = 24
+		}
+		prefix, _ := addr.Prefix(bits)
+		return prefix.Addr().String()
+	case IPHash:
+		if ip == "" {
+			return ""
+		}
+		sum := sha256.Sum256([]byte(salt + ip))
+		return "sha256:" + hex.EncodeToString(sum[:])
+	case IPDrop:
+		return ""
+	}
+	return ip
+}
+
+// importIP returns the address stored for an archived IP; hashed and dropped
+// addresses become unknownIP
+func importIP(ip string) string {
+	addr, ok := parseIP(ip)
+	if !ok {
+		return unknownIP
+	}
+	return addr.String()
+}
+
+// parseIP reads an address as Postgres prints inet values, with or without
+// a prefix length
+func parseIP(ip string) (netip.Addr, bool) {
+	if addr, err := netip.ParseAddr(ip); err == nil {
+		return addr.Unmap(), true
+	}
+	if prefix, err := netip.ParsePrefix(ip); err == nil {
+		return prefix.Addr().Unmap(), true
+	}
+	return netip.Addr{}, false
+}
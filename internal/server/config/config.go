@@ -4,6 +4,9 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"time"
+
+	"heisei/pkg/tracing"
 
 	"gopkg.in/yaml.v2"
 )
@@ -12,12 +15,16 @@ type Config struct {
 	Server   ServerConfig   `yaml:"server"`
 	Database DatabaseConfig `yaml:"database"`
 	Log      LogConfig      `yaml:"log"`
+	Tracing  tracing.Config `yaml:"tracing"`
 }
 
 type ServerConfig struct {
 	Host      string `yaml:"host"`
 	Port      int    `yaml:"port"`
 	DebugMode bool   `yaml:"debug_mode"`
+	// ShutdownDelay is how long the server keeps serving with failing readiness
+	// checks after receiving a termination signal, before it stops accepting connections
+	ShutdownDelay time.Duration `yaml:"shutdown_delay"`
 }
 
 type DatabaseConfig struct {
@@ -26,6 +33,12 @@ type DatabaseConfig struct {
 	User     string `yaml:"user"`
 	Password string `yaml:"password"`
 	DBName   string `yaml:"name"`
+	// QueryTimeout bounds the time the database queries of a request may
+	// take together; 0 means no limit
+	QueryTimeout time.Duration `yaml:"query_timeout"`
+	// ReconcileInterval is how often the thread counters are checked against
+	// the posts and corrected; 0 disables the check
+	ReconcileInterval time.Duration `yaml:"reconcile_interval"`
 }
 
 type LogConfig struct {
@@ -71,6 +84,11 @@ func (c *Config) overrideWithEnv() {
 	if debugMode := os.Getenv("DEBUG_MODE"); debugMode != "" {
 		c.Server.DebugMode = debugMode == "true"
 	}
+	if shutdownDelay := os.Getenv("SERVER_SHUTDOWN_DELAY"); shutdownDelay != "" {
+		if d, err := time.ParseDuration(shutdownDelay); err == nil {
+			c.Server.ShutdownDelay = d
+		}
+	}
 	if dbHost := os.Getenv("DB_HOST"); dbHost != "" {
 		c.Database.Host = dbHost
 	}
@@ -88,15 +106,29 @@ func (c *Config) overrideWithEnv() {
 	if dbName := os.Getenv("DB_NAME"); dbName != "" {
 		c.Database.DBName = dbName
 	}
+	if queryTimeout := os.Getenv("DB_QUERY_TIMEOUT"); queryTimeout != "" {
+		if d, err := time.ParseDuration(queryTimeout); err == nil {
+			c.Database.QueryTimeout = d
+		}
+	}
+	if reconcileInterval := os.Getenv("DB_RECONCILE_INTERVAL"); reconcileInterval != "" {
+		if d, err := time.ParseDuration(reconcileInterval); err == nil {
+			c.Database.ReconcileInterval = d
+		}
+	}
 	if logLevel := os.Getenv("LOG_LEVEL"); logLevel != "" {
 		c.Log.Level = logLevel
 	}
+	c.Tracing.OverrideWithEnv()
 }
 
 func (c *Config) validate() error {
 	if c.Server.Port <= 0 || c.Server.Port > 65535 {
 		return fmt.Errorf("invalid server port: %d", c.Server.Port)
 	}
+	if c.Server.ShutdownDelay < 0 {
+		return fmt.Errorf("invalid server shutdown delay: %v", c.Server.ShutdownDelay)
+	}
 	if c.Database.Port <= 0 || c.Database.Port > 65535 {
 		return fmt.Errorf("invalid database port: %d", c.Database.Port)
 	}
@@ -109,6 +141,15 @@ func (c *Config) validate() error {
 	if c.Database.DBName == "" {
 		return fmt.Errorf("database name is required")
 	}
+	if c.Database.QueryTimeout < 0 {
+		return fmt.Errorf("invalid database query timeout: %v", c.Database.QueryTimeout)
+	}
+	if c.Database.ReconcileInterval < 0 {
+		return fmt.Errorf("invalid database reconcile interval: %v", c.Database.ReconcileInterval)
+	}
+	if err := c.Tracing.Validate(); err != nil {
+		return err
+	}
 	return nil
 }
 
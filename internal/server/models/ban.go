@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// Ban keeps the addresses of a network from posting, until it expires
+type Ban struct {
+	ID uint `gorm:"primaryKey;autoIncrement" json:"id"`
+	// Network is an address range in CIDR notation; single addresses are
+	// stored as /32 or /128
+	Network   string     `gorm:"type:cidr;not null" json:"network" validate:"required,cidr"`
+	Reason    string     `gorm:"size:500;not null" json:"reason" validate:"max=500"`
+	ExpiresAt *time.Time `json:"expires_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+func (Ban) TableName() string {
+	return "bans"
+}
+
+// Validate validates the ban.
+func (b *Ban) Validate() error {
+	return ValidateStruct(b)
+}
+
+// IsActive reports whether the ban is in effect at the given time.
+func (b *Ban) IsActive(now time.Time) bool {
+	return b.ExpiresAt == nil || b.ExpiresAt.After(now)
+}
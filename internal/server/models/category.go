@@ -1,41 +1,58 @@
 package models
 
 import (
+	common "heisei/internal/common/models"
+
 	"gorm.io/gorm"
 )
 
+// Category is a board of threads. Top-level categories with children are
+// sections grouping boards; the hierarchy is one level deep. MaxPosts of 0
+// means no limit, so it has no GORM default that would replace the 0.
 type Category struct {
 	BaseModel
-	Name    string   `gorm:"size:50;not null;index" json:"name" validate:"required,max=50"`
-	Slug    string   `gorm:"size:50;not null;uniqueIndex" json:"slug" validate:"required,max=50,alphanum"`
-	Threads []Thread `gorm:"foreignKey:CategoryID;constraint:OnDelete:CASCADE" json:"threads,omitempty"`
+	ParentID    *uint    `gorm:"index:idx_categories_parent_position,priority:1" json:"parent_id"`
+	Position    int      `gorm:"not null;default:0;index:idx_categories_parent_position,priority:2" json:"position"`
+	Name        string   `gorm:"size:50;not null;index" json:"name" validate:"required,max=50"`
+	Slug        string   `gorm:"size:50;not null;uniqueIndex" json:"slug" validate:"required,max=50,slug"`
+	Description string   `gorm:"size:500;not null;default:''" json:"description" validate:"max=500"`
+	DefaultSort string   `gorm:"size:20;not null;default:bump" json:"default_sort" validate:"oneof=bump created post_count activity_24h"`
+	MaxPosts    int      `gorm:"not null" json:"max_posts" validate:"min=0"`
+	ReadOnly    bool     `gorm:"not null;default:false" json:"read_only"`
+	Threads     []Thread `gorm:"foreignKey:CategoryID;constraint:OnDelete:CASCADE" json:"threads,omitempty"`
 }
 
 func (Category) TableName() string {
 	return "categories"
 }
 
-type CategoryDTO struct {
-	ID   uint   `json:"id"`
-	Name string `json:"name"`
-	Slug string `json:"slug"`
-}
-
 // ToDTO converts the category model to a category DTO.
-func (c *Category) ToDTO() *CategoryDTO {
-	return &CategoryDTO{
-		ID:   c.ID,
-		Name: c.Name,
-		Slug: c.Slug,
+func (c *Category) ToDTO() *common.CategoryDTO {
+	return &common.CategoryDTO{
+		ID:          c.ID,
+		ParentID:    c.ParentID,
+		Position:    c.Position,
+		Name:        c.Name,
+		Slug:        c.Slug,
+		Description: c.Description,
+		DefaultSort: c.DefaultSort,
+		MaxPosts:    c.MaxPosts,
+		ReadOnly:    c.ReadOnly,
 	}
 }
 
-// ToModel converts the category DTO to a category model.
-func (dto *CategoryDTO) ToModel() *Category {
+// CategoryFromDTO converts a category DTO to a category model.
+func CategoryFromDTO(dto *common.CategoryDTO) *Category {
 	return &Category{
-		BaseModel: BaseModel{ID: dto.ID},
-		Name:      dto.Name,
-		Slug:      dto.Slug,
+		BaseModel:   BaseModel{ID: dto.ID},
+		ParentID:    dto.ParentID,
+		Position:    dto.Position,
+		Name:        dto.Name,
+		Slug:        dto.Slug,
+		Description: dto.Description,
+		DefaultSort: dto.DefaultSort,
+		MaxPosts:    dto.MaxPosts,
+		ReadOnly:    dto.ReadOnly,
 	}
 }
 
@@ -44,6 +61,11 @@ func (c *Category) Validate() error {
 	return ValidateStruct(c)
 }
 
+// IsFull reports whether a thread with postCount posts reached the post limit
+func (c *Category) IsFull(postCount int) bool {
+	return c.MaxPosts > 0 && postCount >= c.MaxPosts
+}
+
 // IsEmpty checks if the category has no threads.
 func (c *Category) IsEmpty(db *gorm.DB) (bool, error) {
 	var count int64
@@ -24,11 +24,12 @@ type SoftDelete interface {
 }
 
 // BaseModel is the base model that includes the common fields for all models.
+// It has no gorm.DeletedAt: deleting categories and threads removes them, and
+// posts are soft deleted with their is_deleted flag.
 type BaseModel struct {
-	ID        uint           `gorm:"primaryKey;autoIncrement" json:"id"`
-	CreatedAt time.Time      `gorm:"index" json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+	ID        uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
 }
 
 // Validate is the interface that wraps the validation method.
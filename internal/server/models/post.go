@@ -1,51 +1,51 @@
 package models
 
 import (
-	"gorm.io/gorm"
 	"strings"
-	"time"
+
+	common "heisei/internal/common/models"
+
+	"gorm.io/gorm"
 )
 
 type Post struct {
 	BaseModel
 	ThreadID  uint   `gorm:"not null;index" json:"thread_id" validate:"required"`
+	Number    int    `gorm:"not null;default:null" json:"number"` // set by a trigger on insert
 	Content   string `gorm:"type:text;not null" json:"content" validate:"required,min=1,max=10000"`
 	AuthorIP  string `gorm:"type:inet;not null" json:"author_ip" validate:"required,ip"`
+	Sage      bool   `gorm:"not null;default:false" json:"sage"`
 	IsDeleted bool   `gorm:"not null;default:false;index" json:"is_deleted"`
-	Thread    Thread `gorm:"foreignKey:ThreadID;constraint:OnDelete:CASCADE" json:"thread,omitempty"`
+	Thread    Thread `gorm:"foreignKey:ThreadID;constraint:OnDelete:CASCADE" json:"thread,omitempty" validate:"-"`
 }
 
 func (Post) TableName() string {
 	return "posts"
 }
 
-type PostDTO struct {
-	ID        uint      `json:"id"`
-	ThreadID  uint      `json:"thread_id"`
-	Content   string    `json:"content"`
-	CreatedAt time.Time `json:"created_at"`
-	IsDeleted bool      `json:"is_deleted"`
-}
-
-func (p *Post) ToDTO() *PostDTO {
-	return &PostDTO{
+// ToDTO converts the post model to a post DTO.
+func (p *Post) ToDTO() *common.PostDTO {
+	return &common.PostDTO{
 		ID:        p.ID,
 		ThreadID:  p.ThreadID,
+		Number:    p.Number,
 		Content:   p.Content,
 		CreatedAt: p.CreatedAt,
-		IsDeleted: p.IsDeleted,
+		Sage:      p.Sage,
 	}
 }
 
-func (dto *PostDTO) ToModel() *Post {
+// PostFromDTO converts a post DTO to a post model.
+func PostFromDTO(dto *common.PostDTO) *Post {
 	return &Post{
 		BaseModel: BaseModel{
 			ID:        dto.ID,
 			CreatedAt: dto.CreatedAt,
 		},
-		ThreadID:  dto.ThreadID,
-		Content:   dto.Content,
-		IsDeleted: dto.IsDeleted,
+		ThreadID: dto.ThreadID,
+		Content:  dto.Content,
+		AuthorIP: dto.AuthorIP,
+		Sage:     dto.Sage,
 	}
 }
 
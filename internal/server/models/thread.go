@@ -1,8 +1,11 @@
 package models
 
 import (
-	"gorm.io/gorm"
 	"time"
+
+	common "heisei/internal/common/models"
+
+	"gorm.io/gorm"
 )
 
 type Thread struct {
@@ -10,8 +13,10 @@ type Thread struct {
 	CategoryID uint      `gorm:"not null;index" json:"category_id" validate:"required"`
 	Title      string    `gorm:"size:200;not null;index" json:"title" validate:"required,max=200"`
 	LastPostAt time.Time `gorm:"not null;index" json:"last_post_at"`
+	BumpedAt   time.Time `gorm:"not null;index" json:"bumped_at"`
 	PostCount  int       `gorm:"not null;default:0" json:"post_count" validate:"min=0"`
-	Category   Category  `gorm:"foreignKey:CategoryID;constraint:OnDelete:CASCADE" json:"category,omitempty"`
+	Locked     bool      `gorm:"not null;default:false" json:"locked"`
+	Category   Category  `gorm:"foreignKey:CategoryID;constraint:OnDelete:CASCADE" json:"category,omitempty" validate:"-"`
 	Posts      []Post    `gorm:"foreignKey:ThreadID;constraint:OnDelete:CASCADE" json:"posts,omitempty"`
 }
 
@@ -19,29 +24,23 @@ func (Thread) TableName() string {
 	return "threads"
 }
 
-type ThreadDTO struct {
-	ID         uint      `json:"id"`
-	CategoryID uint      `json:"category_id"`
-	Title      string    `json:"title"`
-	CreatedAt  time.Time `json:"created_at"`
-	LastPostAt time.Time `json:"last_post_at"`
-	PostCount  int       `json:"post_count"`
-}
-
 // ToDTO converts the thread model to a thread DTO.
-func (t *Thread) ToDTO() *ThreadDTO {
-	return &ThreadDTO{
+func (t *Thread) ToDTO() *common.ThreadDTO {
+	return &common.ThreadDTO{
 		ID:         t.ID,
 		CategoryID: t.CategoryID,
 		Title:      t.Title,
 		CreatedAt:  t.CreatedAt,
+		UpdatedAt:  t.UpdatedAt,
 		LastPostAt: t.LastPostAt,
+		BumpedAt:   t.BumpedAt,
 		PostCount:  t.PostCount,
+		Locked:     t.Locked,
 	}
 }
 
-// ToModel converts the thread DTO to a thread model.
-func (dto *ThreadDTO) ToModel() *Thread {
+// ThreadFromDTO converts a thread DTO to a thread model.
+func ThreadFromDTO(dto *common.ThreadDTO) *Thread {
 	return &Thread{
 		BaseModel: BaseModel{
 			ID:        dto.ID,
@@ -50,7 +49,9 @@ func (dto *ThreadDTO) ToModel() *Thread {
 		CategoryID: dto.CategoryID,
 		Title:      dto.Title,
 		LastPostAt: dto.LastPostAt,
+		BumpedAt:   dto.BumpedAt,
 		PostCount:  dto.PostCount,
+		Locked:     dto.Locked,
 	}
 }
 
@@ -59,16 +60,6 @@ func (t *Thread) Validate() error {
 	return ValidateStruct(t)
 }
 
-// IncrementPostCount increments the post count of the thread.
-func (t *Thread) IncrementPostCount(db *gorm.DB) error {
-	return db.Model(t).Update("post_count", gorm.Expr("post_count + ?", 1)).Error
-}
-
-// UpdateLastPostAt updates the last post time of the thread.
-func (t *Thread) UpdateLastPostAt(db *gorm.DB) error {
-	return db.Model(t).Update("last_post_at", time.Now()).Error
-}
-
 // GetLatestPosts returns the latest posts of the thread.
 func (t *Thread) GetLatestPosts(db *gorm.DB, n int) ([]Post, error) {
 	var posts []Post
@@ -1,15 +1,102 @@
 package models
 
-import "github.com/go-playground/validator/v10"
+import (
+	"reflect"
+	"regexp"
+	"strings"
 
-var validate *validator.Validate
+	common "heisei/internal/common/models"
+
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/ja"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	enTranslations "github.com/go-playground/validator/v10/translations/en"
+	jaTranslations "github.com/go-playground/validator/v10/translations/ja"
+)
+
+// DefaultLanguage is used when the requested language has no translations
+const DefaultLanguage = "en"
+
+var (
+	validate   *validator.Validate
+	translator *ut.UniversalTranslator
+	slugRegexp = regexp.MustCompile(`^[a-z0-9]+(?:-[a-z0-9]+)*$`)
+)
 
 // init initializes the validator.
 func init() {
 	validate = validator.New()
+
+	// Report JSON field names so that errors match the request body
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		if name == "" {
+			return field.Name
+		}
+		return name
+	})
+
+	validate.RegisterValidation("slug", func(fl validator.FieldLevel) bool {
+		return slugRegexp.MatchString(fl.Field().String())
+	})
+
+	enLocale := en.New()
+	translator = ut.New(enLocale, enLocale, ja.New())
+	registerTranslations("en", enTranslations.RegisterDefaultTranslations, "{0} must contain only lowercase letters, digits and hyphens")
+	registerTranslations("ja", jaTranslations.RegisterDefaultTranslations, "{0}は英小文字、数字、ハイフンのみ使用できます")
+}
+
+func registerTranslations(lang string, register func(*validator.Validate, ut.Translator) error, slugMessage string) {
+	trans, _ := translator.GetTranslator(lang)
+	if err := register(validate, trans); err != nil {
+		panic(err)
+	}
+	err := validate.RegisterTranslation("slug", trans,
+		func(ut ut.Translator) error {
+			return ut.Add("slug", slugMessage, true)
+		},
+		func(ut ut.Translator, fe validator.FieldError) string {
+			msg, _ := ut.T("slug", fe.Field())
+			return msg
+		},
+	)
+	if err != nil {
+		panic(err)
+	}
 }
 
 // ValidateStruct validates a struct.
 func ValidateStruct(s interface{}) error {
 	return validate.Struct(s)
 }
+
+// TranslateValidationErrors converts validation errors into per-field messages
+// in the given language, falling back to English for unsupported languages.
+func TranslateValidationErrors(errs validator.ValidationErrors, lang string) []common.ErrorDetail {
+	trans, found := translator.GetTranslator(lang)
+	if !found {
+		trans, _ = translator.GetTranslator(DefaultLanguage)
+	}
+
+	details := make([]common.ErrorDetail, len(errs))
+	for i, fe := range errs {
+		details[i] = common.ErrorDetail{
+			Field:   fieldPath(fe),
+			Message: fe.Translate(trans),
+		}
+	}
+	return details
+}
+
+// fieldPath returns the JSON path of the field without the top-level struct name
+func fieldPath(fe validator.FieldError) string {
+	ns := fe.Namespace()
+	if i := strings.Index(ns, "."); i >= 0 {
+		return ns[i+1:]
+	}
+	return ns
+}
@@ -0,0 +1,73 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"heisei/internal/server/models"
+
+	"gorm.io/gorm"
+)
+
+var ErrBanNotFound = errors.New("ban not found")
+
+type BanRepository struct {
+	db *gorm.DB
+}
+
+func NewBanRepository(db *gorm.DB) *BanRepository {
+	return &BanRepository{db: db}
+}
+
+// Create adds a new ban to the database
+func (r *BanRepository) Create(ctx context.Context, ban *models.Ban) error {
+	result := r.db.WithContext(ctx).Create(ban)
+	if result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
+
+// GetAll retrieves the bans, newest first, including expired ones if asked to
+func (r *BanRepository) GetAll(ctx context.Context, expired bool) ([]models.Ban, error) {
+	var bans []models.Ban
+	db := r.db.WithContext(ctx)
+	if !expired {
+		db = db.Where("expires_at IS NULL OR expires_at > CURRENT_TIMESTAMP")
+	}
+	result := db.Order("created_at DESC, id DESC").Find(&bans)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return bans, nil
+}
+
+// Delete removes a ban by its ID
+func (r *BanRepository) Delete(ctx context.Context, id uint) error {
+	result := r.db.WithContext(ctx).Delete(&models.Ban{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrBanNotFound
+	}
+	return nil
+}
+
+// DeleteExpired removes the expired bans and returns their number
+func (r *BanRepository) DeleteExpired(ctx context.Context) (int64, error) {
+	result := r.db.WithContext(ctx).Where("expires_at <= CURRENT_TIMESTAMP").Delete(&models.Ban{})
+	return result.RowsAffected, result.Error
+}
+
+// IsBanned reports whether an active ban covers the given address
+func (r *BanRepository) IsBanned(ctx context.Context, ip string) (bool, error) {
+	var count int64
+	result := r.db.WithContext(ctx).Model(&models.Ban{}).
+		Where("network >>= CAST(? AS inet)", ip).
+		Where("expires_at IS NULL OR expires_at > CURRENT_TIMESTAMP").
+		Count(&count)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return count > 0, nil
+}
@@ -4,8 +4,10 @@ import (
 	"context"
 	"errors"
 	"heisei/internal/server/models"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 var (
@@ -47,10 +49,34 @@ func (r *CategoryRepository) GetByID(ctx context.Context, id uint) (*models.Cate
 	return &category, nil
 }
 
-// GetAll retrieves all categories
+// GetByIDForShare retrieves a category by its ID and keeps it from being
+// changed or deleted until the end of the transaction
+func (r *CategoryRepository) GetByIDForShare(ctx context.Context, id uint) (*models.Category, error) {
+	return r.getLocked(ctx, id, "SHARE")
+}
+
+// GetByIDForUpdate retrieves a category by its ID and locks it until the end
+// of the transaction
+func (r *CategoryRepository) GetByIDForUpdate(ctx context.Context, id uint) (*models.Category, error) {
+	return r.getLocked(ctx, id, "UPDATE")
+}
+
+func (r *CategoryRepository) getLocked(ctx context.Context, id uint, strength string) (*models.Category, error) {
+	var category models.Category
+	result := r.db.WithContext(ctx).Clauses(clause.Locking{Strength: strength}).First(&category, id)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, ErrCategoryNotFound
+		}
+		return nil, result.Error
+	}
+	return &category, nil
+}
+
+// GetAll retrieves all categories ordered by position
 func (r *CategoryRepository) GetAll(ctx context.Context) ([]models.Category, error) {
 	var categories []models.Category
-	result := r.db.WithContext(ctx).Find(&categories)
+	result := r.db.WithContext(ctx).Order("position, id").Find(&categories)
 	if result.Error != nil {
 		return nil, result.Error
 	}
@@ -61,6 +87,9 @@ func (r *CategoryRepository) GetAll(ctx context.Context) ([]models.Category, err
 func (r *CategoryRepository) Update(ctx context.Context, category *models.Category) error {
 	result := r.db.WithContext(ctx).Save(category)
 	if result.Error != nil {
+		if r.db.WithContext(ctx).Where("slug = ? AND id <> ?", category.Slug, category.ID).First(&models.Category{}).Error == nil {
+			return ErrCategoryExists
+		}
 		return result.Error
 	}
 	if result.RowsAffected == 0 {
@@ -81,6 +110,62 @@ func (r *CategoryRepository) Delete(ctx context.Context, id uint) error {
 	return nil
 }
 
+// HasChildren reports whether other categories are placed in a category
+func (r *CategoryRepository) HasChildren(ctx context.Context, id uint) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.Category{}).Where("parent_id = ?", id).Count(&count).Error
+	return count > 0, err
+}
+
+// GetStats returns the number of threads of a category and the time of its
+// latest post, nil if it has no threads
+func (r *CategoryRepository) GetStats(ctx context.Context, category *models.Category) (int64, *time.Time, error) {
+	db := r.db.WithContext(ctx)
+	count, err := category.GetThreadCount(db)
+	if err != nil || count == 0 {
+		return count, nil, err
+	}
+	thread, err := category.GetLatestThread(db)
+	if err != nil {
+		return 0, nil, err
+	}
+	return count, &thread.LastPostAt, nil
+}
+
+// BoardStats summarizes the activity of a category
+type BoardStats struct {
+	ID           uint
+	Slug         string
+	Name         string
+	Threads      int64
+	Posts        int64
+	DeletedPosts int64
+	// RecentPosts counts the posts of the last 24 hours
+	RecentPosts int64
+	LastPostAt  *time.Time
+}
+
+// GetBoardStats returns the statistics of all categories ordered by position
+func (r *CategoryRepository) GetBoardStats(ctx context.Context) ([]BoardStats, error) {
+	var stats []BoardStats
+	result := r.db.WithContext(ctx).Raw(`
+		SELECT c.id, c.slug, c.name,
+			COUNT(DISTINCT t.id) AS threads,
+			COUNT(p.id) FILTER (WHERE NOT p.is_deleted) AS posts,
+			COUNT(p.id) FILTER (WHERE p.is_deleted) AS deleted_posts,
+			COUNT(p.id) FILTER (WHERE NOT p.is_deleted AND p.created_at > CURRENT_TIMESTAMP - INTERVAL '24 hours') AS recent_posts,
+			MAX(p.created_at) FILTER (WHERE NOT p.is_deleted) AS last_post_at
+		FROM categories c
+			LEFT JOIN threads t ON t.category_id = c.id
+			LEFT JOIN posts p ON p.thread_id = t.id
+		GROUP BY c.id
+		ORDER BY c.position, c.id`).Scan(&stats)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return stats, nil
+}
+
 // GetBySlug retrieves a category by its slug
 func (r *CategoryRepository) GetBySlug(ctx context.Context, slug string) (*models.Category, error) {
 	var category models.Category
@@ -110,6 +195,9 @@ func (r *CategoryRepository) CreateWithTx(ctx context.Context, tx *gorm.DB, cate
 func (r *CategoryRepository) UpdateWithTx(ctx context.Context, tx *gorm.DB, category *models.Category) error {
 	result := tx.WithContext(ctx).Save(category)
 	if result.Error != nil {
+		if tx.WithContext(ctx).Where("slug = ? AND id <> ?", category.Slug, category.ID).First(&models.Category{}).Error == nil {
+			return ErrCategoryExists
+		}
 		return result.Error
 	}
 	if result.RowsAffected == 0 {
@@ -135,7 +223,7 @@ func (r *CategoryRepository) GetAllPaginated(ctx context.Context, pagination *mo
 	var categories []models.Category
 	var total int64
 
-	if err := r.db.Model(&models.Category{}).Count(&total).Error; err != nil {
+	if err := r.db.WithContext(ctx).Model(&models.Category{}).Count(&total).Error; err != nil {
 		return nil, err
 	}
 
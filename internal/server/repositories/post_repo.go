@@ -43,19 +43,23 @@ func (r *PostRepository) GetByID(ctx context.Context, id uint) (*models.Post, er
 	return &post, nil
 }
 
-// GetByThread retrieves posts by thread ID
+// GetByThread retrieves the posts of a thread that are not deleted, in the
+// order of their numbers. The numbers of deleted posts are left out.
 func (r *PostRepository) GetByThread(ctx context.Context, threadID uint) ([]models.Post, error) {
 	var posts []models.Post
-	result := r.db.WithContext(ctx).Where("thread_id = ?", threadID).Find(&posts)
+	result := r.db.WithContext(ctx).Where("thread_id = ? AND NOT is_deleted", threadID).
+		Order("number").Find(&posts)
 	if result.Error != nil {
 		return nil, result.Error
 	}
 	return posts, nil
 }
 
-// Update updates an existing post
+// Update stores the content of a post. A post deleted in the meantime is
+// left alone and reported as not found.
 func (r *PostRepository) Update(ctx context.Context, post *models.Post) error {
-	result := r.db.WithContext(ctx).Save(post)
+	result := r.db.WithContext(ctx).Model(post).Where("NOT is_deleted").
+		Select("content", "updated_at").Updates(post)
 	if result.Error != nil {
 		return result.Error
 	}
@@ -90,20 +94,34 @@ func (r *PostRepository) SoftDelete(ctx context.Context, id uint) error {
 	return nil
 }
 
-// GetPostCountByThread returns the number of posts in a thread
+// Restore clears the deletion mark of a post
+func (r *PostRepository) Restore(ctx context.Context, id uint) error {
+	result := r.db.WithContext(ctx).Model(&models.Post{}).Where("id = ?", id).
+		Update("is_deleted", false)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrPostNotFound
+	}
+	return nil
+}
+
+// GetPostCountByThread returns the number of posts in a thread that are not
+// deleted, like the post count kept by the triggers
 func (r *PostRepository) GetPostCountByThread(ctx context.Context, threadID uint) (int64, error) {
 	var count int64
-	result := r.db.WithContext(ctx).Model(&models.Post{}).Where("thread_id = ?", threadID).Count(&count)
+	result := r.db.WithContext(ctx).Model(&models.Post{}).Where("thread_id = ? AND NOT is_deleted", threadID).Count(&count)
 	if result.Error != nil {
 		return 0, result.Error
 	}
 	return count, nil
 }
 
-// GetLatestPostByThread returns the latest post in a thread
+// GetLatestPostByThread returns the latest post in a thread that is not deleted
 func (r *PostRepository) GetLatestPostByThread(ctx context.Context, threadID uint) (*models.Post, error) {
 	var post models.Post
-	result := r.db.WithContext(ctx).Where("thread_id = ?", threadID).Order("created_at DESC").First(&post)
+	result := r.db.WithContext(ctx).Where("thread_id = ? AND NOT is_deleted", threadID).Order("created_at DESC, id DESC").First(&post)
 	if result.Error != nil {
 		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
 			return nil, ErrPostNotFound
@@ -159,16 +177,18 @@ func (r *PostRepository) SoftDeleteWithTx(ctx context.Context, tx *gorm.DB, id u
 	return nil
 }
 
-// GetByThreadPaginated retrieves posts by thread ID with pagination
+// GetByThreadPaginated retrieves a page of the posts of a thread that are not
+// deleted, in the order of GetByThread
 func (r *PostRepository) GetByThreadPaginated(ctx context.Context, threadID uint, pagination *models.Pagination) ([]models.Post, error) {
 	var posts []models.Post
 	var total int64
 
-	if err := r.db.Model(&models.Post{}).Where("thread_id = ?", threadID).Count(&total).Error; err != nil {
+	if err := r.db.WithContext(ctx).Model(&models.Post{}).Where("thread_id = ? AND NOT is_deleted", threadID).Count(&total).Error; err != nil {
 		return nil, err
 	}
 
-	result := r.db.WithContext(ctx).Where("thread_id = ?", threadID).Offset(pagination.Offset).Limit(pagination.Limit).Find(&posts)
+	result := r.db.WithContext(ctx).Where("thread_id = ? AND NOT is_deleted", threadID).Order("number").
+		Offset(pagination.Offset).Limit(pagination.Limit).Find(&posts)
 	if result.Error != nil {
 		return nil, result.Error
 	}
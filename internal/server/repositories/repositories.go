@@ -0,0 +1,40 @@
+package repositories
+
+import (
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// likeEscaper escapes the LIKE wildcards of user input
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// Repositories groups the repositories used by the services
+type Repositories struct {
+	Category *CategoryRepository
+	Thread   *ThreadRepository
+	Post     *PostRepository
+	Ban      *BanRepository
+	db       *gorm.DB
+}
+
+// NewRepositories creates all repositories sharing the given database handle
+func NewRepositories(db *gorm.DB) *Repositories {
+	return &Repositories{
+		Category: NewCategoryRepository(db),
+		Thread:   NewThreadRepository(db),
+		Post:     NewPostRepository(db),
+		Ban:      NewBanRepository(db),
+		db:       db,
+	}
+}
+
+// DB returns the database handle shared by the repositories
+func (r *Repositories) DB() *gorm.DB {
+	return r.db
+}
+
+// escapeLike makes s match literally inside a LIKE pattern
+func escapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
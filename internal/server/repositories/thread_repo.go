@@ -3,9 +3,12 @@ package repositories
 import (
 	"context"
 	"errors"
+	common "heisei/internal/common/models"
 	"heisei/internal/server/models"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 var (
@@ -43,6 +46,20 @@ func (r *ThreadRepository) GetByID(ctx context.Context, id uint) (*models.Thread
 	return &thread, nil
 }
 
+// GetByIDForUpdate retrieves a thread by its ID and locks it until the end
+// of the transaction
+func (r *ThreadRepository) GetByIDForUpdate(ctx context.Context, id uint) (*models.Thread, error) {
+	var thread models.Thread
+	result := r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).First(&thread, id)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, ErrThreadNotFound
+		}
+		return nil, result.Error
+	}
+	return &thread, nil
+}
+
 // GetAll retrieves all threads
 func (r *ThreadRepository) GetAll(ctx context.Context) ([]models.Thread, error) {
 	var threads []models.Thread
@@ -53,19 +70,48 @@ func (r *ThreadRepository) GetAll(ctx context.Context) ([]models.Thread, error)
 	return threads, nil
 }
 
-// GetByCategory retrieves threads by category ID
-func (r *ThreadRepository) GetByCategory(ctx context.Context, categoryID uint) ([]models.Thread, error) {
+// threadOrders are the ORDER BY clauses of the thread sort orders
+var threadOrders = map[string]string{
+	common.ThreadSortBump:      "bumped_at DESC, id DESC",
+	common.ThreadSortCreated:   "created_at DESC, id DESC",
+	common.ThreadSortPostCount: "post_count DESC, bumped_at DESC, id DESC",
+	common.ThreadSortActivity: `(SELECT COUNT(*) FROM posts WHERE posts.thread_id = threads.id AND NOT posts.is_deleted
+		AND posts.created_at > CURRENT_TIMESTAMP - INTERVAL '24 hours') DESC, bumped_at DESC, id DESC`,
+}
+
+// GetByCategory retrieves the threads of a category in the given sort order
+func (r *ThreadRepository) GetByCategory(ctx context.Context, categoryID uint, sort string) ([]models.Thread, error) {
+	var threads []models.Thread
+	order, ok := threadOrders[sort]
+	if !ok {
+		order = threadOrders[common.ThreadSortBump]
+	}
+	result := r.db.WithContext(ctx).Where("category_id = ?", categoryID).Order(order).Find(&threads)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return threads, nil
+}
+
+// Search retrieves threads whose title contains query, optionally limited to a category
+func (r *ThreadRepository) Search(ctx context.Context, categoryID uint, query string) ([]models.Thread, error) {
 	var threads []models.Thread
-	result := r.db.WithContext(ctx).Where("category_id = ?", categoryID).Find(&threads)
+	db := r.db.WithContext(ctx).Where("title ILIKE ?", "%"+escapeLike(query)+"%")
+	if categoryID != 0 {
+		db = db.Where("category_id = ?", categoryID)
+	}
+	result := db.Find(&threads)
 	if result.Error != nil {
 		return nil, result.Error
 	}
 	return threads, nil
 }
 
-// Update updates an existing thread
+// Update stores the title and category of an existing thread. The counters
+// belong to the triggers of the posts table and the lock to SetLocked, so a
+// stale copy of the thread cannot overwrite them.
 func (r *ThreadRepository) Update(ctx context.Context, thread *models.Thread) error {
-	result := r.db.WithContext(ctx).Save(thread)
+	result := r.db.WithContext(ctx).Model(thread).Select("title", "category_id", "updated_at").Updates(thread)
 	if result.Error != nil {
 		return result.Error
 	}
@@ -87,10 +133,10 @@ func (r *ThreadRepository) Delete(ctx context.Context, id uint) error {
 	return nil
 }
 
-// IncrementPostCount increments the post count of a thread
-func (r *ThreadRepository) IncrementPostCount(ctx context.Context, threadID uint) error {
-	result := r.db.WithContext(ctx).Model(&models.Thread{}).Where("id = ?", threadID).
-		UpdateColumn("post_count", gorm.Expr("post_count + ?", 1))
+// SetLocked locks or unlocks a thread
+func (r *ThreadRepository) SetLocked(ctx context.Context, id uint, locked bool) error {
+	result := r.db.WithContext(ctx).Model(&models.Thread{}).Where("id = ?", id).
+		UpdateColumn("locked", locked)
 	if result.Error != nil {
 		return result.Error
 	}
@@ -100,17 +146,68 @@ func (r *ThreadRepository) IncrementPostCount(ctx context.Context, threadID uint
 	return nil
 }
 
-// UpdateLastPostAt updates the last post time of a thread
-func (r *ThreadRepository) UpdateLastPostAt(ctx context.Context, threadID uint) error {
-	result := r.db.WithContext(ctx).Model(&models.Thread{}).Where("id = ?", threadID).
-		UpdateColumn("last_post_at", gorm.Expr("CURRENT_TIMESTAMP"))
+// CounterDiscrepancy is a thread whose counters differ from its posts
+type CounterDiscrepancy struct {
+	ThreadID         uint
+	PostCount        int
+	LastPostAt       time.Time
+	ActualPostCount  int
+	ActualLastPostAt time.Time
+}
+
+// counterDiscrepanciesQuery finds the threads whose post count and last post
+// time differ from their posts that are not deleted. Threads without posts
+// fall back to their creation time, as in the triggers of the posts table.
+const counterDiscrepanciesQuery = `
+	SELECT t.id AS thread_id, t.post_count, t.last_post_at,
+		COUNT(p.id) AS actual_post_count,
+		COALESCE(MAX(p.created_at), t.created_at) AS actual_last_post_at
+	FROM threads t LEFT JOIN posts p ON p.thread_id = t.id AND NOT p.is_deleted
+	GROUP BY t.id
+	HAVING t.post_count <> COUNT(p.id) OR t.last_post_at <> COALESCE(MAX(p.created_at), t.created_at)`
+
+// FindCounterDiscrepancies returns the threads whose counters are wrong
+func (r *ThreadRepository) FindCounterDiscrepancies(ctx context.Context) ([]CounterDiscrepancy, error) {
+	var discrepancies []CounterDiscrepancy
+	result := r.db.WithContext(ctx).Raw(counterDiscrepanciesQuery + " ORDER BY t.id").Scan(&discrepancies)
 	if result.Error != nil {
-		return result.Error
+		return nil, result.Error
 	}
-	if result.RowsAffected == 0 {
-		return ErrThreadNotFound
+	return discrepancies, nil
+}
+
+// RebuildCounters corrects the threads whose counters are wrong and returns
+// them with their previous values. It must run in a transaction: the threads
+// are locked, in the order of their IDs like the posts added to them, before
+// they are counted again, so posts stored in the meantime are not lost.
+func (r *ThreadRepository) RebuildCounters(ctx context.Context) ([]CounterDiscrepancy, error) {
+	found, err := r.FindCounterDiscrepancies(ctx)
+	if err != nil || len(found) == 0 {
+		return nil, err
 	}
-	return nil
+	ids := make([]uint, len(found))
+	for i, d := range found {
+		ids[i] = d.ThreadID
+	}
+	var locked []uint
+	if err := r.db.WithContext(ctx).Model(&models.Thread{}).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id IN ?", ids).Order("id").Pluck("id", &locked).Error; err != nil {
+		return nil, err
+	}
+
+	// A new statement sees the posts committed while waiting for the locks
+	var discrepancies []CounterDiscrepancy
+	result := r.db.WithContext(ctx).Raw(`
+		WITH discrepancies AS (`+counterDiscrepanciesQuery+`)
+		UPDATE threads SET post_count = d.actual_post_count, last_post_at = d.actual_last_post_at
+		FROM discrepancies d
+		WHERE threads.id = d.thread_id AND threads.id IN ?
+		RETURNING d.thread_id, d.post_count, d.last_post_at, d.actual_post_count, d.actual_last_post_at`, ids).
+		Scan(&discrepancies)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return discrepancies, nil
 }
 
 // CreateWithTx creates a new thread within a transaction
@@ -146,32 +243,6 @@ func (r *ThreadRepository) DeleteWithTx(ctx context.Context, tx *gorm.DB, id uin
 	return nil
 }
 
-// IncrementPostCountWithTx increments the post count of a thread within a transaction
-func (r *ThreadRepository) IncrementPostCountWithTx(ctx context.Context, tx *gorm.DB, threadID uint) error {
-	result := tx.WithContext(ctx).Model(&models.Thread{}).Where("id = ?", threadID).
-		UpdateColumn("post_count", gorm.Expr("post_count + ?", 1))
-	if result.Error != nil {
-		return result.Error
-	}
-	if result.RowsAffected == 0 {
-		return ErrThreadNotFound
-	}
-	return nil
-}
-
-// UpdateLastPostAtWithTx updates the last post time of a thread within a transaction
-func (r *ThreadRepository) UpdateLastPostAtWithTx(ctx context.Context, tx *gorm.DB, threadID uint) error {
-	result := tx.WithContext(ctx).Model(&models.Thread{}).Where("id = ?", threadID).
-		UpdateColumn("last_post_at", gorm.Expr("CURRENT_TIMESTAMP"))
-	if result.Error != nil {
-		return result.Error
-	}
-	if result.RowsAffected == 0 {
-		return ErrThreadNotFound
-	}
-	return nil
-}
-
 // GetAllPagenated retrieves all threads with pagination
 func (r *ThreadRepository) GetAllPagenated(ctx context.Context, pagination *models.Pagination) ([]models.Thread, error) {
 	var threads []models.Thread
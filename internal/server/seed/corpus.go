@@ -0,0 +1,198 @@
+package seed
+
+// Languages of the generated text
+const (
+	LanguageJapanese = "ja"
+	LanguageEnglish  = "en"
+	// LanguageMixed alternates the language from section to section, or from
+	// board to board without sections
+	LanguageMixed = "mixed"
+)
+
+// board is a board of the corpus. Its topics fill the {topic} placeholders
+// of the titles and posts of its threads.
+type board struct {
+	slug        string
+	name        string
+	description string
+	topics      []string
+}
+
+// corpus holds the text of one language. Posts are made of sentences, which
+// are joined by separator.
+type corpus struct {
+	sections  []board
+	boards    []board
+	titles    []string
+	openers   []string
+	sentences []string
+	replies   []string
+	// suffix follows the number of numbered names, e.g. "News 2"
+	suffix    string
+	separator string
+}
+
+var corpora = map[string]*corpus{
+	LanguageEnglish:  english,
+	LanguageJapanese: japanese,
+}
+
+var english = &corpus{
+	sections: []board{
+		{slug: "general", name: "General", description: "Everything else"},
+		{slug: "hobbies", name: "Hobbies", description: "What we do for fun"},
+		{slug: "tech", name: "Technology", description: "Computers and gadgets"},
+		{slug: "culture", name: "Culture", description: "Books, films and music"},
+	},
+	boards: []board{
+		{slug: "news", name: "News", description: "Current events",
+			topics: []string{"local elections", "public transport", "housing prices", "the economy", "space launches"}},
+		{slug: "programming", name: "Programming", description: "Code, tools and languages",
+			topics: []string{"Go generics", "Rust", "PostgreSQL", "Vim", "code review", "unit tests"}},
+		{slug: "retro", name: "Retro Computing", description: "Old machines and the people who love them",
+			topics: []string{"the PC-98", "floppy disks", "CRT monitors", "BBS software", "dial-up modems"}},
+		{slug: "cooking", name: "Cooking", description: "Recipes and kitchen disasters",
+			topics: []string{"sourdough", "ramen", "cast iron pans", "curry", "meal prep"}},
+		{slug: "games", name: "Games", description: "Video and board games",
+			topics: []string{"speedruns", "JRPGs", "tabletop RPGs", "retro consoles", "indie games"}},
+		{slug: "music", name: "Music", description: "Listening and playing",
+			topics: []string{"city pop", "synthesizers", "vinyl records", "jazz", "guitar pedals"}},
+		{slug: "books", name: "Books", description: "What are you reading?",
+			topics: []string{"science fiction", "Murakami", "poetry", "comics", "libraries"}},
+		{slug: "travel", name: "Travel", description: "Trips and tips",
+			topics: []string{"Kyoto", "night trains", "hostels", "hiking", "cheap flights"}},
+	},
+	titles: []string{
+		"{topic} general",
+		"Anyone else into {topic}?",
+		"{topic} thread #{n}",
+		"Help with {topic}",
+		"Unpopular opinions about {topic}",
+		"What got you into {topic}?",
+		"Post your {topic} stories",
+		"Beginner questions about {topic}",
+		"Is {topic} worth it?",
+		"{topic}: what changed this year",
+	},
+	openers: []string{
+		"Let's talk about {topic}.",
+		"Old thread hit the limit, so here is a new one.",
+		"I have been thinking about {topic} a lot lately.",
+		"Quick question for the people who know {topic}.",
+		"Starting a thread since there was none about {topic}.",
+	},
+	sentences: []string{
+		"I tried it last weekend and it went better than expected.",
+		"Honestly it depends on what you want out of it.",
+		"The documentation is a bit thin, but the community is helpful.",
+		"My first attempt was a complete mess.",
+		"It took me a while to get used to it.",
+		"Does anyone have a good guide for getting started?",
+		"I would not recommend it to someone on a budget.",
+		"The older version was better in my opinion.",
+		"There is a shop near the station that sells everything you need.",
+		"That is pretty much how I feel about {topic} too.",
+		"People overthink {topic}, just start somewhere.",
+		"I have been doing this for ten years and I still learn something new.",
+		"Can confirm, same thing happened to me.",
+		"Not sure that is true anymore.",
+		"Source?",
+		"This thread is making me want to try {topic} again.",
+		"Bookmarked, thanks.",
+		"It was cheaper than I thought.",
+		"Somebody should write a FAQ for this board.",
+		"The real answer is practice.",
+	},
+	replies: []string{
+		"Agreed.",
+		"This.",
+		"I disagree, but I see where you are coming from.",
+		"Good point.",
+		"Thanks, that helped.",
+		"Same here.",
+		"lol",
+		"Could you explain what you mean?",
+	},
+	suffix:    " %d",
+	separator: " ",
+}
+
+var japanese = &corpus{
+	sections: []board{
+		{slug: "zatsudan", name: "雑談", description: "なんでも雑談"},
+		{slug: "shumi", name: "趣味", description: "趣味の話題"},
+		{slug: "gijutsu", name: "技術", description: "コンピュータと技術"},
+		{slug: "bunka", name: "文化", description: "本・映画・音楽"},
+	},
+	boards: []board{
+		{slug: "nyusu", name: "ニュース速報", description: "最新のニュース",
+			topics: []string{"選挙", "天気予報", "新しい路線", "物価高", "宇宙開発"}},
+		{slug: "puroguramingu", name: "プログラミング", description: "コードと開発環境",
+			topics: []string{"Go言語", "Rust", "PostgreSQL", "Vim", "テスト駆動開発", "コードレビュー"}},
+		{slug: "retoro-pc", name: "レトロPC", description: "懐かしのパソコン",
+			topics: []string{"PC-98", "フロッピー", "ブラウン管", "パソコン通信", "MSX"}},
+		{slug: "ryori", name: "料理", description: "レシピと失敗談",
+			topics: []string{"ラーメン", "カレー", "弁当", "鉄フライパン", "自家製パン"}},
+		{slug: "geemu", name: "ゲーム", description: "テレビゲームとボードゲーム",
+			topics: []string{"RPG", "囲碁", "将棋", "レトロゲーム", "インディーゲーム"}},
+		{slug: "ongaku", name: "音楽", description: "聴く人も演奏する人も",
+			topics: []string{"シティポップ", "シンセサイザー", "レコード", "ジャズ", "ギター"}},
+		{slug: "dokusho", name: "読書", description: "最近読んだ本",
+			topics: []string{"SF小説", "村上春樹", "漫画", "図書館", "詩集"}},
+		{slug: "ryoko", name: "旅行", description: "旅の思い出と情報",
+			topics: []string{"京都", "寝台列車", "温泉", "登山", "青春18きっぷ"}},
+	},
+	titles: []string{
+		"{topic}について語るスレ",
+		"{topic}総合スレ Part{n}",
+		"【質問】{topic}で困ってます",
+		"{topic}好きな人集まれ",
+		"{topic}の思い出",
+		"初心者が{topic}を始めるスレ",
+		"{topic}って正直どうなの？",
+		"【雑談】{topic}",
+		"{topic}で一番好きなもの",
+		"今年の{topic}を振り返る",
+	},
+	openers: []string{
+		"{topic}について語りましょう。",
+		"前スレが埋まったので立てました。",
+		"最近{topic}にハマっています。",
+		"詳しい人に質問です。",
+		"{topic}のスレがなかったので立ててみました。",
+	},
+	sentences: []string{
+		"先週末に試してみたら思ったより良かったです。",
+		"正直、目的によると思います。",
+		"ドキュメントは少ないけど、みんな親切です。",
+		"最初は大失敗しました。",
+		"慣れるまで少し時間がかかりました。",
+		"おすすめの入門書はありますか？",
+		"予算が少ないならおすすめしません。",
+		"昔のほうが良かった気がする。",
+		"駅前の店に全部揃ってますよ。",
+		"{topic}については自分も同じ意見です。",
+		"{topic}は考えすぎないで始めるのが一番。",
+		"十年やってるけどまだ発見があります。",
+		"わかる、自分も同じことがあった。",
+		"それはもう古い情報かも。",
+		"ソースは？",
+		"このスレ見てたらまた{topic}やりたくなってきた。",
+		"ブックマークしました、ありがとう。",
+		"思ったより安かったです。",
+		"誰かこの板のFAQ作ってほしい。",
+		"結局は練習あるのみ。",
+	},
+	replies: []string{
+		"同意。",
+		"それな。",
+		"ちょっと違うと思うけど、言いたいことはわかる。",
+		"なるほど。",
+		"ありがとう、助かりました。",
+		"自分もです。",
+		"ワロタ",
+		"詳しく教えてください。",
+	},
+	suffix:    "%d",
+	separator: "",
+}
@@ -0,0 +1,451 @@
+// Package seed fills a database with generated boards for demos and load
+// tests. The data depends only on the Config, so the same seed gives the
+// same categories, threads and posts on every run.
+package seed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+
+	common "heisei/internal/common/models"
+	"heisei/internal/server/models"
+
+	"gorm.io/gorm"
+)
+
+// postBatchSize is the number of posts inserted per statement
+const postBatchSize = 500
+
+// Config sets the shape and size of the generated data
+type Config struct {
+	// Seed selects the data; the same Config gives the same data
+	Seed int64
+	// Sections is the number of sections grouping the boards; with 0 the
+	// boards are top-level categories
+	Sections int
+	// Boards is the number of boards per section, or in total without sections
+	Boards int
+	// Threads is the number of threads per board
+	Threads int
+	// Posts is the average number of posts per thread
+	Posts int
+	// Language of the text: LanguageJapanese, LanguageEnglish or LanguageMixed
+	Language string
+	// End is the time of the latest post; posts are spread over Span before it
+	End  time.Time
+	Span time.Duration
+}
+
+// DefaultConfig returns a small board for demos. End must still be set.
+func DefaultConfig() Config {
+	return Config{
+		Seed:     1,
+		Sections: 2,
+		Boards:   3,
+		Threads:  20,
+		Posts:    30,
+		Language: LanguageMixed,
+		Span:     90 * 24 * time.Hour,
+	}
+}
+
+// Validate reports an invalid configuration
+func (c Config) Validate() error {
+	switch {
+	case c.Sections < 0 || c.Threads < 0 || c.Posts < 0:
+		return errors.New("sections, threads and posts cannot be negative")
+	case c.Boards < 1:
+		return errors.New("at least one board is needed")
+	case c.Language != LanguageMixed && corpora[c.Language] == nil:
+		return fmt.Errorf("unknown language %q", c.Language)
+	case c.End.IsZero():
+		return errors.New("end time is required")
+	case c.Span <= 0:
+		return errors.New("span must be positive")
+	}
+	return nil
+}
+
+// Stats counts the generated records
+type Stats struct {
+	Categories int
+	Threads    int
+	Posts      int
+}
+
+// Writer stores the generated records and assigns their IDs
+type Writer interface {
+	WriteCategory(category *models.Category) error
+	// WriteThread stores a thread with its posts, whose ThreadID is not set
+	WriteThread(thread *models.Thread, posts []*models.Post) error
+}
+
+// Seed generates the data for cfg into an empty database. Each thread is
+// written with its posts in a transaction; the counters of the threads are
+// set by the triggers of the posts table.
+func Seed(ctx context.Context, db *gorm.DB, cfg Config) (*Stats, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	var count int64
+	if err := db.WithContext(ctx).Model(&models.Category{}).Count(&count).Error; err != nil {
+		return nil, fmt.Errorf("failed to count categories: %w", err)
+	}
+	if count > 0 {
+		return nil, errors.New("the database already has categories; seed an empty database")
+	}
+
+	// The hooks of the models would replace the generated timestamps
+	w := &dbWriter{db: db.WithContext(ctx).Session(&gorm.Session{SkipHooks: true})}
+	return NewGenerator(cfg).Generate(w)
+}
+
+// dbWriter inserts the records with their generated timestamps
+type dbWriter struct {
+	db *gorm.DB
+}
+
+func (w *dbWriter) WriteCategory(category *models.Category) error {
+	if err := w.db.Create(category).Error; err != nil {
+		return fmt.Errorf("failed to create category %q: %w", category.Slug, err)
+	}
+	return nil
+}
+
+func (w *dbWriter) WriteThread(thread *models.Thread, posts []*models.Post) error {
+	return w.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(thread).Error; err != nil {
+			return fmt.Errorf("failed to create thread %q: %w", thread.Title, err)
+		}
+		if len(posts) == 0 {
+			return nil
+		}
+		for _, post := range posts {
+			post.ThreadID = thread.ID
+		}
+		if err := tx.CreateInBatches(posts, postBatchSize).Error; err != nil {
+			return fmt.Errorf("failed to create posts of thread %d: %w", thread.ID, err)
+		}
+		return nil
+	})
+}
+
+// Generator generates the records of a Config
+type Generator struct {
+	cfg   Config
+	rng   *rand.Rand
+	start time.Time
+	// timelines holds the timeline of each language
+	timelines map[string]*timeline
+	// used counts the sections and boards taken from each corpus
+	used  map[string]int
+	stats Stats
+}
+
+func NewGenerator(cfg Config) *Generator {
+	start := cfg.End.Add(-cfg.Span)
+	return &Generator{
+		cfg:   cfg,
+		rng:   rand.New(rand.NewSource(cfg.Seed)),
+		start: start,
+		timelines: map[string]*timeline{
+			LanguageEnglish:  newTimeline(start, cfg.End, 0),
+			LanguageJapanese: newTimeline(start, cfg.End, 9*time.Hour),
+		},
+		used: make(map[string]int),
+	}
+}
+
+// Generate writes the sections, each followed by its boards, and the
+// threads of each board after the board
+func (g *Generator) Generate(w Writer) (*Stats, error) {
+	if g.cfg.Sections == 0 {
+		for i := 0; i < g.cfg.Boards; i++ {
+			if err := g.board(w, g.language(i), nil, i); err != nil {
+				return &g.stats, err
+			}
+		}
+		return &g.stats, nil
+	}
+
+	for s := 0; s < g.cfg.Sections; s++ {
+		lang := g.language(s)
+		section, _ := g.category(lang, nil, s, true)
+		if err := w.WriteCategory(section); err != nil {
+			return &g.stats, err
+		}
+		g.stats.Categories++
+		for i := 0; i < g.cfg.Boards; i++ {
+			if err := g.board(w, lang, &section.ID, i); err != nil {
+				return &g.stats, err
+			}
+		}
+	}
+	return &g.stats, nil
+}
+
+// language returns the language of the nth section, or of the nth board
+// without sections. The boards of a section share its language.
+func (g *Generator) language(n int) string {
+	if g.cfg.Language != LanguageMixed {
+		return g.cfg.Language
+	}
+	if n%2 == 0 {
+		return LanguageJapanese
+	}
+	return LanguageEnglish
+}
+
+// board writes a board at position in its section and the threads of the
+// board
+func (g *Generator) board(w Writer, lang string, parentID *uint, position int) error {
+	category, entry := g.category(lang, parentID, position, false)
+	if err := w.WriteCategory(category); err != nil {
+		return err
+	}
+	g.stats.Categories++
+
+	for i := 0; i < g.cfg.Threads; i++ {
+		thread, posts := g.thread(lang, category, entry.topics)
+		if err := w.WriteThread(thread, posts); err != nil {
+			return err
+		}
+		g.stats.Threads++
+		g.stats.Posts += len(posts)
+	}
+	return nil
+}
+
+// category returns the next section or board of the corpus of lang, with
+// its corpus entry. Once the corpus is used up, its names are reused with a
+// number.
+func (g *Generator) category(lang string, parentID *uint, position int, section bool) (*models.Category, board) {
+	c := corpora[lang]
+	entries, key := c.boards, lang+"/board"
+	if section {
+		entries, key = c.sections, lang+"/section"
+	}
+	n := g.used[key]
+	g.used[key]++
+	entry := entries[n%len(entries)]
+	name, slug := entry.name, entry.slug
+	if round := n/len(entries) + 1; round > 1 {
+		name += fmt.Sprintf(c.suffix, round)
+		slug += fmt.Sprintf("-%d", round)
+	}
+
+	sortOrder := common.ThreadSortBump
+	// A few boards show another order
+	if !section && g.rng.Intn(4) == 0 {
+		sortOrder = []string{common.ThreadSortCreated, common.ThreadSortPostCount, common.ThreadSortActivity}[g.rng.Intn(3)]
+	}
+	return &models.Category{
+		BaseModel:   models.BaseModel{CreatedAt: g.start, UpdatedAt: g.start},
+		ParentID:    parentID,
+		Position:    position,
+		Name:        name,
+		Slug:        slug,
+		Description: entry.description,
+		DefaultSort: sortOrder,
+		MaxPosts:    common.DefaultMaxPosts,
+	}, entry
+}
+
+// thread returns a thread of category with its posts in order
+func (g *Generator) thread(lang string, category *models.Category, topics []string) (*models.Thread, []*models.Post) {
+	c := corpora[lang]
+	tl := g.timelines[lang]
+	topic := topics[g.rng.Intn(len(topics))]
+	fill := func(s string) string {
+		return strings.ReplaceAll(s, "{topic}", topic)
+	}
+
+	// More threads are started towards the end, as a board grows
+	started := tl.total() * math.Sqrt(g.rng.Float64())
+	createdAt := tl.at(started)
+	title := fill(c.titles[g.rng.Intn(len(c.titles))])
+	title = strings.ReplaceAll(title, "{n}", fmt.Sprint(2+g.rng.Intn(30)))
+	// The counters start at the creation time, and the triggers move them
+	// as the posts are inserted
+	thread := &models.Thread{
+		BaseModel:  models.BaseModel{CreatedAt: createdAt, UpdatedAt: createdAt},
+		CategoryID: category.ID,
+		Title:      title,
+		LastPostAt: createdAt,
+		BumpedAt:   createdAt,
+		Locked:     g.rng.Intn(30) == 0,
+	}
+
+	count := 0
+	if g.cfg.Posts > 0 {
+		count = 1 + g.rng.Intn(2*g.cfg.Posts-1)
+	}
+	if category.IsFull(count) {
+		count = category.MaxPosts
+	}
+	times := g.postTimes(tl, started, count)
+	authors := g.authors(1 + g.rng.Intn(8))
+
+	posts := make([]*models.Post, count)
+	for i := range posts {
+		// Numbered in order, deleted posts included, as by the database
+		post := &models.Post{
+			BaseModel: models.BaseModel{CreatedAt: times[i], UpdatedAt: times[i]},
+			Number:    i + 1,
+			AuthorIP:  authors[g.rng.Intn(len(authors))],
+		}
+		if i == 0 {
+			post.AuthorIP = authors[0]
+			post.Content = fill(c.openers[g.rng.Intn(len(c.openers))]) + c.separator + g.sentences(c, fill, 1+g.rng.Intn(3))
+		} else {
+			post.Content = g.reply(c, fill, posts[:i], category.Slug)
+			post.Sage = g.rng.Intn(10) == 0
+			post.IsDeleted = g.rng.Intn(50) == 0
+		}
+		posts[i] = post
+	}
+	return thread, posts
+}
+
+// postTimes returns the times of count posts starting at the activity
+// started. Replies come quickly at first and slow down as the thread ages.
+func (g *Generator) postTimes(tl *timeline, started float64, count int) []time.Time {
+	if count == 0 {
+		return nil
+	}
+	lifetime := math.Min(tl.total()-started, tl.total()/8*g.rng.ExpFloat64())
+	gaps := make([]float64, count)
+	var sum float64
+	for i := 1; i < count; i++ {
+		gaps[i] = g.rng.ExpFloat64() * (1 + 3*float64(i)/float64(count))
+		sum += gaps[i]
+	}
+
+	times := make([]time.Time, count)
+	var elapsed float64
+	for i := range times {
+		elapsed += gaps[i]
+		activity := started
+		if sum > 0 {
+			activity += lifetime * elapsed / sum
+		}
+		times[i] = tl.at(activity)
+	}
+	return times
+}
+
+// reply returns the content of a post following earlier
+func (g *Generator) reply(c *corpus, fill func(string) string, earlier []*models.Post, slug string) string {
+	// Only the posts that are shown are replied to; the first one always is
+	var shown []*models.Post
+	for _, post := range earlier {
+		if !post.IsDeleted {
+			shown = append(shown, post)
+		}
+	}
+
+	var b strings.Builder
+	switch n := g.rng.Intn(100); {
+	case n < 30:
+		// Anchors mostly point at recent posts
+		target := shown[len(shown)-1-g.rng.Intn(min(len(shown), 10))]
+		fmt.Fprintf(&b, ">>%d\n%s", target.Number, c.replies[g.rng.Intn(len(c.replies))])
+		if g.rng.Intn(2) == 0 {
+			b.WriteString("\n" + g.sentences(c, fill, 1))
+		}
+	case n < 38:
+		quoted := shown[g.rng.Intn(len(shown))].Content
+		line, _, _ := strings.Cut(quoted, "\n")
+		fmt.Fprintf(&b, "> %s\n%s", line, g.sentences(c, fill, 1+g.rng.Intn(2)))
+	default:
+		b.WriteString(g.sentences(c, fill, 1+g.rng.Intn(3)))
+	}
+	if g.rng.Intn(25) == 0 {
+		fmt.Fprintf(&b, "\nhttps://example.com/%s/%d", slug, 1+g.rng.Intn(9999))
+	}
+	return b.String()
+}
+
+// sentences returns n sentences, sometimes on separate lines
+func (g *Generator) sentences(c *corpus, fill func(string) string, n int) string {
+	var b strings.Builder
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			if g.rng.Intn(5) == 0 {
+				b.WriteString("\n")
+			} else {
+				b.WriteString(c.separator)
+			}
+		}
+		b.WriteString(fill(c.sentences[g.rng.Intn(len(c.sentences))]))
+	}
+	return b.String()
+}
+
+// authors returns the addresses of the n people posting in a thread, from
+// the ranges reserved for documentation
+func (g *Generator) authors(n int) []string {
+	networks := []string{"192.0.2.%d", "198.51.100.%d", "203.0.113.%d"}
+	authors := make([]string, n)
+	for i := range authors {
+		if g.rng.Intn(10) == 0 {
+			authors[i] = fmt.Sprintf("2001:db8:%x:%x::%x", g.rng.Intn(0x10000), g.rng.Intn(0x10000), 1+g.rng.Intn(0xffff))
+			continue
+		}
+		authors[i] = fmt.Sprintf(networks[g.rng.Intn(len(networks))], 1+g.rng.Intn(254))
+	}
+	return authors
+}
+
+// hourWeights is the relative activity at each hour of the day, local to
+// the people posting: quiet at night, busiest in the evening
+var hourWeights = [24]float64{4, 3, 2, 1, 1, 1, 1, 2, 3, 4, 4, 5, 6, 5, 5, 5, 6, 7, 8, 9, 10, 10, 9, 6}
+
+// timeline maps activity to time. An hour of the evening holds more activity
+// than an hour of the night, so evenly spread activity clusters posts in
+// the evening.
+type timeline struct {
+	start time.Time
+	end   time.Time
+	// cumulative[i] is the activity before hour i of the timeline
+	cumulative []float64
+}
+
+// newTimeline returns the timeline from start to end of people living at
+// offset from UTC
+func newTimeline(start, end time.Time, offset time.Duration) *timeline {
+	hours := int(math.Ceil(end.Sub(start).Hours()))
+	tl := &timeline{start: start, end: end, cumulative: make([]float64, hours+1)}
+	for i := 0; i < hours; i++ {
+		hour := start.Add(time.Duration(i)*time.Hour + offset).UTC().Hour()
+		tl.cumulative[i+1] = tl.cumulative[i] + hourWeights[hour]
+	}
+	return tl
+}
+
+// total returns the activity of the whole timeline
+func (tl *timeline) total() float64 {
+	return tl.cumulative[len(tl.cumulative)-1]
+}
+
+// at returns the time at which activity is reached, to the second
+func (tl *timeline) at(activity float64) time.Time {
+	i := sort.SearchFloat64s(tl.cumulative, activity)
+	if i == 0 {
+		return tl.start
+	}
+	// Activity is spread evenly within the hour
+	hour := i - 1
+	within := (activity - tl.cumulative[hour]) / (tl.cumulative[i] - tl.cumulative[hour])
+	t := tl.start.Add(time.Duration(hour)*time.Hour + time.Duration(within*float64(time.Hour))).Truncate(time.Second)
+	if t.After(tl.end) {
+		return tl.end
+	}
+	return t
+}
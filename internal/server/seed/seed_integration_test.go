@@ -0,0 +1,72 @@
+//go:build integration
+
+package seed
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"heisei/internal/server/models"
+	"heisei/pkg/database/dbtest"
+)
+
+func TestMain(m *testing.M) { dbtest.Main(m) }
+
+func TestSeed(t *testing.T) {
+	db := dbtest.New(t)
+	ctx := context.Background()
+	cfg := Config{Seed: 3, Sections: 1, Boards: 2, Threads: 5, Posts: 10, Language: LanguageMixed, End: testEnd, Span: 7 * 24 * time.Hour}
+
+	stats, err := Seed(ctx, db, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Categories != 3 || stats.Threads != 10 {
+		t.Errorf("seeded %d categories and %d threads, want 3 and 10", stats.Categories, stats.Threads)
+	}
+	var posts int64
+	if err := db.Model(&models.Post{}).Count(&posts).Error; err != nil {
+		t.Fatal(err)
+	}
+	if int(posts) != stats.Posts {
+		t.Errorf("database has %d posts, seeded %d", posts, stats.Posts)
+	}
+
+	// The generated timestamps are kept, and the triggers set the counters
+	want := generate(t, cfg)
+	var threads []models.Thread
+	if err := db.Order("id").Find(&threads).Error; err != nil {
+		t.Fatal(err)
+	}
+	for i, thread := range threads {
+		if !thread.CreatedAt.Equal(want.threads[i].CreatedAt) {
+			t.Errorf("thread %d created at %v, generated at %v", thread.ID, thread.CreatedAt, want.threads[i].CreatedAt)
+		}
+		var count int
+		last := thread.CreatedAt
+		for _, post := range want.posts[want.threads[i].ID] {
+			if !post.IsDeleted {
+				count++
+				last = post.CreatedAt
+			}
+		}
+		if thread.PostCount != count || !thread.LastPostAt.Equal(last) {
+			t.Errorf("thread %d has %d posts, the last at %v; want %d at %v", thread.ID, thread.PostCount, thread.LastPostAt, count, last)
+		}
+		// The database numbers the posts as the anchors expect
+		var numbers []int
+		if err := db.Model(&models.Post{}).Where("thread_id = ?", thread.ID).Order("id").Pluck("number", &numbers).Error; err != nil {
+			t.Fatal(err)
+		}
+		for j, number := range numbers {
+			if number != j+1 {
+				t.Errorf("thread %d: post %d has number %d", thread.ID, j+1, number)
+			}
+		}
+	}
+
+	if _, err := Seed(ctx, db, cfg); err == nil {
+		t.Error("seeded a database that already has categories")
+	}
+}
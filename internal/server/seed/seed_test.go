@@ -0,0 +1,218 @@
+package seed
+
+import (
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"heisei/internal/server/models"
+)
+
+var testEnd = time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+// memoryWriter keeps the records, numbering them like the database
+type memoryWriter struct {
+	categories []*models.Category
+	threads    []*models.Thread
+	posts      map[uint][]*models.Post
+}
+
+func (w *memoryWriter) WriteCategory(category *models.Category) error {
+	category.ID = uint(len(w.categories) + 1)
+	w.categories = append(w.categories, category)
+	return nil
+}
+
+func (w *memoryWriter) WriteThread(thread *models.Thread, posts []*models.Post) error {
+	thread.ID = uint(len(w.threads) + 1)
+	w.threads = append(w.threads, thread)
+	for _, post := range posts {
+		post.ThreadID = thread.ID
+	}
+	if w.posts == nil {
+		w.posts = make(map[uint][]*models.Post)
+	}
+	w.posts[thread.ID] = posts
+	return nil
+}
+
+func generate(t *testing.T, cfg Config) *memoryWriter {
+	t.Helper()
+	if err := cfg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	w := &memoryWriter{}
+	if _, err := NewGenerator(cfg).Generate(w); err != nil {
+		t.Fatal(err)
+	}
+	return w
+}
+
+func TestGenerateIsDeterministic(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.End = testEnd
+	first, second := generate(t, cfg), generate(t, cfg)
+	if !reflect.DeepEqual(first, second) {
+		t.Error("the same config generated different data")
+	}
+
+	cfg.Seed++
+	if other := generate(t, cfg); reflect.DeepEqual(first.posts, other.posts) {
+		t.Error("another seed generated the same posts")
+	}
+}
+
+func TestGenerateScale(t *testing.T) {
+	cfg := Config{Seed: 7, Sections: 3, Boards: 4, Threads: 10, Posts: 20, Language: LanguageMixed, End: testEnd, Span: 30 * 24 * time.Hour}
+	w := generate(t, cfg)
+
+	if got, want := len(w.categories), 3+3*4; got != want {
+		t.Fatalf("generated %d categories, want %d", got, want)
+	}
+	slugs := make(map[string]bool)
+	var sections int
+	for _, category := range w.categories {
+		if err := category.Validate(); err != nil {
+			t.Errorf("category %q: %v", category.Slug, err)
+		}
+		if slugs[category.Slug] {
+			t.Errorf("slug %q generated twice", category.Slug)
+		}
+		slugs[category.Slug] = true
+		if category.ParentID == nil {
+			sections++
+		}
+	}
+	if sections != 3 {
+		t.Errorf("generated %d sections, want 3", sections)
+	}
+
+	if got, want := len(w.threads), 3*4*10; got != want {
+		t.Fatalf("generated %d threads, want %d", got, want)
+	}
+	var posts int
+	var japanese bool
+	for _, thread := range w.threads {
+		if err := thread.Validate(); err != nil {
+			t.Errorf("thread %q: %v", thread.Title, err)
+		}
+		for _, post := range w.posts[thread.ID] {
+			if err := post.Validate(); err != nil {
+				t.Errorf("post %q: %v", post.Content, err)
+			}
+			if strings.ContainsRune(post.Content, 'す') {
+				japanese = true
+			}
+		}
+		posts += len(w.posts[thread.ID])
+	}
+	// Posts is the average number of posts per thread
+	if average := float64(posts) / float64(len(w.threads)); average < 15 || average > 25 {
+		t.Errorf("generated %.1f posts per thread, want about 20", average)
+	}
+	if !japanese {
+		t.Error("mixed language generated no Japanese posts")
+	}
+}
+
+func TestGenerateTimes(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.End = testEnd
+	w := generate(t, cfg)
+	start := testEnd.Add(-cfg.Span)
+
+	for _, thread := range w.threads {
+		if thread.CreatedAt.Before(start) || thread.CreatedAt.After(testEnd) {
+			t.Errorf("thread %d created at %v, outside %v to %v", thread.ID, thread.CreatedAt, start, testEnd)
+		}
+		if !thread.LastPostAt.Equal(thread.CreatedAt) || thread.PostCount != 0 {
+			t.Errorf("thread %d has counters set, which the database maintains", thread.ID)
+		}
+		posts := w.posts[thread.ID]
+		if len(posts) > 0 && !posts[0].CreatedAt.Equal(thread.CreatedAt) {
+			t.Errorf("thread %d created at %v, its first post at %v", thread.ID, thread.CreatedAt, posts[0].CreatedAt)
+		}
+		for i := 1; i < len(posts); i++ {
+			if posts[i].CreatedAt.Before(posts[i-1].CreatedAt) || posts[i].CreatedAt.After(testEnd) {
+				t.Errorf("thread %d: post %d at %v follows %v", thread.ID, i+1, posts[i].CreatedAt, posts[i-1].CreatedAt)
+			}
+		}
+	}
+}
+
+func TestGenerateAnchors(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.End = testEnd
+	w := generate(t, cfg)
+	anchor := regexp.MustCompile(`^>>(\d+)`)
+
+	var anchors, deleted int
+	for _, thread := range w.threads {
+		posts := w.posts[thread.ID]
+		for i, post := range posts {
+			if post.Number != i+1 {
+				t.Errorf("thread %d: post %d has number %d", thread.ID, i+1, post.Number)
+			}
+			if post.IsDeleted {
+				deleted++
+			}
+			m := anchor.FindStringSubmatch(post.Content)
+			if m == nil {
+				continue
+			}
+			anchors++
+			// Anchors refer to earlier posts that are shown
+			number, _ := strconv.Atoi(m[1])
+			if number < 1 || number > i || posts[number-1].IsDeleted {
+				t.Errorf("thread %d: post %d refers to >>%d", thread.ID, post.Number, number)
+			}
+		}
+	}
+	if anchors == 0 || deleted == 0 {
+		t.Errorf("generated %d anchors and %d deleted posts, want some of both", anchors, deleted)
+	}
+}
+
+func TestTimelineFavorsTheEvening(t *testing.T) {
+	end := time.Date(2024, 1, 8, 0, 0, 0, 0, time.UTC)
+	tl := newTimeline(end.Add(-7*24*time.Hour), end, 0)
+	counts := make(map[int]int)
+	const samples = 7000
+	for i := 0; i < samples; i++ {
+		counts[tl.at(tl.total()*float64(i)/samples).Hour()]++
+	}
+	if counts[21] <= 5*counts[4] {
+		t.Errorf("%d posts at 21:00 and %d at 04:00, want many more in the evening", counts[21], counts[4])
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	valid := DefaultConfig()
+	valid.End = testEnd
+
+	tests := []struct {
+		name   string
+		modify func(c *Config)
+	}{
+		{"no boards", func(c *Config) { c.Boards = 0 }},
+		{"negative posts", func(c *Config) { c.Posts = -1 }},
+		{"unknown language", func(c *Config) { c.Language = "fr" }},
+		{"no end", func(c *Config) { c.End = time.Time{} }},
+		{"no span", func(c *Config) { c.Span = 0 }},
+	}
+	if err := valid.Validate(); err != nil {
+		t.Fatalf("default config: %v", err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := valid
+			tt.modify(&cfg)
+			if err := cfg.Validate(); err == nil {
+				t.Error("invalid config accepted")
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package services
+
+import (
+	"context"
+	"net/netip"
+	"time"
+
+	"heisei/internal/common/models"
+	servermodels "heisei/internal/server/models"
+	"heisei/internal/server/repositories"
+
+	"go.uber.org/zap"
+)
+
+type BanService struct {
+	repo   *repositories.BanRepository
+	logger *zap.Logger
+}
+
+func NewBanService(repo *repositories.BanRepository, logger *zap.Logger) *BanService {
+	return &BanService{
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+// CreateBan bans a network, given in CIDR notation or as a single address.
+// A zero duration bans it permanently.
+func (s *BanService) CreateBan(ctx context.Context, network, reason string, duration time.Duration) (*servermodels.Ban, error) {
+	ctx, span := startSpan(ctx, "BanService.CreateBan")
+	defer span.End()
+
+	prefix, err := parseNetwork(network)
+	if err != nil {
+		return nil, models.ErrInvalidInput("network")
+	}
+	ban := &servermodels.Ban{Network: prefix.String(), Reason: reason}
+	if duration > 0 {
+		expiresAt := time.Now().Add(duration)
+		ban.ExpiresAt = &expiresAt
+	}
+	if err := ban.Validate(); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Create(ctx, ban); err != nil {
+		logError(ctx, s.logger, "Failed to create ban", err)
+		return nil, err
+	}
+	return ban, nil
+}
+
+// GetBans lists the active bans, and the expired ones if asked to
+func (s *BanService) GetBans(ctx context.Context, expired bool) ([]servermodels.Ban, error) {
+	ctx, span := startSpan(ctx, "BanService.GetBans")
+	defer span.End()
+
+	bans, err := s.repo.GetAll(ctx, expired)
+	if err != nil {
+		logError(ctx, s.logger, "Failed to get bans", err)
+		return nil, err
+	}
+	return bans, nil
+}
+
+func (s *BanService) DeleteBan(ctx context.Context, id uint) error {
+	ctx, span := startSpan(ctx, "BanService.DeleteBan")
+	defer span.End()
+
+	err := s.repo.Delete(ctx, id)
+	if err != nil {
+		logError(ctx, s.logger, "Failed to delete ban", err, zap.Uint("id", id))
+		return err
+	}
+	return nil
+}
+
+// PurgeExpiredBans removes the expired bans and returns their number
+func (s *BanService) PurgeExpiredBans(ctx context.Context) (int64, error) {
+	ctx, span := startSpan(ctx, "BanService.PurgeExpiredBans")
+	defer span.End()
+
+	count, err := s.repo.DeleteExpired(ctx)
+	if err != nil {
+		logError(ctx, s.logger, "Failed to delete expired bans", err)
+		return 0, err
+	}
+	return count, nil
+}
+
+// CheckBanned returns ErrAuthorBanned if an active ban covers the address
+func (s *BanService) CheckBanned(ctx context.Context, ip string) error {
+	ctx, span := startSpan(ctx, "BanService.CheckBanned")
+	defer span.End()
+
+	banned, err := s.repo.IsBanned(ctx, ip)
+	if err != nil {
+		logError(ctx, s.logger, "Failed to check bans", err)
+		return err
+	}
+	if banned {
+		return models.ErrAuthorBanned()
+	}
+	return nil
+}
+
+// parseNetwork reads a network in CIDR notation or a single address, and
+// clears the host bits, which a cidr column rejects
+func parseNetwork(network string) (netip.Prefix, error) {
+	if addr, err := netip.ParseAddr(network); err == nil {
+		addr = addr.Unmap()
+		return netip.PrefixFrom(addr, addr.BitLen()), nil
+	}
+	prefix, err := netip.ParsePrefix(network)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	return prefix.Masked(), nil
+}
@@ -1,7 +1,11 @@
 package services
 
 import (
+	"context"
+	"errors"
+
 	"heisei/internal/common/models"
+	servermodels "heisei/internal/server/models"
 	"heisei/internal/server/repositories"
 
 	"go.uber.org/zap"
@@ -9,69 +13,188 @@ import (
 
 type CategoryService struct {
 	repo   *repositories.CategoryRepository
+	uow    *UnitOfWork
 	logger *zap.Logger
 }
 
-func NewCategoryService(repo *repositories.CategoryRepository, logger *zap.Logger) *CategoryService {
+func NewCategoryService(repo *repositories.CategoryRepository, uow *UnitOfWork, logger *zap.Logger) *CategoryService {
 	return &CategoryService{
 		repo:   repo,
+		uow:    uow,
 		logger: logger,
 	}
 }
 
-func (s *CategoryService) CreateCategory(dto models.CategoryDTO) (*models.CategoryDTO, error) {
-	category := dto.ToModel()
-	err := s.repo.Create(category)
+func (s *CategoryService) CreateCategory(ctx context.Context, dto models.CategoryDTO) (*models.CategoryDTO, error) {
+	ctx, span := startSpan(ctx, "CategoryService.CreateCategory")
+	defer span.End()
+
+	category := servermodels.CategoryFromDTO(&dto)
+	if err := category.Validate(); err != nil {
+		return nil, err
+	}
+	if err := s.checkParent(ctx, category); err != nil {
+		return nil, err
+	}
+	err := s.repo.Create(ctx, category)
 	if err != nil {
-		s.logger.Error("Failed to create category", zap.Error(err))
+		logError(ctx, s.logger, "Failed to create category", err)
 		return nil, err
 	}
-	return category.ToDTO(), nil
+	return s.toDTO(ctx, category)
 }
 
-func (s *CategoryService) GetAllCategories() ([]models.CategoryDTO, error) {
-	categories, err := s.repo.GetAll()
+func (s *CategoryService) GetAllCategories(ctx context.Context) ([]models.CategoryDTO, error) {
+	ctx, span := startSpan(ctx, "CategoryService.GetAllCategories")
+	defer span.End()
+
+	categories, err := s.repo.GetAll(ctx)
 	if err != nil {
-		s.logger.Error("Failed to get all categories", zap.Error(err))
+		logError(ctx, s.logger, "Failed to get all categories", err)
 		return nil, err
 	}
 	categoryDTOs := make([]models.CategoryDTO, len(categories))
-	for i, category := range categories {
-		categoryDTOs[i] = *category.ToDTO()
+	for i := range categories {
+		dto, err := s.toDTO(ctx, &categories[i])
+		if err != nil {
+			return nil, err
+		}
+		categoryDTOs[i] = *dto
 	}
 	return categoryDTOs, nil
 }
 
-func (s *CategoryService) GetCategoryByID(id uint) (*models.CategoryDTO, error) {
-	category, err := s.repo.GetByID(id)
+func (s *CategoryService) GetCategoryByID(ctx context.Context, id uint) (*models.CategoryDTO, error) {
+	ctx, span := startSpan(ctx, "CategoryService.GetCategoryByID")
+	defer span.End()
+
+	category, err := s.repo.GetByID(ctx, id)
 	if err != nil {
-		s.logger.Error("Failed to get category by ID", zap.Error(err), zap.Uint("id", id))
+		logError(ctx, s.logger, "Failed to get category by ID", err, zap.Uint("id", id))
 		return nil, err
 	}
-	return category.ToDTO(), nil
+	return s.toDTO(ctx, category)
 }
 
-func (s *CategoryService) UpdateCategory(id uint, dto models.CategoryDTO) (*models.CategoryDTO, error) {
-	category, err := s.repo.GetByID(id)
+func (s *CategoryService) UpdateCategory(ctx context.Context, id uint, dto models.CategoryDTO) (*models.CategoryDTO, error) {
+	ctx, span := startSpan(ctx, "CategoryService.UpdateCategory")
+	defer span.End()
+
+	category, err := s.repo.GetByID(ctx, id)
 	if err != nil {
-		s.logger.Error("Failed to get category for update", zap.Error(err), zap.Uint("id", id))
+		logError(ctx, s.logger, "Failed to get category for update", err, zap.Uint("id", id))
 		return nil, err
 	}
+	category.ParentID = dto.ParentID
+	category.Position = dto.Position
 	category.Name = dto.Name
 	category.Slug = dto.Slug
-	err = s.repo.Update(category)
+	category.Description = dto.Description
+	category.DefaultSort = dto.DefaultSort
+	category.MaxPosts = dto.MaxPosts
+	category.ReadOnly = dto.ReadOnly
+	if err := category.Validate(); err != nil {
+		return nil, err
+	}
+	if err := s.checkParent(ctx, category); err != nil {
+		return nil, err
+	}
+	err = s.repo.Update(ctx, category)
+	if err != nil {
+		logError(ctx, s.logger, "Failed to update category", err, zap.Uint("id", id))
+		return nil, err
+	}
+	return s.toDTO(ctx, category)
+}
+
+func (s *CategoryService) DeleteCategory(ctx context.Context, id uint) error {
+	ctx, span := startSpan(ctx, "CategoryService.DeleteCategory")
+	defer span.End()
+
+	// Waits for the threads being created in the category, so that none
+	// survives it
+	err := s.uow.Do(ctx, func(repos *repositories.Repositories) error {
+		if _, err := repos.Category.GetByIDForUpdate(ctx, id); err != nil {
+			return err
+		}
+		return repos.Category.Delete(ctx, id)
+	})
+	if err != nil {
+		logError(ctx, s.logger, "Failed to delete category", err, zap.Uint("id", id))
+		return err
+	}
+	return nil
+}
+
+// GetCategoryBySlug returns a category by its slug
+func (s *CategoryService) GetCategoryBySlug(ctx context.Context, slug string) (*models.CategoryDTO, error) {
+	ctx, span := startSpan(ctx, "CategoryService.GetCategoryBySlug")
+	defer span.End()
+
+	category, err := s.repo.GetBySlug(ctx, slug)
+	if err != nil {
+		logError(ctx, s.logger, "Failed to get category by slug", err, zap.String("slug", slug))
+		return nil, err
+	}
+	return s.toDTO(ctx, category)
+}
+
+// GetBoardStats returns the thread and post counts of every category
+func (s *CategoryService) GetBoardStats(ctx context.Context) ([]repositories.BoardStats, error) {
+	ctx, span := startSpan(ctx, "CategoryService.GetBoardStats")
+	defer span.End()
+
+	stats, err := s.repo.GetBoardStats(ctx)
 	if err != nil {
-		s.logger.Error("Failed to update category", zap.Error(err), zap.Uint("id", id))
+		logError(ctx, s.logger, "Failed to get board stats", err)
 		return nil, err
 	}
-	return category.ToDTO(), nil
+	return stats, nil
 }
 
-func (s *CategoryService) DeleteCategory(id uint) error {
-	err := s.repo.Delete(id)
+// checkParent makes sure the parent of a category is an existing top-level
+// category, and that categories with children stay at the top level
+func (s *CategoryService) checkParent(ctx context.Context, category *servermodels.Category) error {
+	if category.ParentID == nil {
+		return nil
+	}
+	if *category.ParentID == category.ID {
+		return models.ErrInvalidInput("parent_id")
+	}
+	parent, err := s.repo.GetByID(ctx, *category.ParentID)
+	if errors.Is(err, repositories.ErrCategoryNotFound) {
+		return models.ErrInvalidInput("parent_id")
+	}
 	if err != nil {
-		s.logger.Error("Failed to delete category", zap.Error(err), zap.Uint("id", id))
+		logError(ctx, s.logger, "Failed to get parent category", err, zap.Uint("parentID", *category.ParentID))
 		return err
 	}
+	if parent.ParentID != nil {
+		return models.ErrInvalidInput("parent_id")
+	}
+	if category.ID == 0 {
+		return nil
+	}
+	hasChildren, err := s.repo.HasChildren(ctx, category.ID)
+	if err != nil {
+		logError(ctx, s.logger, "Failed to check category children", err, zap.Uint("id", category.ID))
+		return err
+	}
+	if hasChildren {
+		return models.ErrInvalidInput("parent_id")
+	}
 	return nil
 }
+
+// toDTO converts a category to a DTO with its thread count and latest activity
+func (s *CategoryService) toDTO(ctx context.Context, category *servermodels.Category) (*models.CategoryDTO, error) {
+	count, lastPostAt, err := s.repo.GetStats(ctx, category)
+	if err != nil {
+		logError(ctx, s.logger, "Failed to get category stats", err, zap.Uint("id", category.ID))
+		return nil, err
+	}
+	dto := category.ToDTO()
+	dto.ThreadCount = count
+	dto.LastPostAt = lastPostAt
+	return dto, nil
+}
@@ -0,0 +1,272 @@
+//go:build integration
+
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+
+	"heisei/internal/common/models"
+	"heisei/internal/server/repositories"
+	"heisei/pkg/database/dbtest"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+func TestMain(m *testing.M) { dbtest.Main(m) }
+
+// newIntegrationServices returns services on a new database; see dbtest for
+// where it is created
+func newIntegrationServices(t *testing.T) (*Services, *gorm.DB) {
+	t.Helper()
+	db := dbtest.New(t)
+	return NewServices(repositories.NewRepositories(db), zap.NewNop()), db
+}
+
+func createThread(t *testing.T, svc *Services) *models.ThreadDTO {
+	t.Helper()
+	ctx := context.Background()
+	category, err := svc.Category.CreateCategory(ctx, models.CategoryDTO{
+		Name:        "News",
+		Slug:        "news",
+		DefaultSort: models.ThreadSortBump,
+		MaxPosts:    models.DefaultMaxPosts,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	thread, err := svc.Thread.CreateThread(ctx, models.ThreadDTO{CategoryID: category.ID, Title: "Counters"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return thread
+}
+
+// createPosts adds posts to a thread and returns them as stored
+func createPosts(t *testing.T, svc *Services, threadID uint, sage ...bool) []*models.PostDTO {
+	t.Helper()
+	ctx := context.Background()
+	posts := make([]*models.PostDTO, len(sage))
+	for i := range sage {
+		post, err := svc.Post.CreatePost(ctx, models.PostDTO{
+			ThreadID: threadID,
+			Content:  fmt.Sprintf("post %d", i),
+			AuthorIP: "192.0.2.1",
+			Sage:     sage[i],
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Read back the creation time as rounded by the database
+		if posts[i], err = svc.Post.GetPostByID(ctx, post.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return posts
+}
+
+func checkCounters(t *testing.T, svc *Services, threadID uint, postCount int, lastPost *models.PostDTO) *models.ThreadDTO {
+	t.Helper()
+	thread, err := svc.Thread.GetThreadByID(context.Background(), threadID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if thread.PostCount != postCount {
+		t.Errorf("post count = %d, want %d", thread.PostCount, postCount)
+	}
+	if !thread.LastPostAt.Equal(lastPost.CreatedAt) {
+		t.Errorf("last post at = %v, want %v", thread.LastPostAt, lastPost.CreatedAt)
+	}
+	return thread
+}
+
+func TestCountersFollowPosts(t *testing.T) {
+	svc, _ := newIntegrationServices(t)
+	ctx := context.Background()
+	thread := createThread(t, svc)
+
+	posts := createPosts(t, svc, thread.ID, false, false, true)
+	// Each post is counted once, and the sage post does not bump the thread
+	got := checkCounters(t, svc, thread.ID, 3, posts[2])
+	if !got.BumpedAt.Equal(posts[1].CreatedAt) {
+		t.Errorf("bumped at = %v, want the time of the last post without sage %v", got.BumpedAt, posts[1].CreatedAt)
+	}
+
+	if err := svc.Post.DeletePost(ctx, posts[2].ID); err != nil {
+		t.Fatal(err)
+	}
+	checkCounters(t, svc, thread.ID, 2, posts[1])
+	// Deleting a deleted post changes nothing
+	if err := svc.Post.DeletePost(ctx, posts[2].ID); err != nil {
+		t.Fatal(err)
+	}
+	checkCounters(t, svc, thread.ID, 2, posts[1])
+
+	if err := svc.Post.RestorePost(ctx, posts[2].ID); err != nil {
+		t.Fatal(err)
+	}
+	checkCounters(t, svc, thread.ID, 3, posts[2])
+
+	discrepancies, err := svc.Thread.ReconcileCounters(ctx, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(discrepancies) != 0 {
+		t.Errorf("discrepancies = %+v, want none", discrepancies)
+	}
+}
+
+func TestEmptyThreadCounters(t *testing.T) {
+	svc, _ := newIntegrationServices(t)
+	ctx := context.Background()
+	thread := createThread(t, svc)
+
+	discrepancies, err := svc.Thread.ReconcileCounters(ctx, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(discrepancies) != 0 {
+		t.Errorf("discrepancies of a new thread = %+v, want none", discrepancies)
+	}
+
+	// A thread whose only post is deleted falls back to its creation time
+	stored, err := svc.Thread.GetThreadByID(ctx, thread.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	posts := createPosts(t, svc, thread.ID, false)
+	if err := svc.Post.DeletePost(ctx, posts[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	checkCounters(t, svc, thread.ID, 0, &models.PostDTO{CreatedAt: stored.CreatedAt})
+}
+
+func TestReconcileCounters(t *testing.T) {
+	svc, db := newIntegrationServices(t)
+	ctx := context.Background()
+	thread := createThread(t, svc)
+	posts := createPosts(t, svc, thread.ID, false, false)
+
+	if err := db.Exec("UPDATE threads SET post_count = 4 WHERE id = ?", thread.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	discrepancies, err := svc.Thread.ReconcileCounters(ctx, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(discrepancies) != 1 || discrepancies[0].ThreadID != thread.ID ||
+		discrepancies[0].PostCount != 4 || discrepancies[0].ActualPostCount != 2 {
+		t.Fatalf("discrepancies = %+v, want thread %d with 4 instead of 2 posts", discrepancies, thread.ID)
+	}
+	// A dry run changes nothing
+	checkCounters(t, svc, thread.ID, 4, posts[1])
+
+	if discrepancies, err = svc.Thread.ReconcileCounters(ctx, true); err != nil {
+		t.Fatal(err)
+	}
+	if len(discrepancies) != 1 {
+		t.Errorf("fixed %d threads, want 1", len(discrepancies))
+	}
+	checkCounters(t, svc, thread.ID, 2, posts[1])
+
+	if discrepancies, err = svc.Thread.ReconcileCounters(ctx, false); err != nil {
+		t.Fatal(err)
+	}
+	if len(discrepancies) != 0 {
+		t.Errorf("discrepancies after fixing = %+v, want none", discrepancies)
+	}
+}
+
+func TestUpdatePost(t *testing.T) {
+	svc, db := newIntegrationServices(t)
+	ctx := context.Background()
+	thread := createThread(t, svc)
+	posts := createPosts(t, svc, thread.ID, false, true)
+
+	// Only the content changes
+	updated, err := svc.Post.UpdatePost(ctx, posts[1].ID, models.PostDTO{Content: "edited"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored, err := svc.Post.GetPostByID(ctx, posts[1].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Content != "edited" || stored.Content != "edited" || !stored.Sage {
+		t.Errorf("post = %+v, want the new content and sage kept", stored)
+	}
+
+	// Deleted posts cannot be edited, and stay deleted
+	if err := svc.Post.DeletePost(ctx, posts[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.Post.UpdatePost(ctx, posts[0].ID, models.PostDTO{Content: "edited"}); !errors.Is(err, repositories.ErrPostNotFound) {
+		t.Errorf("err = %v, want %v", err, repositories.ErrPostNotFound)
+	}
+	if _, err := svc.Post.GetPostByID(ctx, posts[0].ID); !errors.Is(err, repositories.ErrPostNotFound) {
+		t.Errorf("reading a deleted post: err = %v, want %v", err, repositories.ErrPostNotFound)
+	}
+	var content string
+	if err := db.Raw("SELECT content FROM posts WHERE id = ?", posts[0].ID).Scan(&content).Error; err != nil {
+		t.Fatal(err)
+	}
+	if content != posts[0].Content {
+		t.Errorf("content = %q, want it unchanged", content)
+	}
+	// The thread counts the post that is left
+	checkCounters(t, svc, thread.ID, 1, posts[1])
+}
+
+func TestGetPostsByThread(t *testing.T) {
+	svc, db := newIntegrationServices(t)
+	ctx := context.Background()
+	thread := createThread(t, svc)
+	posts := createPosts(t, svc, thread.ID, false, false, false, false)
+	for i, post := range posts {
+		if post.Number != i+1 {
+			t.Errorf("post %d has number %d, want %d", post.ID, post.Number, i+1)
+		}
+	}
+
+	// The posts keep their numbers and order when an earlier post is deleted,
+	// whatever their creation times
+	if err := db.Exec("UPDATE posts SET created_at = created_at - INTERVAL '1 hour' WHERE id = ?", posts[3].ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.Post.DeletePost(ctx, posts[1].ID); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := svc.Post.GetPostsByThread(ctx, thread.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []int{1, 3, 4}
+	numbers := make([]int, len(got))
+	for i, post := range got {
+		numbers[i] = post.Number
+	}
+	if !slices.Equal(numbers, want) {
+		t.Errorf("post numbers = %v, want %v without the deleted post", numbers, want)
+	}
+	stored, err := svc.Thread.GetThreadByID(ctx, thread.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.PostCount != len(got) {
+		t.Errorf("post count = %d, want the %d listed posts", stored.PostCount, len(got))
+	}
+
+	// Numbers are not reused, also after a post is removed for good
+	if err := db.Exec("DELETE FROM posts WHERE id = ?", posts[3].ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if next := createPosts(t, svc, thread.ID, false); next[0].Number != 5 {
+		t.Errorf("new post has number %d, want 5", next[0].Number)
+	}
+}
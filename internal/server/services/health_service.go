@@ -0,0 +1,101 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+
+	"heisei/internal/common/models"
+	"heisei/pkg/database"
+
+	"go.uber.org/zap"
+)
+
+type HealthService struct {
+	db       *database.Database
+	logger   *zap.Logger
+	draining atomic.Bool
+}
+
+func NewHealthService(db *database.Database, logger *zap.Logger) *HealthService {
+	return &HealthService{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// StartDraining marks the server as shutting down so that readiness checks fail
+func (s *HealthService) StartDraining() {
+	s.draining.Store(true)
+}
+
+// IsDraining reports whether the server is shutting down
+func (s *HealthService) IsDraining() bool {
+	return s.draining.Load()
+}
+
+// Liveness reports whether the process is able to serve requests at all
+func (s *HealthService) Liveness() *models.HealthResponse {
+	return &models.HealthResponse{Status: models.HealthStatusOK}
+}
+
+// Readiness checks the dependencies required to serve traffic.
+// The returned bool is false if any of the checks failed.
+func (s *HealthService) Readiness(ctx context.Context) (*models.HealthResponse, bool) {
+	checks := []models.HealthCheckResult{
+		s.check("shutdown", s.checkShutdown),
+		s.check("database", func() error { return s.db.PingContext(ctx) }),
+		s.check("migrations", func() error { return s.checkMigrations(ctx) }),
+	}
+
+	ready := true
+	for _, c := range checks {
+		if c.Status != models.HealthStatusOK {
+			ready = false
+		}
+	}
+
+	status := models.HealthStatusOK
+	if !ready {
+		status = models.HealthStatusFail
+	}
+	return &models.HealthResponse{Status: status, Checks: checks}, ready
+}
+
+func (s *HealthService) check(name string, fn func() error) models.HealthCheckResult {
+	if err := fn(); err != nil {
+		s.logger.Warn("Readiness check failed", zap.String("check", name), zap.Error(err))
+		return models.HealthCheckResult{Name: name, Status: models.HealthStatusFail, Error: err.Error()}
+	}
+	return models.HealthCheckResult{Name: name, Status: models.HealthStatusOK}
+}
+
+func (s *HealthService) checkShutdown() error {
+	if s.IsDraining() {
+		return fmt.Errorf("server is shutting down")
+	}
+	return nil
+}
+
+func (s *HealthService) checkMigrations(ctx context.Context) error {
+	expected, err := database.LatestMigrationVersion()
+	if err != nil {
+		return fmt.Errorf("failed to read migration files: %w", err)
+	}
+
+	sqlDB, err := s.db.DB.DB()
+	if err != nil {
+		return fmt.Errorf("failed to get database instance: %w", err)
+	}
+	current, dirty, err := database.MigrationVersion(ctx, sqlDB)
+	if err != nil {
+		return fmt.Errorf("failed to read migration version: %w", err)
+	}
+	if dirty {
+		return fmt.Errorf("migration version %d is dirty", current)
+	}
+	if current != expected {
+		return fmt.Errorf("migration version %d does not match expected version %d", current, expected)
+	}
+	return nil
+}
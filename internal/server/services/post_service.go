@@ -1,62 +1,102 @@
 package services
 
 import (
+	"context"
+
 	"heisei/internal/common/models"
+	servermodels "heisei/internal/server/models"
 	"heisei/internal/server/repositories"
 
 	"go.uber.org/zap"
 )
 
 type PostService struct {
-	repo          *repositories.PostRepository
-	threadRepo    *repositories.ThreadRepository
-	threadService *ThreadService
-	logger        *zap.Logger
+	repo       *repositories.PostRepository
+	banService *BanService
+	uow        *UnitOfWork
+	logger     *zap.Logger
 }
 
-func NewPostService(repo *repositories.PostRepository, threadRepo *repositories.ThreadRepository, threadService *ThreadService, logger *zap.Logger) *PostService {
+func NewPostService(repo *repositories.PostRepository, banService *BanService, uow *UnitOfWork, logger *zap.Logger) *PostService {
 	return &PostService{
-		repo:          repo,
-		threadRepo:    threadRepo,
-		threadService: threadService,
-		logger:        logger,
+		repo:       repo,
+		banService: banService,
+		uow:        uow,
+		logger:     logger,
 	}
 }
 
-func (s *PostService) CreatePost(dto models.PostDTO) (*models.PostDTO, error) {
-	post := dto.ToModel()
-	err := s.repo.Create(post)
-	if err != nil {
-		s.logger.Error("Failed to create post", zap.Error(err))
+func (s *PostService) CreatePost(ctx context.Context, dto models.PostDTO) (*models.PostDTO, error) {
+	ctx, span := startSpan(ctx, "PostService.CreatePost")
+	defer span.End()
+
+	post := servermodels.PostFromDTO(&dto)
+	if err := post.Validate(); err != nil {
 		return nil, err
 	}
-
-	// Update thread's post count and last post time
-	err = s.threadService.IncrementPostCount(post.ThreadID)
-	if err != nil {
-		s.logger.Error("Failed to increment thread post count", zap.Error(err), zap.Uint("threadID", post.ThreadID))
+	if err := s.banService.CheckBanned(ctx, post.AuthorIP); err != nil {
+		return nil, err
 	}
-	err = s.threadService.UpdateLastPostAt(post.ThreadID)
+	// The thread stays locked until the post is stored, so that concurrent
+	// posts cannot fill it beyond the limit of its category
+	err := s.uow.Do(ctx, func(repos *repositories.Repositories) error {
+		// Clear the ID assigned by a failed attempt
+		post.ID = 0
+		thread, err := repos.Thread.GetByIDForUpdate(ctx, post.ThreadID)
+		if err != nil {
+			return err
+		}
+		if thread.Locked {
+			return models.ErrThreadLocked(thread.ID)
+		}
+		category, err := repos.Category.GetByID(ctx, thread.CategoryID)
+		if err != nil {
+			logError(ctx, s.logger, "Failed to get category of thread", err, zap.Uint("threadID", thread.ID))
+			return err
+		}
+		if category.ReadOnly {
+			return models.ErrCategoryReadOnly(category.ID)
+		}
+		if category.IsFull(thread.PostCount) {
+			return models.ErrThreadFull(thread.ID)
+		}
+		// The post count, last post and bump times of the thread are updated
+		// by trigger in the same statement
+		if err := repos.Post.Create(ctx, post); err != nil {
+			logError(ctx, s.logger, "Failed to create post", err)
+			return err
+		}
+		return nil
+	})
 	if err != nil {
-		s.logger.Error("Failed to update thread last post time", zap.Error(err), zap.Uint("threadID", post.ThreadID))
+		return nil, err
 	}
-
 	return post.ToDTO(), nil
 }
 
-func (s *PostService) GetPostByID(id uint) (*models.PostDTO, error) {
-	post, err := s.repo.GetByID(id)
+// GetPostByID returns a post that is not deleted
+func (s *PostService) GetPostByID(ctx context.Context, id uint) (*models.PostDTO, error) {
+	ctx, span := startSpan(ctx, "PostService.GetPostByID")
+	defer span.End()
+
+	post, err := s.repo.GetByID(ctx, id)
 	if err != nil {
-		s.logger.Error("Failed to get post by ID", zap.Error(err), zap.Uint("id", id))
+		logError(ctx, s.logger, "Failed to get post by ID", err, zap.Uint("id", id))
 		return nil, err
 	}
+	if post.IsDeleted {
+		return nil, repositories.ErrPostNotFound
+	}
 	return post.ToDTO(), nil
 }
 
-func (s *PostService) GetPostsByThread(threadID uint) ([]models.PostDTO, error) {
-	posts, err := s.repo.GetByThread(threadID)
+func (s *PostService) GetPostsByThread(ctx context.Context, threadID uint) ([]models.PostDTO, error) {
+	ctx, span := startSpan(ctx, "PostService.GetPostsByThread")
+	defer span.End()
+
+	posts, err := s.repo.GetByThread(ctx, threadID)
 	if err != nil {
-		s.logger.Error("Failed to get posts by thread", zap.Error(err), zap.Uint("threadID", threadID))
+		logError(ctx, s.logger, "Failed to get posts by thread", err, zap.Uint("threadID", threadID))
 		return nil, err
 	}
 	postDTOs := make([]models.PostDTO, len(posts))
@@ -66,43 +106,95 @@ func (s *PostService) GetPostsByThread(threadID uint) ([]models.PostDTO, error)
 	return postDTOs, nil
 }
 
-func (s *PostService) UpdatePost(id uint, dto models.PostDTO) (*models.PostDTO, error) {
-	post, err := s.repo.GetByID(id)
+func (s *PostService) UpdatePost(ctx context.Context, id uint, dto models.PostDTO) (*models.PostDTO, error) {
+	ctx, span := startSpan(ctx, "PostService.UpdatePost")
+	defer span.End()
+
+	post, err := s.repo.GetByID(ctx, id)
 	if err != nil {
-		s.logger.Error("Failed to get post for update", zap.Error(err), zap.Uint("id", id))
+		logError(ctx, s.logger, "Failed to get post for update", err, zap.Uint("id", id))
 		return nil, err
 	}
+	// Deleted posts cannot be edited
+	if post.IsDeleted {
+		return nil, repositories.ErrPostNotFound
+	}
 	post.Content = dto.Content
-	err = s.repo.Update(post)
+	if err := post.Validate(); err != nil {
+		return nil, err
+	}
+	err = s.repo.Update(ctx, post)
 	if err != nil {
-		s.logger.Error("Failed to update post", zap.Error(err), zap.Uint("id", id))
+		logError(ctx, s.logger, "Failed to update post", err, zap.Uint("id", id))
 		return nil, err
 	}
 	return post.ToDTO(), nil
 }
 
-func (s *PostService) DeletePost(id uint) error {
-	err := s.repo.SoftDelete(id)
+func (s *PostService) DeletePost(ctx context.Context, id uint) error {
+	ctx, span := startSpan(ctx, "PostService.DeletePost")
+	defer span.End()
+
+	err := s.moderate(ctx, id, func(repos *repositories.Repositories) error {
+		return repos.Post.SoftDelete(ctx, id)
+	})
 	if err != nil {
-		s.logger.Error("Failed to soft delete post", zap.Error(err), zap.Uint("id", id))
+		logError(ctx, s.logger, "Failed to soft delete post", err, zap.Uint("id", id))
 		return err
 	}
 	return nil
 }
 
-func (s *PostService) GetPostCountByThread(threadID uint) (int64, error) {
-	count, err := s.repo.GetPostCountByThread(threadID)
+// RestorePost undoes the deletion of a post
+func (s *PostService) RestorePost(ctx context.Context, id uint) error {
+	ctx, span := startSpan(ctx, "PostService.RestorePost")
+	defer span.End()
+
+	err := s.moderate(ctx, id, func(repos *repositories.Repositories) error {
+		return repos.Post.Restore(ctx, id)
+	})
 	if err != nil {
-		s.logger.Error("Failed to get post count by thread", zap.Error(err), zap.Uint("threadID", threadID))
+		logError(ctx, s.logger, "Failed to restore post", err, zap.Uint("id", id))
+		return err
+	}
+	return nil
+}
+
+// moderate runs fn on a post in a unit of work. The thread of the post is
+// locked first, in the same order as by CreatePost, as the triggers of the
+// post update the thread.
+func (s *PostService) moderate(ctx context.Context, id uint, fn func(repos *repositories.Repositories) error) error {
+	return s.uow.Do(ctx, func(repos *repositories.Repositories) error {
+		post, err := repos.Post.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if _, err := repos.Thread.GetByIDForUpdate(ctx, post.ThreadID); err != nil {
+			return err
+		}
+		return fn(repos)
+	})
+}
+
+func (s *PostService) GetPostCountByThread(ctx context.Context, threadID uint) (int64, error) {
+	ctx, span := startSpan(ctx, "PostService.GetPostCountByThread")
+	defer span.End()
+
+	count, err := s.repo.GetPostCountByThread(ctx, threadID)
+	if err != nil {
+		logError(ctx, s.logger, "Failed to get post count by thread", err, zap.Uint("threadID", threadID))
 		return 0, err
 	}
 	return count, nil
 }
 
-func (s *PostService) GetLatestPostByThread(threadID uint) (*models.PostDTO, error) {
-	post, err := s.repo.GetLatestPostByThread(threadID)
+func (s *PostService) GetLatestPostByThread(ctx context.Context, threadID uint) (*models.PostDTO, error) {
+	ctx, span := startSpan(ctx, "PostService.GetLatestPostByThread")
+	defer span.End()
+
+	post, err := s.repo.GetLatestPostByThread(ctx, threadID)
 	if err != nil {
-		s.logger.Error("Failed to get latest post by thread", zap.Error(err), zap.Uint("threadID", threadID))
+		logError(ctx, s.logger, "Failed to get latest post by thread", err, zap.Uint("threadID", threadID))
 		return nil, err
 	}
 	return post.ToDTO(), nil
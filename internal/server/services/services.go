@@ -0,0 +1,30 @@
+package services
+
+import (
+	"heisei/internal/server/repositories"
+	"heisei/pkg/database"
+
+	"go.uber.org/zap"
+)
+
+// Services groups the services used by the handlers
+type Services struct {
+	Category *CategoryService
+	Thread   *ThreadService
+	Post     *PostService
+	Ban      *BanService
+	Health   *HealthService
+}
+
+// NewServices creates all services on top of the given repositories
+func NewServices(repos *repositories.Repositories, logger *zap.Logger) *Services {
+	uow := NewUnitOfWork(repos.DB(), logger)
+	banService := NewBanService(repos.Ban, logger)
+	return &Services{
+		Category: NewCategoryService(repos.Category, uow, logger),
+		Thread:   NewThreadService(repos.Thread, repos.Category, uow, logger),
+		Post:     NewPostService(repos.Post, banService, uow, logger),
+		Ban:      banService,
+		Health:   NewHealthService(&database.Database{DB: repos.DB()}, logger),
+	}
+}
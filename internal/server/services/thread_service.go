@@ -1,38 +1,77 @@
 package services
 
 import (
+	"context"
+	"time"
+
 	"heisei/internal/common/models"
+	servermodels "heisei/internal/server/models"
 	"heisei/internal/server/repositories"
 
 	"go.uber.org/zap"
 )
 
 type ThreadService struct {
-	repo   *repositories.ThreadRepository
-	logger *zap.Logger
+	repo         *repositories.ThreadRepository
+	categoryRepo *repositories.CategoryRepository
+	uow          *UnitOfWork
+	logger       *zap.Logger
 }
 
-func NewThreadService(repo *repositories.ThreadRepository, logger *zap.Logger) *ThreadService {
+func NewThreadService(repo *repositories.ThreadRepository, categoryRepo *repositories.CategoryRepository, uow *UnitOfWork, logger *zap.Logger) *ThreadService {
 	return &ThreadService{
-		repo:   repo,
-		logger: logger,
+		repo:         repo,
+		categoryRepo: categoryRepo,
+		uow:          uow,
+		logger:       logger,
 	}
 }
 
-func (s *ThreadService) CreateThread(dto models.ThreadDTO) (*models.ThreadDTO, error) {
-	thread := dto.ToModel()
-	err := s.repo.Create(thread)
+func (s *ThreadService) CreateThread(ctx context.Context, dto models.ThreadDTO) (*models.ThreadDTO, error) {
+	ctx, span := startSpan(ctx, "ThreadService.CreateThread")
+	defer span.End()
+
+	thread := servermodels.ThreadFromDTO(&dto)
+	if err := thread.Validate(); err != nil {
+		return nil, err
+	}
+	// The category is kept from being deleted or made read-only until the
+	// thread is stored
+	err := s.uow.Do(ctx, func(repos *repositories.Repositories) error {
+		category, err := repos.Category.GetByIDForShare(ctx, thread.CategoryID)
+		if err != nil {
+			return err
+		}
+		if category.ReadOnly {
+			return models.ErrCategoryReadOnly(category.ID)
+		}
+		// Clear the ID assigned by a failed attempt
+		thread.ID = 0
+		// A new thread starts at the top of the bump order. Its counters are
+		// kept up to date by the triggers of the posts table.
+		thread.CreatedAt = time.Now()
+		thread.LastPostAt = thread.CreatedAt
+		thread.BumpedAt = thread.CreatedAt
+		thread.PostCount = 0
+		if err := repos.Thread.Create(ctx, thread); err != nil {
+			logError(ctx, s.logger, "Failed to create thread", err)
+			return err
+		}
+		return nil
+	})
 	if err != nil {
-		s.logger.Error("Failed to create thread", zap.Error(err))
 		return nil, err
 	}
 	return thread.ToDTO(), nil
 }
 
-func (s *ThreadService) GetAllThreads() ([]models.ThreadDTO, error) {
-	threads, err := s.repo.GetAll()
+func (s *ThreadService) GetAllThreads(ctx context.Context) ([]models.ThreadDTO, error) {
+	ctx, span := startSpan(ctx, "ThreadService.GetAllThreads")
+	defer span.End()
+
+	threads, err := s.repo.GetAll(ctx)
 	if err != nil {
-		s.logger.Error("Failed to get all threads", zap.Error(err))
+		logError(ctx, s.logger, "Failed to get all threads", err)
 		return nil, err
 	}
 	threadDTOs := make([]models.ThreadDTO, len(threads))
@@ -42,19 +81,35 @@ func (s *ThreadService) GetAllThreads() ([]models.ThreadDTO, error) {
 	return threadDTOs, nil
 }
 
-func (s *ThreadService) GetThreadByID(id uint) (*models.ThreadDTO, error) {
-	thread, err := s.repo.GetByID(id)
+func (s *ThreadService) GetThreadByID(ctx context.Context, id uint) (*models.ThreadDTO, error) {
+	ctx, span := startSpan(ctx, "ThreadService.GetThreadByID")
+	defer span.End()
+
+	thread, err := s.repo.GetByID(ctx, id)
 	if err != nil {
-		s.logger.Error("Failed to get thread by ID", zap.Error(err), zap.Uint("id", id))
+		logError(ctx, s.logger, "Failed to get thread by ID", err, zap.Uint("id", id))
 		return nil, err
 	}
 	return thread.ToDTO(), nil
 }
 
-func (s *ThreadService) GetThreadsByCategory(categoryID uint) ([]models.ThreadDTO, error) {
-	threads, err := s.repo.GetByCategory(categoryID)
+// GetThreadsByCategory lists the threads of a category in the given sort
+// order, or in the default order of the category if sort is empty
+func (s *ThreadService) GetThreadsByCategory(ctx context.Context, categoryID uint, sort string) ([]models.ThreadDTO, error) {
+	ctx, span := startSpan(ctx, "ThreadService.GetThreadsByCategory")
+	defer span.End()
+
+	category, err := s.categoryRepo.GetByID(ctx, categoryID)
+	if err != nil {
+		logError(ctx, s.logger, "Failed to get category of threads", err, zap.Uint("categoryID", categoryID))
+		return nil, err
+	}
+	if sort == "" {
+		sort = category.DefaultSort
+	}
+	threads, err := s.repo.GetByCategory(ctx, categoryID, sort)
 	if err != nil {
-		s.logger.Error("Failed to get threads by category", zap.Error(err), zap.Uint("categoryID", categoryID))
+		logError(ctx, s.logger, "Failed to get threads by category", err, zap.Uint("categoryID", categoryID))
 		return nil, err
 	}
 	threadDTOs := make([]models.ThreadDTO, len(threads))
@@ -64,44 +119,171 @@ func (s *ThreadService) GetThreadsByCategory(categoryID uint) ([]models.ThreadDT
 	return threadDTOs, nil
 }
 
-func (s *ThreadService) UpdateThread(id uint, dto models.ThreadDTO) (*models.ThreadDTO, error) {
-	thread, err := s.repo.GetByID(id)
+func (s *ThreadService) SearchThreads(ctx context.Context, categoryID uint, query string) ([]models.ThreadDTO, error) {
+	ctx, span := startSpan(ctx, "ThreadService.SearchThreads")
+	defer span.End()
+
+	threads, err := s.repo.Search(ctx, categoryID, query)
 	if err != nil {
-		s.logger.Error("Failed to get thread for update", zap.Error(err), zap.Uint("id", id))
+		logError(ctx, s.logger, "Failed to search threads", err, zap.Uint("categoryID", categoryID), zap.String("query", query))
+		return nil, err
+	}
+	threadDTOs := make([]models.ThreadDTO, len(threads))
+	for i, thread := range threads {
+		threadDTOs[i] = *thread.ToDTO()
+	}
+	return threadDTOs, nil
+}
+
+// UpdateThread changes the title and category of a thread. The thread is
+// locked while it changes, and its counters are left to the triggers.
+// Threads in a read-only category can neither be changed nor moved out.
+func (s *ThreadService) UpdateThread(ctx context.Context, id uint, dto models.ThreadDTO) (*models.ThreadDTO, error) {
+	ctx, span := startSpan(ctx, "ThreadService.UpdateThread")
+	defer span.End()
+
+	if err := servermodels.ThreadFromDTO(&dto).Validate(); err != nil {
 		return nil, err
 	}
-	thread.Title = dto.Title
-	thread.CategoryID = dto.CategoryID
-	err = s.repo.Update(thread)
+	var thread *servermodels.Thread
+	err := s.uow.Do(ctx, func(repos *repositories.Repositories) error {
+		category, err := repos.Category.GetByIDForShare(ctx, dto.CategoryID)
+		if err != nil {
+			return err
+		}
+		if category.ReadOnly {
+			return models.ErrCategoryReadOnly(category.ID)
+		}
+		if thread, err = repos.Thread.GetByIDForUpdate(ctx, id); err != nil {
+			return err
+		}
+		if thread.CategoryID != category.ID {
+			current, err := repos.Category.GetByIDForShare(ctx, thread.CategoryID)
+			if err != nil {
+				return err
+			}
+			if current.ReadOnly {
+				return models.ErrCategoryReadOnly(current.ID)
+			}
+		}
+		thread.Title = dto.Title
+		thread.CategoryID = dto.CategoryID
+		return repos.Thread.Update(ctx, thread)
+	})
 	if err != nil {
-		s.logger.Error("Failed to update thread", zap.Error(err), zap.Uint("id", id))
+		logError(ctx, s.logger, "Failed to update thread", err, zap.Uint("id", id))
 		return nil, err
 	}
 	return thread.ToDTO(), nil
 }
 
-func (s *ThreadService) DeleteThread(id uint) error {
-	err := s.repo.Delete(id)
+// SetLocked locks or unlocks a thread; locked threads take no new posts
+func (s *ThreadService) SetLocked(ctx context.Context, id uint, locked bool) error {
+	ctx, span := startSpan(ctx, "ThreadService.SetLocked")
+	defer span.End()
+
+	// Waits for the posts being added to the thread
+	err := s.uow.Do(ctx, func(repos *repositories.Repositories) error {
+		if _, err := repos.Thread.GetByIDForUpdate(ctx, id); err != nil {
+			return err
+		}
+		return repos.Thread.SetLocked(ctx, id, locked)
+	})
 	if err != nil {
-		s.logger.Error("Failed to delete thread", zap.Error(err), zap.Uint("id", id))
+		logError(ctx, s.logger, "Failed to lock thread", err, zap.Uint("id", id), zap.Bool("locked", locked))
 		return err
 	}
 	return nil
 }
 
-func (s *ThreadService) IncrementPostCount(threadID uint) error {
-	err := s.repo.IncrementPostCount(threadID)
+// MoveThread moves a thread to another category
+func (s *ThreadService) MoveThread(ctx context.Context, id, categoryID uint) (*models.ThreadDTO, error) {
+	ctx, span := startSpan(ctx, "ThreadService.MoveThread")
+	defer span.End()
+
+	var thread *servermodels.Thread
+	err := s.uow.Do(ctx, func(repos *repositories.Repositories) error {
+		if _, err := repos.Category.GetByIDForShare(ctx, categoryID); err != nil {
+			return err
+		}
+		var err error
+		if thread, err = repos.Thread.GetByIDForUpdate(ctx, id); err != nil {
+			return err
+		}
+		thread.CategoryID = categoryID
+		return repos.Thread.Update(ctx, thread)
+	})
 	if err != nil {
-		s.logger.Error("Failed to increment post count", zap.Error(err), zap.Uint("threadID", threadID))
-		return err
+		logError(ctx, s.logger, "Failed to move thread", err, zap.Uint("id", id), zap.Uint("categoryID", categoryID))
+		return nil, err
 	}
-	return nil
+	return thread.ToDTO(), nil
+}
+
+// ReconcileCounters checks the post counts and last post times of all
+// threads against their posts, and corrects them if fix is set. It returns
+// the threads whose counters were wrong.
+func (s *ThreadService) ReconcileCounters(ctx context.Context, fix bool) ([]repositories.CounterDiscrepancy, error) {
+	ctx, span := startSpan(ctx, "ThreadService.ReconcileCounters")
+	defer span.End()
+
+	var discrepancies []repositories.CounterDiscrepancy
+	var err error
+	if fix {
+		err = s.uow.Do(ctx, func(repos *repositories.Repositories) error {
+			var err error
+			discrepancies, err = repos.Thread.RebuildCounters(ctx)
+			return err
+		})
+	} else {
+		discrepancies, err = s.repo.FindCounterDiscrepancies(ctx)
+	}
+	if err != nil {
+		logError(ctx, s.logger, "Failed to reconcile thread counters", err)
+		return nil, err
+	}
+	for _, d := range discrepancies {
+		s.logger.Warn("Thread counters differ from posts",
+			zap.Uint("threadID", d.ThreadID),
+			zap.Int("postCount", d.PostCount),
+			zap.Int("actualPostCount", d.ActualPostCount),
+			zap.Time("lastPostAt", d.LastPostAt),
+			zap.Time("actualLastPostAt", d.ActualLastPostAt),
+			zap.Bool("fixed", fix))
+	}
+	return discrepancies, nil
 }
 
-func (s *ThreadService) UpdateLastPostAt(threadID uint) error {
-	err := s.repo.UpdateLastPostAt(threadID)
+// RunCounterReconciliation corrects the thread counters every interval until
+// ctx is done
+func (s *ThreadService) RunCounterReconciliation(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			// Errors have been logged
+			s.ReconcileCounters(ctx, true)
+		}
+	}
+}
+
+func (s *ThreadService) DeleteThread(ctx context.Context, id uint) error {
+	ctx, span := startSpan(ctx, "ThreadService.DeleteThread")
+	defer span.End()
+
+	// Waits for the posts being added to the thread, which would otherwise
+	// lock the thread and its posts in the opposite order
+	err := s.uow.Do(ctx, func(repos *repositories.Repositories) error {
+		if _, err := repos.Thread.GetByIDForUpdate(ctx, id); err != nil {
+			return err
+		}
+		return repos.Thread.Delete(ctx, id)
+	})
 	if err != nil {
-		s.logger.Error("Failed to update last post time", zap.Error(err), zap.Uint("threadID", threadID))
+		logError(ctx, s.logger, "Failed to delete thread", err, zap.Uint("id", id))
 		return err
 	}
 	return nil
@@ -0,0 +1,30 @@
+package services
+
+import (
+	"context"
+
+	"heisei/pkg/utils"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+var tracer = otel.Tracer("heisei/internal/server/services")
+
+// startSpan starts a span for a service method
+func startSpan(ctx context.Context, name string) (context.Context, trace.Span) {
+	return tracer.Start(ctx, name)
+}
+
+// logError records err on the current span and logs it with the request and trace IDs of ctx
+func logError(ctx context.Context, logger *zap.Logger, msg string, err error, fields ...zap.Field) {
+	span := trace.SpanFromContext(ctx)
+	span.RecordError(err)
+	span.SetStatus(codes.Error, msg)
+
+	fields = append(fields, zap.Error(err))
+	fields = append(fields, utils.RequestFields(ctx)...)
+	logger.Error(msg, fields...)
+}
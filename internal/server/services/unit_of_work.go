@@ -0,0 +1,93 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"time"
+
+	"heisei/internal/server/repositories"
+	"heisei/pkg/utils"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// PostgreSQL error codes of the failures that succeed when the transaction is
+// run again
+const (
+	pgSerializationFailure = "40001"
+	pgDeadlockDetected     = "40P01"
+)
+
+// defaultMaxAttempts is the number of times a unit of work is run before its
+// error is returned
+const defaultMaxAttempts = 3
+
+// retryBackoff is the base delay before running a unit of work again; it
+// grows with each attempt and is jittered so that the transactions that
+// collided do not collide again
+const retryBackoff = 20 * time.Millisecond
+
+// UnitOfWork runs service operations in transactions
+type UnitOfWork struct {
+	db          *gorm.DB
+	logger      *zap.Logger
+	maxAttempts int
+}
+
+func NewUnitOfWork(db *gorm.DB, logger *zap.Logger) *UnitOfWork {
+	return &UnitOfWork{
+		db:          db,
+		logger:      logger,
+		maxAttempts: defaultMaxAttempts,
+	}
+}
+
+// Do runs fn in a transaction with repositories bound to it. The transaction
+// is committed if fn returns nil and rolled back otherwise. fn is run again
+// after serialization failures and deadlocks, so it must not have effects
+// outside the transaction.
+func (u *UnitOfWork) Do(ctx context.Context, fn func(repos *repositories.Repositories) error) error {
+	return retry(ctx, u.maxAttempts, func() error {
+		return repositories.WithTransaction(ctx, u.db, func(tx *gorm.DB) error {
+			return fn(repositories.NewRepositories(tx))
+		})
+	}, func(attempt int, err error) {
+		fields := append([]zap.Field{zap.Int("attempt", attempt), zap.Error(err)}, utils.RequestFields(ctx)...)
+		u.logger.Warn("Retrying transaction", fields...)
+	})
+}
+
+// retry calls fn until it succeeds, fails with an error that is not
+// retryable, or was called attempts times. onRetry is called before each
+// further attempt.
+func retry(ctx context.Context, attempts int, fn func() error, onRetry func(attempt int, err error)) error {
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= attempts || !isRetryable(err) {
+			return err
+		}
+		onRetry(attempt, err)
+
+		delay := retryBackoff * time.Duration(attempt)
+		delay += time.Duration(rand.Int63n(int64(delay)))
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+// isRetryable reports whether err is a serialization failure or a deadlock
+func isRetryable(err error) bool {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return false
+	}
+	return pgErr.Code == pgSerializationFailure || pgErr.Code == pgDeadlockDetected
+}
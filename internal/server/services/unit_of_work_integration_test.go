@@ -0,0 +1,218 @@
+//go:build integration
+
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"heisei/internal/common/models"
+)
+
+func TestConcurrentPostsRespectMaxPosts(t *testing.T) {
+	svc, _ := newIntegrationServices(t)
+	ctx := context.Background()
+	const maxPosts, posters = 5, 20
+
+	category, err := svc.Category.CreateCategory(ctx, models.CategoryDTO{
+		Name:        "Small",
+		Slug:        "small",
+		DefaultSort: models.ThreadSortBump,
+		MaxPosts:    maxPosts,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	thread, err := svc.Thread.CreateThread(ctx, models.ThreadDTO{CategoryID: category.ID, Title: "Race"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	errs := make([]error, posters)
+	var wg sync.WaitGroup
+	for i := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[i] = svc.Post.CreatePost(ctx, models.PostDTO{
+				ThreadID: thread.ID,
+				Content:  fmt.Sprintf("post %d", i),
+				AuthorIP: "192.0.2.1",
+			})
+		}()
+	}
+	wg.Wait()
+
+	created := 0
+	for _, err := range errs {
+		var appErr *models.AppError
+		switch {
+		case err == nil:
+			created++
+		case errors.As(err, &appErr) && appErr.Message == models.ErrThreadFull(thread.ID).Message:
+		default:
+			t.Errorf("unexpected error: %v", err)
+		}
+	}
+	if created != maxPosts {
+		t.Errorf("created %d posts, want %d", created, maxPosts)
+	}
+	stored, err := svc.Thread.GetThreadByID(ctx, thread.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.PostCount != maxPosts {
+		t.Errorf("post count = %d, want %d", stored.PostCount, maxPosts)
+	}
+}
+
+func TestUpdateThreadKeepsCounters(t *testing.T) {
+	svc, _ := newIntegrationServices(t)
+	ctx := context.Background()
+	thread := createThread(t, svc)
+	const posters = 10
+
+	// Title changes running next to new posts do not write back stale counters
+	errs := make([]error, 2*posters)
+	var wg sync.WaitGroup
+	for i := 0; i < posters; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			_, errs[2*i] = svc.Post.CreatePost(ctx, models.PostDTO{
+				ThreadID: thread.ID,
+				Content:  fmt.Sprintf("post %d", i),
+				AuthorIP: "192.0.2.1",
+			})
+		}()
+		go func() {
+			defer wg.Done()
+			_, errs[2*i+1] = svc.Thread.UpdateThread(ctx, thread.ID, models.ThreadDTO{
+				CategoryID: thread.CategoryID,
+				Title:      fmt.Sprintf("Title %d", i),
+			})
+		}()
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	stored, err := svc.Thread.GetThreadByID(ctx, thread.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.PostCount != posters {
+		t.Errorf("post count = %d, want %d", stored.PostCount, posters)
+	}
+	discrepancies, err := svc.Thread.ReconcileCounters(ctx, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(discrepancies) != 0 {
+		t.Errorf("discrepancies = %+v, want none", discrepancies)
+	}
+}
+
+func TestUpdateThreadIntoReadOnlyCategory(t *testing.T) {
+	svc, db := newIntegrationServices(t)
+	ctx := context.Background()
+	thread := createThread(t, svc)
+	archive, err := svc.Category.CreateCategory(ctx, models.CategoryDTO{
+		Name:        "Archive",
+		Slug:        "archive",
+		DefaultSort: models.ThreadSortBump,
+		MaxPosts:    models.DefaultMaxPosts,
+		ReadOnly:    true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = svc.Thread.UpdateThread(ctx, thread.ID, models.ThreadDTO{CategoryID: archive.ID, Title: thread.Title})
+	var appErr *models.AppError
+	if !errors.As(err, &appErr) || appErr.Message != models.ErrCategoryReadOnly(archive.ID).Message {
+		t.Fatalf("err = %v, want the category to be read-only", err)
+	}
+	stored, err := svc.Thread.GetThreadByID(ctx, thread.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.CategoryID != thread.CategoryID {
+		t.Errorf("category = %d, want the thread to stay in %d", stored.CategoryID, thread.CategoryID)
+	}
+
+	// Nor can a thread be renamed or moved out of a read-only category
+	if err := db.Exec("UPDATE categories SET read_only = false WHERE id = ?", archive.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Exec("UPDATE categories SET read_only = true WHERE id = ?", thread.CategoryID).Error; err != nil {
+		t.Fatal(err)
+	}
+	for _, dto := range []models.ThreadDTO{
+		{CategoryID: thread.CategoryID, Title: "Renamed"},
+		{CategoryID: archive.ID, Title: thread.Title},
+	} {
+		_, err = svc.Thread.UpdateThread(ctx, thread.ID, dto)
+		if !errors.As(err, &appErr) || appErr.Message != models.ErrCategoryReadOnly(thread.CategoryID).Message {
+			t.Errorf("update to %+v: err = %v, want the category of the thread to be read-only", dto, err)
+		}
+	}
+	if stored, err = svc.Thread.GetThreadByID(ctx, thread.ID); err != nil {
+		t.Fatal(err)
+	}
+	if stored.CategoryID != thread.CategoryID || stored.Title != thread.Title {
+		t.Errorf("thread = %+v, want it unchanged", stored)
+	}
+}
+
+func TestRebuildCountersNextToNewPosts(t *testing.T) {
+	svc, db := newIntegrationServices(t)
+	ctx := context.Background()
+	thread := createThread(t, svc)
+	const posters = 20
+
+	// Rebuilding the counters while posts are added keeps the posts counted
+	errs := make([]error, posters)
+	var wg sync.WaitGroup
+	for i := range errs {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			_, errs[i] = svc.Post.CreatePost(ctx, models.PostDTO{
+				ThreadID: thread.ID,
+				Content:  fmt.Sprintf("post %d", i),
+				AuthorIP: "192.0.2.1",
+			})
+		}()
+		go func() {
+			defer wg.Done()
+			if err := db.Exec("UPDATE threads SET post_count = post_count + 100 WHERE id = ?", thread.ID).Error; err != nil {
+				t.Error(err)
+			}
+			if _, err := svc.Thread.ReconcileCounters(ctx, true); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Each corruption was followed by a rebuild
+	stored, err := svc.Thread.GetThreadByID(ctx, thread.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.PostCount != posters {
+		t.Errorf("post count = %d, want %d", stored.PostCount, posters)
+	}
+}
@@ -0,0 +1,66 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestRetry(t *testing.T) {
+	serialization := fmt.Errorf("failed to commit transaction: %w", &pgconn.PgError{Code: pgSerializationFailure})
+	deadlock := &pgconn.PgError{Code: pgDeadlockDetected}
+	uniqueViolation := &pgconn.PgError{Code: "23505"}
+	other := errors.New("thread not found")
+
+	tests := []struct {
+		name  string
+		errs  []error
+		calls int
+		want  error
+	}{
+		{"success", []error{nil}, 1, nil},
+		{"serialization failure", []error{serialization, nil}, 2, nil},
+		{"deadlock", []error{deadlock, deadlock, nil}, 3, nil},
+		{"attempts exhausted", []error{deadlock, serialization, deadlock}, 3, deadlock},
+		{"other database error", []error{uniqueViolation}, 1, uniqueViolation},
+		{"other error", []error{other}, 1, other},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls, retries := 0, 0
+			err := retry(context.Background(), 3, func() error {
+				calls++
+				return tt.errs[calls-1]
+			}, func(attempt int, err error) {
+				retries++
+				if attempt != calls {
+					t.Errorf("retry after attempt %d, want %d", attempt, calls)
+				}
+			})
+			if err != tt.want {
+				t.Errorf("err = %v, want %v", err, tt.want)
+			}
+			if calls != tt.calls || retries != tt.calls-1 {
+				t.Errorf("%d calls and %d retries, want %d and %d", calls, retries, tt.calls, tt.calls-1)
+			}
+		})
+	}
+}
+
+func TestRetryStopsWhenContextIsDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	deadlock := &pgconn.PgError{Code: pgDeadlockDetected}
+
+	calls := 0
+	err := retry(ctx, 3, func() error {
+		calls++
+		return deadlock
+	}, func(int, error) {})
+	if err != deadlock || calls != 1 {
+		t.Errorf("err = %v after %d calls, want the first error", err, calls)
+	}
+}
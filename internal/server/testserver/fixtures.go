@@ -0,0 +1,104 @@
+package testserver
+
+import (
+	"context"
+	"testing"
+
+	"heisei/internal/common/models"
+)
+
+// Fixtures are the records created by Seed. Since every server has its own
+// database, their IDs are the same in every test.
+type Fixtures struct {
+	// Section is a top-level category holding News and Archive
+	Section *models.CategoryDTO
+	News    *models.CategoryDTO
+	// Archive is read-only
+	Archive *models.CategoryDTO
+
+	// Welcome is a thread in News with WelcomePosts, the last one with sage
+	Welcome      *models.ThreadDTO
+	WelcomePosts []*models.PostDTO
+	// Locked is a locked thread in News with one post
+	Locked *models.ThreadDTO
+	// Old is a thread in Archive with one post, created before the category
+	// was made read-only
+	Old *models.ThreadDTO
+}
+
+// Seed creates the fixtures through the services
+func (s *Server) Seed(t testing.TB) *Fixtures {
+	t.Helper()
+	ctx := context.Background()
+	svc := s.Services
+	f := &Fixtures{}
+	check := func(err error) {
+		t.Helper()
+		if err != nil {
+			t.Fatalf("failed to seed fixtures: %v", err)
+		}
+	}
+
+	var err error
+	f.Section, err = svc.Category.CreateCategory(ctx, models.CategoryDTO{
+		Name:        "Boards",
+		Slug:        "boards",
+		DefaultSort: models.ThreadSortBump,
+	})
+	check(err)
+	f.News, err = svc.Category.CreateCategory(ctx, models.CategoryDTO{
+		ParentID:    &f.Section.ID,
+		Name:        "News",
+		Slug:        "news",
+		Description: "Current events",
+		DefaultSort: models.ThreadSortBump,
+		MaxPosts:    models.DefaultMaxPosts,
+	})
+	check(err)
+	f.Archive, err = svc.Category.CreateCategory(ctx, models.CategoryDTO{
+		ParentID:    &f.Section.ID,
+		Position:    1,
+		Name:        "Archive",
+		Slug:        "archive",
+		DefaultSort: models.ThreadSortCreated,
+		MaxPosts:    models.DefaultMaxPosts,
+	})
+	check(err)
+
+	f.Welcome, err = svc.Thread.CreateThread(ctx, models.ThreadDTO{CategoryID: f.News.ID, Title: "Welcome"})
+	check(err)
+	for i, content := range []string{"First post", "Second post", "Sage post"} {
+		post, err := svc.Post.CreatePost(ctx, models.PostDTO{
+			ThreadID: f.Welcome.ID,
+			Content:  content,
+			AuthorIP: "192.0.2.1",
+			Sage:     i == 2,
+		})
+		check(err)
+		f.WelcomePosts = append(f.WelcomePosts, post)
+	}
+
+	f.Locked, err = svc.Thread.CreateThread(ctx, models.ThreadDTO{CategoryID: f.News.ID, Title: "Locked"})
+	check(err)
+	_, err = svc.Post.CreatePost(ctx, models.PostDTO{ThreadID: f.Locked.ID, Content: "Closed", AuthorIP: "192.0.2.2"})
+	check(err)
+	check(svc.Thread.SetLocked(ctx, f.Locked.ID, true))
+
+	f.Old, err = svc.Thread.CreateThread(ctx, models.ThreadDTO{CategoryID: f.Archive.ID, Title: "Old"})
+	check(err)
+	_, err = svc.Post.CreatePost(ctx, models.PostDTO{ThreadID: f.Old.ID, Content: "Archived", AuthorIP: "192.0.2.3"})
+	check(err)
+	archive := *f.Archive
+	archive.ReadOnly = true
+	f.Archive, err = svc.Category.UpdateCategory(ctx, archive.ID, archive)
+	check(err)
+
+	// Read back the counters maintained by the database
+	f.Welcome, err = svc.Thread.GetThreadByID(ctx, f.Welcome.ID)
+	check(err)
+	f.Locked, err = svc.Thread.GetThreadByID(ctx, f.Locked.ID)
+	check(err)
+	f.Old, err = svc.Thread.GetThreadByID(ctx, f.Old.ID)
+	check(err)
+	return f
+}
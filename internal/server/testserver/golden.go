@@ -0,0 +1,90 @@
+package testserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// update rewrites the golden files with the actual responses:
+//
+//	go test -tags integration ./... -run TestGolden -update
+var update = flag.Bool("update", false, "rewrite golden files")
+
+// Placeholders of the values that differ between runs
+const (
+	timePlaceholder      = "<time>"
+	requestIDPlaceholder = "<request_id>"
+)
+
+// AssertGolden compares a JSON response with testdata/<name>.golden.json.
+// Timestamps and request IDs are replaced by placeholders, and the JSON is
+// indented with sorted keys, so that the files are stable and readable.
+func AssertGolden(t testing.TB, name string, body []byte) {
+	t.Helper()
+	got, err := normalizeJSON(body)
+	if err != nil {
+		t.Fatalf("invalid JSON response %q: %v", body, err)
+	}
+
+	path := filepath.Join("testdata", name+".golden.json")
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v; run with -update to create it", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("response differs from %s; run with -update if the change is intended\ngot:\n%s\nwant:\n%s", path, got, want)
+	}
+}
+
+func normalizeJSON(body []byte) ([]byte, error) {
+	var v interface{}
+	if err := json.Unmarshal(body, &v); err != nil {
+		return nil, err
+	}
+	var out bytes.Buffer
+	enc := json.NewEncoder(&out)
+	// Keep the placeholders readable
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(normalize("", v)); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// normalize replaces the values of timestamp fields, named *_at, and of
+// request IDs
+func normalize(key string, v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, value := range v {
+			v[k] = normalize(k, value)
+		}
+	case []interface{}:
+		for i, value := range v {
+			v[i] = normalize("", value)
+		}
+	case string:
+		switch {
+		case strings.HasSuffix(key, "_at"):
+			return timePlaceholder
+		case key == "request_id":
+			return requestIDPlaceholder
+		}
+	}
+	return v
+}
@@ -0,0 +1,37 @@
+package testserver
+
+import "testing"
+
+func TestNormalizeJSON(t *testing.T) {
+	body := `[{"id":1,"created_at":"2024-05-01T10:00:00Z","last_post_at":null,"tags":["x_at"],` +
+		`"nested":{"bumped_at":"2024-05-01T10:00:00Z","post_count":2}},{"request_id":"abc","message":"m"}]`
+	want := `[
+  {
+    "created_at": "<time>",
+    "id": 1,
+    "last_post_at": null,
+    "nested": {
+      "bumped_at": "<time>",
+      "post_count": 2
+    },
+    "tags": [
+      "x_at"
+    ]
+  },
+  {
+    "message": "m",
+    "request_id": "<request_id>"
+  }
+]
+`
+	got, err := normalizeJSON([]byte(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+	if _, err := normalizeJSON([]byte("{")); err == nil {
+		t.Error("invalid JSON was accepted")
+	}
+}
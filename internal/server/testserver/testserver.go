@@ -0,0 +1,98 @@
+// Package testserver runs the full HTTP server against an ephemeral database
+// for end-to-end tests.
+//
+// Packages using it must run their tests through dbtest.Main:
+//
+//	func TestMain(m *testing.M) { dbtest.Main(m) }
+package testserver
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"heisei/internal/client/api"
+	"heisei/internal/server/api/handlers"
+	"heisei/internal/server/api/openapi"
+	"heisei/internal/server/config"
+	"heisei/internal/server/repositories"
+	"heisei/internal/server/services"
+	"heisei/pkg/database/dbtest"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// queryTimeout bounds the database time of each request, as configured in
+// the sample configuration
+const queryTimeout = 10 * time.Second
+
+// Server is a running server with its own database
+type Server struct {
+	DB       *gorm.DB
+	Services *services.Services
+	// URL is the base URL of the server, as passed to api.NewClient
+	URL string
+	// Client talks to the server without retries
+	Client *api.Client
+	http   *httptest.Server
+}
+
+// New starts a server on a new database. It is stopped and the database is
+// dropped when the test ends; the test is skipped if no database is available.
+func New(t testing.TB) *Server {
+	t.Helper()
+	db := dbtest.New(t)
+	logger := zap.NewNop()
+	svc := services.NewServices(repositories.NewRepositories(db), logger)
+
+	// The handler of cmd/server
+	cfg := &config.Config{Database: config.DatabaseConfig{QueryTimeout: queryTimeout}}
+	srv := httptest.NewServer(handlers.NewHandler(svc, cfg, logger))
+	t.Cleanup(srv.Close)
+
+	return &Server{
+		DB:       db,
+		Services: svc,
+		URL:      srv.URL,
+		Client:   api.NewClient(srv.URL, api.WithHTTPClient(srv.Client()), api.WithRetries(0, 0)),
+		http:     srv,
+	}
+}
+
+// Do sends a request to path under the API prefix, e.g. /categories, and
+// returns the response with its body read
+func (s *Server) Do(t testing.TB, method, path string, body []byte) (*http.Response, []byte) {
+	t.Helper()
+	req, err := http.NewRequest(method, s.URL+openapi.BasePath+path, bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := s.http.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp, data
+}
+
+// Get requests path under the API prefix and fails the test unless it
+// succeeds; it returns the response body
+func (s *Server) Get(t testing.TB, path string) []byte {
+	t.Helper()
+	resp, body := s.Do(t, http.MethodGet, path, nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET %s: status %d: %s", path, resp.StatusCode, body)
+	}
+	return body
+}
@@ -0,0 +1 @@
+ALTER TABLE threads ADD COLUMN locked BOOLEAN NOT NULL DEFAULT FALSE;
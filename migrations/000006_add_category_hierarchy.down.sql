@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS idx_categories_parent_position;
+
+ALTER TABLE categories
+    DROP COLUMN IF EXISTS read_only,
+    DROP COLUMN IF EXISTS max_posts,
+    DROP COLUMN IF EXISTS default_sort,
+    DROP COLUMN IF EXISTS description,
+    DROP COLUMN IF EXISTS position,
+    DROP COLUMN IF EXISTS parent_id;
@@ -0,0 +1,9 @@
+ALTER TABLE categories
+    ADD COLUMN parent_id INTEGER REFERENCES categories(id) ON DELETE SET NULL,
+    ADD COLUMN position INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN description VARCHAR(500) NOT NULL DEFAULT '',
+    ADD COLUMN default_sort VARCHAR(20) NOT NULL DEFAULT 'bump',
+    ADD COLUMN max_posts INTEGER NOT NULL DEFAULT 1000,
+    ADD COLUMN read_only BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE INDEX idx_categories_parent_position ON categories(parent_id, position);
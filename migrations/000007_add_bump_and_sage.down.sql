@@ -0,0 +1,15 @@
+CREATE OR REPLACE FUNCTION update_thread_on_post() RETURNS TRIGGER AS $$
+BEGIN
+  UPDATE threads
+  SET last_post_at = NEW.created_at,
+      post_count = post_count + 1,
+      updated_at = CURRENT_TIMESTAMP
+  WHERE id = NEW.thread_id;
+  RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+ALTER TABLE posts DROP COLUMN IF EXISTS sage;
+
+DROP INDEX IF EXISTS idx_threads_category_bumped_at;
+ALTER TABLE threads DROP COLUMN IF EXISTS bumped_at;
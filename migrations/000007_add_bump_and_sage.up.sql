@@ -0,0 +1,18 @@
+ALTER TABLE threads ADD COLUMN bumped_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP;
+UPDATE threads SET bumped_at = last_post_at;
+CREATE INDEX idx_threads_category_bumped_at ON threads(category_id, bumped_at DESC);
+
+ALTER TABLE posts ADD COLUMN sage BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Posts marked sage do not bump their thread
+CREATE OR REPLACE FUNCTION update_thread_on_post() RETURNS TRIGGER AS $$
+BEGIN
+  UPDATE threads
+  SET last_post_at = NEW.created_at,
+      bumped_at = CASE WHEN NEW.sage THEN bumped_at ELSE NEW.created_at END,
+      post_count = post_count + 1,
+      updated_at = CURRENT_TIMESTAMP
+  WHERE id = NEW.thread_id;
+  RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,10 @@
+CREATE TABLE bans (
+    id SERIAL PRIMARY KEY,
+    network CIDR NOT NULL,
+    reason VARCHAR(500) NOT NULL DEFAULT '',
+    expires_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Lets the containment lookups of new posts use an index
+CREATE INDEX idx_bans_network ON bans USING gist (network inet_ops);
@@ -0,0 +1,14 @@
+DROP TRIGGER IF EXISTS trigger_update_thread_on_post_change ON posts;
+DROP FUNCTION IF EXISTS update_thread_on_post_change();
+
+CREATE OR REPLACE FUNCTION update_thread_on_post() RETURNS TRIGGER AS $$
+BEGIN
+  UPDATE threads
+  SET last_post_at = NEW.created_at,
+      bumped_at = CASE WHEN NEW.sage THEN bumped_at ELSE NEW.created_at END,
+      post_count = post_count + 1,
+      updated_at = CURRENT_TIMESTAMP
+  WHERE id = NEW.thread_id;
+  RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
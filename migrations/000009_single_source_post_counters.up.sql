@@ -0,0 +1,61 @@
+-- The triggers are the only writers of post_count and last_post_at. Only posts
+-- that are not deleted are counted, so soft deletes and restores update the
+-- counters as well.
+CREATE OR REPLACE FUNCTION update_thread_on_post() RETURNS TRIGGER AS $$
+BEGIN
+  IF NEW.is_deleted THEN
+    RETURN NEW;
+  END IF;
+  UPDATE threads
+  SET last_post_at = GREATEST(last_post_at, NEW.created_at),
+      bumped_at = CASE WHEN NEW.sage THEN bumped_at ELSE GREATEST(bumped_at, NEW.created_at) END,
+      post_count = post_count + 1,
+      updated_at = CURRENT_TIMESTAMP
+  WHERE id = NEW.thread_id;
+  RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE FUNCTION update_thread_on_post_change() RETURNS TRIGGER AS $$
+DECLARE
+  delta INTEGER;
+BEGIN
+  IF TG_OP = 'DELETE' THEN
+    IF OLD.is_deleted THEN
+      RETURN NULL;
+    END IF;
+    delta := -1;
+  ELSIF OLD.is_deleted = NEW.is_deleted THEN
+    RETURN NULL;
+  ELSIF NEW.is_deleted THEN
+    delta := -1;
+  ELSE
+    delta := 1;
+  END IF;
+
+  -- Threads without posts fall back to their creation time
+  UPDATE threads
+  SET post_count = post_count + delta,
+      last_post_at = COALESCE(
+        (SELECT MAX(created_at) FROM posts WHERE thread_id = threads.id AND NOT is_deleted),
+        created_at),
+      updated_at = CURRENT_TIMESTAMP
+  WHERE id = OLD.thread_id;
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trigger_update_thread_on_post_change
+AFTER UPDATE OF is_deleted OR DELETE ON posts
+FOR EACH ROW
+EXECUTE FUNCTION update_thread_on_post_change();
+
+-- Posts were counted twice, by the trigger and by the application
+UPDATE threads
+SET post_count = counts.post_count, last_post_at = counts.last_post_at
+FROM (
+  SELECT t.id, COUNT(p.id) AS post_count, COALESCE(MAX(p.created_at), t.created_at) AS last_post_at
+  FROM threads t LEFT JOIN posts p ON p.thread_id = t.id AND NOT p.is_deleted
+  GROUP BY t.id
+) counts
+WHERE threads.id = counts.id;
@@ -0,0 +1,3 @@
+-- Posts are written with the timestamps of BaseModel like the other tables
+ALTER TABLE posts ADD COLUMN updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP;
+UPDATE posts SET updated_at = created_at;
@@ -0,0 +1,5 @@
+DROP TRIGGER IF EXISTS trigger_number_post ON posts;
+DROP FUNCTION IF EXISTS number_post();
+ALTER TABLE threads DROP COLUMN IF EXISTS last_post_number;
+DROP INDEX IF EXISTS idx_posts_thread_number;
+ALTER TABLE posts DROP COLUMN IF EXISTS number;
@@ -0,0 +1,38 @@
+-- Posts keep the number they were given when written, so that >>N references
+-- still point at the same post after earlier posts are deleted. Deleted posts
+-- are numbered as well, and numbers are never reused.
+ALTER TABLE posts ADD COLUMN number INTEGER;
+UPDATE posts
+SET number = numbered.number
+FROM (
+  SELECT id, ROW_NUMBER() OVER (PARTITION BY thread_id ORDER BY created_at, id) AS number
+  FROM posts
+) numbered
+WHERE posts.id = numbered.id;
+ALTER TABLE posts ALTER COLUMN number SET NOT NULL;
+CREATE UNIQUE INDEX idx_posts_thread_number ON posts (thread_id, number);
+
+ALTER TABLE threads ADD COLUMN last_post_number INTEGER NOT NULL DEFAULT 0;
+UPDATE threads
+SET last_post_number = COALESCE((SELECT MAX(number) FROM posts WHERE thread_id = threads.id), 0);
+
+-- Taking the next number locks the thread row until the post is stored, so
+-- concurrent posts are numbered in the order they commit
+CREATE FUNCTION number_post() RETURNS TRIGGER AS $$
+BEGIN
+  UPDATE threads
+  SET last_post_number = last_post_number + 1
+  WHERE id = NEW.thread_id
+  RETURNING last_post_number INTO NEW.number;
+  IF NOT FOUND THEN
+    -- Left to the foreign key to report the missing thread
+    NEW.number := 0;
+  END IF;
+  RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trigger_number_post
+BEFORE INSERT ON posts
+FOR EACH ROW
+EXECUTE FUNCTION number_post();
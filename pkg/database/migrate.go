@@ -1,21 +1,33 @@
 package database
 
 import (
+	"context"
 	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+
 	"github.com/golang-migrate/migrate/v4"
 	"github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/source"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
 )
 
+// MigrationsURL is the location of the migration files
+const MigrationsURL = "file://migrations"
+
 func RunMigrations(db *sql.DB) error {
+	return RunMigrationsFrom(db, MigrationsURL)
+}
+
+// RunMigrationsFrom applies the migrations found at sourceURL, e.g.
+// file:///path/to/migrations
+func RunMigrationsFrom(db *sql.DB, sourceURL string) error {
 	driver, err := postgres.WithInstance(db, &postgres.Config{})
 	if err != nil {
 		return err
 	}
-
-	m, err := migrate.NewWithDatabaseInstance(
-		"file://migrations",
-		"postgres", driver)
+	m, err := migrate.NewWithDatabaseInstance(sourceURL, "postgres", driver)
 	if err != nil {
 		return err
 	}
@@ -26,3 +38,85 @@ func RunMigrations(db *sql.DB) error {
 
 	return nil
 }
+
+// MigrateSteps applies the next n migrations, or rolls back the last -n
+// migrations if n is negative
+func MigrateSteps(db *sql.DB, n int) error {
+	m, err := newMigrate(db)
+	if err != nil {
+		return err
+	}
+	return m.Steps(n)
+}
+
+// ForceMigration sets the migration version without running any migration,
+// which clears the dirty flag left by a failed migration
+func ForceMigration(db *sql.DB, version int) error {
+	m, err := newMigrate(db)
+	if err != nil {
+		return err
+	}
+	return m.Force(version)
+}
+
+// newMigrate creates a migrate instance for the migration files. It is not
+// closed, since that would close db as well.
+func newMigrate(db *sql.DB) (*migrate.Migrate, error) {
+	driver, err := postgres.WithInstance(db, &postgres.Config{})
+	if err != nil {
+		return nil, err
+	}
+	return migrate.NewWithDatabaseInstance(MigrationsURL, "postgres", driver)
+}
+
+// MigrationVersion returns the migration version currently applied to the database.
+// It uses a dedicated connection so that the shared pool is left open afterwards.
+func MigrationVersion(ctx context.Context, db *sql.DB) (uint, bool, error) {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to get connection: %w", err)
+	}
+
+	driver, err := postgres.WithConnection(ctx, conn, &postgres.Config{})
+	if err != nil {
+		conn.Close()
+		return 0, false, err
+	}
+
+	m, err := migrate.NewWithDatabaseInstance(MigrationsURL, "postgres", driver)
+	if err != nil {
+		driver.Close()
+		return 0, false, err
+	}
+	defer m.Close()
+
+	version, dirty, err := m.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		return 0, false, nil
+	}
+	return version, dirty, err
+}
+
+// LatestMigrationVersion returns the highest version found in the migration files
+func LatestMigrationVersion() (uint, error) {
+	src, err := source.Open(MigrationsURL)
+	if err != nil {
+		return 0, err
+	}
+	defer src.Close()
+
+	version, err := src.First()
+	if err != nil {
+		return 0, err
+	}
+	for {
+		next, err := src.Next(version)
+		if errors.Is(err, os.ErrNotExist) {
+			return version, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		version = next
+	}
+}
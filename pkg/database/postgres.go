@@ -1,6 +1,7 @@
 package database
 
 import (
+	"context"
 	"fmt"
 	"time"
 
@@ -27,6 +28,10 @@ func NewDatabase(cfg *config.Config) (*Database, error) {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
 
+	if err := db.Use(NewTracingPlugin()); err != nil {
+		return nil, fmt.Errorf("failed to register tracing plugin: %w", err)
+	}
+
 	// Set connection pool settings
 	sqlDB, err := db.DB()
 	if err != nil {
@@ -58,6 +63,15 @@ func (db *Database) Ping() error {
 	return sqlDB.Ping()
 }
 
+// PingContext checks the database connection, honouring the context deadline
+func (db *Database) PingContext(ctx context.Context) error {
+	sqlDB, err := db.DB.DB()
+	if err != nil {
+		return fmt.Errorf("failed to get database instance: %w", err)
+	}
+	return sqlDB.PingContext(ctx)
+}
+
 // RunMigrations runs database migrations
 func (db *Database) RunMigrations() error {
 	return db.AutoMigrate(
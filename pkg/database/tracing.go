@@ -0,0 +1,89 @@
+package database
+
+import (
+	"errors"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+const spanInstanceKey = "heisei:tracing_span"
+
+// TracingPlugin is a GORM plugin that records a span for every query
+type TracingPlugin struct {
+	tracer trace.Tracer
+}
+
+// NewTracingPlugin creates a tracing plugin using the global tracer provider
+func NewTracingPlugin() *TracingPlugin {
+	return &TracingPlugin{tracer: otel.Tracer("heisei/pkg/database")}
+}
+
+// Name returns the name of the plugin
+func (p *TracingPlugin) Name() string {
+	return "heisei:tracing"
+}
+
+// Initialize registers the tracing callbacks around each GORM operation
+func (p *TracingPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	operations := []struct {
+		name   string
+		before func(string, func(*gorm.DB)) error
+		after  func(string, func(*gorm.DB)) error
+	}{
+		{"create", cb.Create().Before("gorm:create").Register, cb.Create().After("gorm:create").Register},
+		{"query", cb.Query().Before("gorm:query").Register, cb.Query().After("gorm:query").Register},
+		{"update", cb.Update().Before("gorm:update").Register, cb.Update().After("gorm:update").Register},
+		{"delete", cb.Delete().Before("gorm:delete").Register, cb.Delete().After("gorm:delete").Register},
+		{"row", cb.Row().Before("gorm:row").Register, cb.Row().After("gorm:row").Register},
+		{"raw", cb.Raw().Before("gorm:raw").Register, cb.Raw().After("gorm:raw").Register},
+	}
+
+	for _, op := range operations {
+		if err := op.before("tracing:before_"+op.name, p.before("gorm."+op.name)); err != nil {
+			return err
+		}
+		if err := op.after("tracing:after_"+op.name, p.after); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *TracingPlugin) before(name string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		if db.Statement == nil || db.Statement.Context == nil {
+			return
+		}
+		ctx, span := p.tracer.Start(db.Statement.Context, name, trace.WithSpanKind(trace.SpanKindClient))
+		db.Statement.Context = ctx
+		db.InstanceSet(spanInstanceKey, span)
+	}
+}
+
+func (p *TracingPlugin) after(db *gorm.DB) {
+	v, ok := db.InstanceGet(spanInstanceKey)
+	if !ok {
+		return
+	}
+	span, ok := v.(trace.Span)
+	if !ok {
+		return
+	}
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("db.system", "postgresql"),
+		attribute.String("db.statement", db.Statement.SQL.String()),
+		attribute.String("db.sql.table", db.Statement.Table),
+		attribute.Int64("db.rows_affected", db.RowsAffected),
+	)
+	if db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound) {
+		span.RecordError(db.Error)
+		span.SetStatus(codes.Error, db.Error.Error())
+	}
+}
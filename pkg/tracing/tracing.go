@@ -0,0 +1,154 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// Supported exporters
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterFile   = "file"
+	ExporterOTLP   = "otlp"
+)
+
+// Config holds the tracing configuration shared by the server and the client
+type Config struct {
+	// Exporter is one of none, stdout, file or otlp
+	Exporter string `yaml:"exporter"`
+	// Endpoint is the OTLP/HTTP collector address, e.g. localhost:4318
+	Endpoint string `yaml:"endpoint"`
+	// Insecure disables TLS for the OTLP exporter
+	Insecure bool `yaml:"insecure"`
+	// FilePath is where spans are written when Exporter is file
+	FilePath string `yaml:"file_path"`
+	// SampleRatio is the fraction of new traces that are recorded, from 0 to 1.
+	// Zero is treated as 1 so that an unset value records everything.
+	SampleRatio float64 `yaml:"sample_ratio"`
+}
+
+// OverrideWithEnv overrides the configuration with TRACING_* environment variables
+func (c *Config) OverrideWithEnv() {
+	if exporter := os.Getenv("TRACING_EXPORTER"); exporter != "" {
+		c.Exporter = exporter
+	}
+	if endpoint := os.Getenv("TRACING_ENDPOINT"); endpoint != "" {
+		c.Endpoint = endpoint
+	}
+	if insecure := os.Getenv("TRACING_INSECURE"); insecure != "" {
+		c.Insecure = insecure == "true"
+	}
+	if filePath := os.Getenv("TRACING_FILE_PATH"); filePath != "" {
+		c.FilePath = filePath
+	}
+	if ratio := os.Getenv("TRACING_SAMPLE_RATIO"); ratio != "" {
+		if r, err := strconv.ParseFloat(ratio, 64); err == nil {
+			c.SampleRatio = r
+		}
+	}
+}
+
+// Validate checks the tracing configuration
+func (c *Config) Validate() error {
+	switch c.Exporter {
+	case "", ExporterNone, ExporterStdout:
+	case ExporterFile:
+		if c.FilePath == "" {
+			return fmt.Errorf("tracing file path is required for the file exporter")
+		}
+	case ExporterOTLP:
+		if c.Endpoint == "" {
+			return fmt.Errorf("tracing endpoint is required for the otlp exporter")
+		}
+	default:
+		return fmt.Errorf("unknown tracing exporter: %s", c.Exporter)
+	}
+	if c.SampleRatio < 0 || c.SampleRatio > 1 {
+		return fmt.Errorf("invalid tracing sample ratio: %v", c.SampleRatio)
+	}
+	return nil
+}
+
+// ShutdownFunc flushes pending spans and releases the exporter
+type ShutdownFunc func(context.Context) error
+
+// Init installs the global tracer provider and W3C trace context propagator.
+// With the none exporter spans are still created, so trace IDs propagate and
+// show up in logs, but nothing is exported.
+func Init(cfg Config, serviceName string) (ShutdownFunc, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	res := resource.NewSchemaless(attribute.String("service.name", serviceName))
+
+	ratio := cfg.SampleRatio
+	if ratio == 0 {
+		ratio = 1
+	}
+	opts := []sdktrace.TracerProviderOption{
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))),
+	}
+
+	var closer io.Closer
+	switch cfg.Exporter {
+	case "", ExporterNone:
+	case ExporterStdout:
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(os.Stderr))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create stdout exporter: %w", err)
+		}
+		opts = append(opts, sdktrace.WithBatcher(exporter))
+	case ExporterFile:
+		file, err := os.OpenFile(cfg.FilePath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open trace file: %w", err)
+		}
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(file))
+		if err != nil {
+			file.Close()
+			return nil, fmt.Errorf("failed to create file exporter: %w", err)
+		}
+		closer = file
+		opts = append(opts, sdktrace.WithBatcher(exporter))
+	case ExporterOTLP:
+		clientOpts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(cfg.Endpoint)}
+		if cfg.Insecure {
+			clientOpts = append(clientOpts, otlptracehttp.WithInsecure())
+		}
+		exporter, err := otlptracehttp.New(context.Background(), clientOpts...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create otlp exporter: %w", err)
+		}
+		opts = append(opts, sdktrace.WithBatcher(exporter))
+	default:
+		return nil, fmt.Errorf("unknown tracing exporter: %s", cfg.Exporter)
+	}
+
+	provider := sdktrace.NewTracerProvider(opts...)
+	otel.SetTracerProvider(provider)
+
+	return func(ctx context.Context) error {
+		err := provider.Shutdown(ctx)
+		if closer != nil {
+			if cerr := closer.Close(); err == nil {
+				err = cerr
+			}
+		}
+		return err
+	}, nil
+}
@@ -1,8 +1,11 @@
 package utils
 
 import (
+	"context"
+	"io"
 	"os"
 
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
@@ -10,6 +13,12 @@ import (
 var Logger *zap.Logger
 
 func InitLogger(level string) {
+	Logger = NewLogger(level, os.Stdout)
+}
+
+// NewLogger creates a JSON logger writing to w, for programs whose stdout is
+// taken by other output
+func NewLogger(level string, w io.Writer) *zap.Logger {
 	// Set the log level
 	var zapLevel zapcore.Level
 	switch level {
@@ -33,12 +42,12 @@ func InitLogger(level string) {
 	// Create a new core
 	core := zapcore.NewCore(
 		zapcore.NewJSONEncoder(encoderConfig),
-		zapcore.AddSync(os.Stdout),
+		zapcore.AddSync(w),
 		zapLevel,
 	)
 
 	// Create a new logger
-	Logger = zap.New(core, zap.AddCaller(), zap.AddStacktrace(zap.ErrorLevel))
+	return zap.New(core, zap.AddCaller(), zap.AddStacktrace(zap.ErrorLevel))
 }
 
 func GetLogger() *zap.Logger {
@@ -47,3 +56,16 @@ func GetLogger() *zap.Logger {
 	}
 	return Logger
 }
+
+// TraceFields returns the trace and span IDs of the span in ctx as log fields.
+// It returns nil if ctx carries no valid span.
+func TraceFields(ctx context.Context) []zap.Field {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return nil
+	}
+	return []zap.Field{
+		zap.String("trace_id", sc.TraceID().String()),
+		zap.String("span_id", sc.SpanID().String()),
+	}
+}
@@ -0,0 +1,40 @@
+package utils
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+
+	"go.uber.org/zap"
+)
+
+type requestIDKey struct{}
+
+// NewRequestID generates a random request ID
+func NewRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
+
+// ContextWithRequestID returns a copy of ctx carrying the request ID
+func ContextWithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestIDFromContext returns the request ID stored in ctx, or an empty string
+func RequestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
+
+// RequestFields returns the request ID and trace IDs in ctx as log fields
+func RequestFields(ctx context.Context) []zap.Field {
+	fields := TraceFields(ctx)
+	if requestID := RequestIDFromContext(ctx); requestID != "" {
+		fields = append(fields, zap.String("request_id", requestID))
+	}
+	return fields
+}
@@ -27,10 +27,13 @@ func ValidateThreadTitle(title string) bool {
 	return utf8.RuneCountInString(title) >= 3 && utf8.RuneCountInString(title) <= 100
 }
 
+// MaxPostContentLength is the maximum number of runes in a post
+const MaxPostContentLength = 10000
+
 // ValidatePostContent checks if the post content is valid
 func ValidatePostContent(content string) bool {
 	// Validate the post content length
-	return utf8.RuneCountInString(content) >= 1 && utf8.RuneCountInString(content) <= 10000
+	return utf8.RuneCountInString(content) >= 1 && utf8.RuneCountInString(content) <= MaxPostContentLength
 }
 
 // SanitizeInput removes or escapes potentially harmful characters